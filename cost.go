@@ -0,0 +1,66 @@
+package graphql
+
+import (
+	"github.com/graph-gophers/graphql-go/ast"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/validation"
+)
+
+// CostDirective declares the @cost directive, which overrides the cost of a field in the static cost analysis
+// enabled with [MaxComplexity] or [FieldCost]. Add it to the schema to use the directive, for example:
+//
+//	type Query {
+//		users(first: Int, last: Int): [User!]! @cost(weight: 2, multipliers: ["first", "last"])
+//	}
+//
+// The weight replaces the default cost of 1 of the field. The multipliers name the arguments whose values multiply
+// the cost of the field and its selections. List arguments count with their length.
+const CostDirective = `
+directive @cost(weight: Int! = 1, multipliers: [String!]) on FIELD_DEFINITION
+`
+
+// FieldCostFunc returns the cost of a field in the static cost analysis. The cost of the selections of the field
+// is passed as childCost and args holds the values of the arguments of the field. If ok is false, the cost of the
+// field is computed from its @cost directive or the defaults.
+type FieldCostFunc func(typeName, fieldName string, args map[string]interface{}, childCost int) (cost int, ok bool)
+
+// MaxComplexity specifies the maximum cost of an operation. Operations which exceed it are rejected before they
+// are executed. The cost of a field is (weight + cost of its selections) * multiplier, where the weight defaults to
+// 1 and fields returning lists are multiplied by their first and last arguments. Use [CostDirective] or [FieldCost]
+// to change the costs. The computed cost is reported in the "cost" entry of the response extensions.
+// The default is 0 which disables max complexity checking.
+func MaxComplexity(n int) SchemaOpt {
+	return func(s *Schema) {
+		s.maxComplexity = n
+	}
+}
+
+// FieldCost sets a function which computes the cost of the fields in the static cost analysis. It enables the
+// cost analysis and the reporting of the cost in the response extensions even without [MaxComplexity].
+func FieldCost(fn FieldCostFunc) SchemaOpt {
+	return func(s *Schema) {
+		s.fieldCost = fn
+	}
+}
+
+// complexity computes the cost of the operation if the cost analysis is enabled. It returns the extensions which
+// report the cost and an error if the cost exceeds the maximum complexity.
+func (s *Schema) complexity(doc *ast.ExecutableDefinition, op *ast.OperationDefinition, variables map[string]interface{}) (map[string]interface{}, *errors.QueryError) {
+	if s.maxComplexity <= 0 && s.fieldCost == nil {
+		return nil, nil
+	}
+
+	cost := validation.Complexity(s.schema, doc, op, variables, validation.CostFunc(s.fieldCost))
+	report := map[string]interface{}{"requested": cost}
+	if s.maxComplexity > 0 {
+		report["maximum"] = s.maxComplexity
+	}
+	extensions := map[string]interface{}{"cost": report}
+	if s.maxComplexity > 0 && cost > s.maxComplexity {
+		qErr := errors.Errorf("The operation has a cost of %d that exceeds the max complexity %d", cost, s.maxComplexity)
+		qErr.Locations = []errors.Location{op.Loc}
+		qErr.Rule = "MaxComplexityExceeded"
+		return extensions, qErr
+	}
+	return extensions, nil
+}
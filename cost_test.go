@@ -0,0 +1,120 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+const costSchema = graphql.CostDirective + `
+	type Query {
+		users(first: Int): [User!]!
+		report: String! @cost(weight: 50)
+	}
+
+	type User {
+		name: String!
+		friends(first: Int): [User!]! @cost(weight: 2, multipliers: ["first"])
+	}
+`
+
+type costQuery struct{}
+
+func (costQuery) Users(args struct{ First *int32 }) []*costUser {
+	return []*costUser{{"alice"}}
+}
+
+func (costQuery) Report() string { return "ok" }
+
+type costUser struct{ name string }
+
+func (u *costUser) Name() string { return u.name }
+
+func (u *costUser) Friends(args struct{ First *int32 }) []*costUser { return nil }
+
+func TestMaxComplexity(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []graphql.SchemaOpt
+		query     string
+		variables map[string]interface{}
+		want      string
+	}{
+		{
+			name:  "disabled",
+			query: `{ users(first: 1000) { friends(first: 1000) { name } } }`,
+			want:  `{"data":{"users":[{"friends":[]}]}}`,
+		},
+		{
+			name:  "within_budget",
+			opts:  []graphql.SchemaOpt{graphql.MaxComplexity(100)},
+			query: `{ users(first: 10) { name } }`,
+			want:  `{"data":{"users":[{"name":"alice"}]},"extensions":{"cost":{"maximum":100,"requested":20}}}`,
+		},
+		{
+			name:  "over_budget",
+			opts:  []graphql.SchemaOpt{graphql.MaxComplexity(100)},
+			query: `{ users(first: 1000) { friends(first: 1000) { name } } }`,
+			want:  `{"errors":[{"message":"The operation has a cost of 3001000 that exceeds the max complexity 100","locations":[{"line":1,"column":1}]}],"extensions":{"cost":{"maximum":100,"requested":3001000}}}`,
+		},
+		{
+			name:      "variables",
+			opts:      []graphql.SchemaOpt{graphql.MaxComplexity(100)},
+			query:     `query($n: Int) { users(first: $n) { name } }`,
+			variables: map[string]interface{}{"n": 60},
+			want:      `{"errors":[{"message":"The operation has a cost of 120 that exceeds the max complexity 100","locations":[{"line":1,"column":1}]}],"extensions":{"cost":{"maximum":100,"requested":120}}}`,
+		},
+		{
+			name:  "directive_weight",
+			opts:  []graphql.SchemaOpt{graphql.MaxComplexity(10)},
+			query: `{ report }`,
+			want:  `{"errors":[{"message":"The operation has a cost of 50 that exceeds the max complexity 10","locations":[{"line":1,"column":1}]}],"extensions":{"cost":{"maximum":10,"requested":50}}}`,
+		},
+		{
+			name: "field_cost",
+			opts: []graphql.SchemaOpt{graphql.FieldCost(func(typeName, fieldName string, args map[string]interface{}, childCost int) (int, bool) {
+				if fieldName == "report" {
+					return 0, true
+				}
+				return 0, false
+			})},
+			query: `{ report users { name } }`,
+			want:  `{"data":{"report":"ok","users":[{"name":"alice"}]},"extensions":{"cost":{"requested":2}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := graphql.MustParseSchema(costSchema, &costQuery{}, tt.opts...)
+			resp := s.Exec(context.Background(), tt.query, "", tt.variables)
+			got, err := json.Marshal(resp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxComplexity_Prepared(t *testing.T) {
+	s := graphql.MustParseSchema(costSchema, &costQuery{}, graphql.MaxComplexity(100))
+	p, errs := s.Prepare(`query($n: Int) { users(first: $n) { name } }`, "")
+	if errs != nil {
+		t.Fatal(errs)
+	}
+
+	resp := p.Exec(context.Background(), map[string]interface{}{"n": 10})
+	if len(resp.Errors) != 0 || resp.Extensions["cost"].(map[string]interface{})["requested"] != 20 {
+		t.Errorf("got errors %v and extensions %v", resp.Errors, resp.Extensions)
+	}
+	resp = p.Exec(context.Background(), map[string]interface{}{"n": 100})
+	if len(resp.Errors) != 1 || resp.Errors[0].Rule != "MaxComplexityExceeded" {
+		t.Errorf("got errors %v, want the max complexity to be exceeded", resp.Errors)
+	}
+}
@@ -0,0 +1,36 @@
+package directives
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go/ast"
+)
+
+// FieldInfo describes the field which is resolved by a FieldMiddleware.
+type FieldInfo struct {
+	// TypeName is the name of the object type the field belongs to.
+	TypeName string
+	// Definition is the definition of the field in the schema.
+	Definition *ast.FieldDefinition
+	// Args holds the values of the arguments of the field, with the variables replaced by their values.
+	Args map[string]interface{}
+	// Path is the path of the field in the response.
+	Path []interface{}
+}
+
+// FieldMiddleware intercepts the resolvers of all fields of the schema, independent of the directives of the fields.
+// It is called with the packed arguments of the field, which it passes on to next, or replaces. Middlewares wrap
+// the ResolverInterceptor implementations of directives, which wrap the resolver.
+//
+// See the graphql.FieldMiddleware Schema Option.
+type FieldMiddleware interface {
+	ResolveField(ctx context.Context, field *FieldInfo, args interface{}, next Resolver) (output interface{}, err error)
+}
+
+// FieldMiddlewareFunc is a function which implements FieldMiddleware.
+type FieldMiddlewareFunc func(ctx context.Context, field *FieldInfo, args interface{}, next Resolver) (output interface{}, err error)
+
+// ResolveField calls f.
+func (f FieldMiddlewareFunc) ResolveField(ctx context.Context, field *FieldInfo, args interface{}, next Resolver) (output interface{}, err error) {
+	return f(ctx, field, args, next)
+}
@@ -0,0 +1,127 @@
+package graphql
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/graph-gophers/graphql-go/ast"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/lru"
+	"github.com/graph-gophers/graphql-go/internal/query"
+	"github.com/graph-gophers/graphql-go/internal/validation"
+)
+
+// schemaIDs is the source of the identities of the schemas, which separate their entries in a shared [DocumentCache].
+var schemaIDs uint64
+
+// DocumentCache holds parsed and validated documents, so that frequently executed operations are neither parsed
+// nor validated again. A cache may be shared by several schemas, its entries are keyed by the schema and the query.
+// The validation of the variable values still runs for every request.
+type DocumentCache struct {
+	// hits and misses are accessed atomically and must stay 64-bit aligned.
+	hits   uint64
+	misses uint64
+	cache  *lru.Cache
+}
+
+// DocumentCacheStats are the statistics of a [DocumentCache], which help to choose its size.
+type DocumentCacheStats struct {
+	// Hits is the number of documents which were found in the cache.
+	Hits uint64
+	// Misses is the number of documents which had to be parsed and validated.
+	Misses uint64
+	// Len is the number of documents in the cache.
+	Len int
+}
+
+// NewDocumentCache returns a cache which holds up to size documents and evicts the least recently used ones.
+func NewDocumentCache(size int) *DocumentCache {
+	return &DocumentCache{cache: lru.New(size)}
+}
+
+// Stats returns the current statistics of the cache.
+func (c *DocumentCache) Stats() DocumentCacheStats {
+	return DocumentCacheStats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+		Len:    c.cache.Len(),
+	}
+}
+
+// UseDocumentCache enables caching of parsed and validated documents. By default every request is parsed and
+// validated.
+func UseDocumentCache(c *DocumentCache) SchemaOpt {
+	return func(s *Schema) {
+		s.documentCache = c
+	}
+}
+
+// document is a parsed document. The documents stored in the cache are validated and must not be modified.
+type document struct {
+	doc       *ast.ExecutableDefinition
+	key       string
+	validated bool
+	errs      []*errors.QueryError // the errors of the validation which doesn't depend on the variables
+}
+
+// parse parses the query or returns the cached document.
+func (s *Schema) parse(queryString string) (*document, *errors.QueryError) {
+	c := s.documentCache
+	if c == nil {
+		doc, qErr := query.Parse(queryString)
+		if qErr != nil {
+			return nil, qErr
+		}
+		return &document{doc: doc}, nil
+	}
+
+	key := strconv.FormatUint(s.id, 10) + ":" + queryString
+	if v, ok := c.cache.Get(key); ok {
+		atomic.AddUint64(&c.hits, 1)
+		return v.(*document), nil
+	}
+	atomic.AddUint64(&c.misses, 1)
+	doc, qErr := query.Parse(queryString)
+	if qErr != nil {
+		return nil, qErr
+	}
+	return &document{doc: doc, key: key}, nil
+}
+
+// validate validates the document with the variables. The validation result of a document is cached if the schema
+// uses a document cache, only the variable values are validated again.
+func (s *Schema) validate(d *document, variables map[string]interface{}) []*errors.QueryError {
+	if s.documentCache == nil {
+		return validation.Validate(s.schema, d.doc, variables, s.maxDepth)
+	}
+	if errs := s.validateDocument(d); len(errs) != 0 {
+		return errs
+	}
+	return validation.ValidateVariables(s.schema, d.doc, variables)
+}
+
+// validateDocument validates the document without the variable values and caches the result.
+func (s *Schema) validateDocument(d *document) []*errors.QueryError {
+	if s.documentCache == nil {
+		return validation.ValidateDocument(s.schema, d.doc, s.maxDepth)
+	}
+	if !d.validated {
+		d = &document{
+			doc:       d.doc,
+			key:       d.key,
+			validated: true,
+			errs:      validation.ValidateDocument(s.schema, d.doc, s.maxDepth),
+		}
+		s.documentCache.cache.Add(d.key, d)
+	}
+	if len(d.errs) == 0 {
+		return nil
+	}
+	// The errors are copied, as they are shared with every request for the document.
+	errs := make([]*errors.QueryError, len(d.errs))
+	for i, err := range d.errs {
+		e := *err
+		errs[i] = &e
+	}
+	return errs
+}
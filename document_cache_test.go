@@ -0,0 +1,122 @@
+package graphql_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/example/starwars"
+)
+
+func TestDocumentCache(t *testing.T) {
+	t.Parallel()
+
+	cache := graphql.NewDocumentCache(10)
+	s := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.UseDocumentCache(cache))
+	ctx := context.Background()
+
+	const query = `query($episode: Episode) { hero(episode: $episode) { name } }`
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		want      string
+		stats     graphql.DocumentCacheStats
+	}{
+		{
+			name:      "miss",
+			query:     query,
+			variables: map[string]interface{}{"episode": "EMPIRE"},
+			want:      `{"data":{"hero":{"name":"Luke Skywalker"}}}`,
+			stats:     graphql.DocumentCacheStats{Misses: 1, Len: 1},
+		},
+		{
+			name:      "hit",
+			query:     query,
+			variables: map[string]interface{}{"episode": "JEDI"},
+			want:      `{"data":{"hero":{"name":"R2-D2"}}}`,
+			stats:     graphql.DocumentCacheStats{Hits: 1, Misses: 1, Len: 1},
+		},
+		{
+			name:      "invalid_variables_on_hit",
+			query:     query,
+			variables: map[string]interface{}{"episode": "CLONES"},
+			want:      `{"errors":[{"message":"Variable \"episode\" has invalid value CLONES.\nExpected type \"Episode\", found CLONES.","locations":[{"line":1,"column":7}]}]}`,
+			stats:     graphql.DocumentCacheStats{Hits: 2, Misses: 1, Len: 1},
+		},
+		{
+			name:  "invalid_document",
+			query: `{ unknown }`,
+			want:  `{"errors":[{"message":"Cannot query field \"unknown\" on type \"Query\".","locations":[{"line":1,"column":3}]}]}`,
+			stats: graphql.DocumentCacheStats{Hits: 2, Misses: 2, Len: 2},
+		},
+		{
+			name:  "invalid_document_on_hit",
+			query: `{ unknown }`,
+			want:  `{"errors":[{"message":"Cannot query field \"unknown\" on type \"Query\".","locations":[{"line":1,"column":3}]}]}`,
+			stats: graphql.DocumentCacheStats{Hits: 3, Misses: 2, Len: 2},
+		},
+		{
+			name:  "parse_error",
+			query: `{`,
+			want:  `{"errors":[{"message":"syntax error: unexpected \"\", expecting Ident","locations":[{"line":1,"column":2}]}]}`,
+			stats: graphql.DocumentCacheStats{Hits: 3, Misses: 3, Len: 2},
+		},
+	}
+	for _, tt := range tests {
+		resp := s.Exec(ctx, tt.query, "", tt.variables)
+		if got := marshalResponse(t, resp); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+		if got := cache.Stats(); got != tt.stats {
+			t.Errorf("%s: got stats %+v, want %+v", tt.name, got, tt.stats)
+		}
+	}
+}
+
+func TestDocumentCache_SharedBySchemas(t *testing.T) {
+	t.Parallel()
+
+	cache := graphql.NewDocumentCache(10)
+	starwarsSchema := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.UseDocumentCache(cache))
+	limitedSchema := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.UseDocumentCache(cache), graphql.MaxDepth(1))
+
+	const query = `{ hero { name } }`
+	if errs := starwarsSchema.Validate(query); len(errs) != 0 {
+		t.Fatalf("got errors %v", errs)
+	}
+	if errs := limitedSchema.Validate(query); len(errs) != 1 {
+		t.Fatalf("got errors %v, want the max depth to be exceeded", errs)
+	}
+	if got, want := cache.Stats(), (graphql.DocumentCacheStats{Misses: 2, Len: 2}); got != want {
+		t.Errorf("got stats %+v, want %+v", got, want)
+	}
+}
+
+func TestDocumentCache_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	cache := graphql.NewDocumentCache(1)
+	s := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.UseDocumentCache(cache))
+	queries := []string{
+		`{ hero { name friends { name } } }`,
+		`query($id: ID!) { character(id: $id) { name ... on Human { height } } }`,
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(query string) {
+			defer wg.Done()
+			resp := s.Exec(context.Background(), query, "", map[string]interface{}{"id": "1000"})
+			if len(resp.Errors) != 0 {
+				t.Errorf("got errors %v", resp.Errors)
+			}
+		}(queries[i%len(queries)])
+	}
+	wg.Wait()
+	if st := cache.Stats(); st.Hits+st.Misses != 50 {
+		t.Errorf("got %d lookups, want 50", st.Hits+st.Misses)
+	}
+}
@@ -0,0 +1,60 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// ErrorCodes adds a "code" extension to every error of a response, which tells clients whether a document could
+// not be parsed (GRAPHQL_PARSE_FAILED), was invalid (GRAPHQL_VALIDATION_FAILED), had invalid variables
+// (BAD_USER_INPUT), referred to an unknown operation (OPERATION_RESOLUTION_FAILURE) or could not be executed for
+// other reasons (BAD_REQUEST), or whether its execution failed (INTERNAL_SERVER_ERROR) or timed out (TIMEOUT).
+// Errors which already have a code, e.g. those created with the constructors of the errors package or the errors of
+// automatic persisted queries, keep it.
+func ErrorCodes() SchemaOpt {
+	return func(s *Schema) {
+		s.errorCodes = true
+	}
+}
+
+// ValidationRuleNames adds a "rule" extension with the name of the violated validation rule to validation errors.
+func ValidationRuleNames() SchemaOpt {
+	return func(s *Schema) {
+		s.validationRuleNames = true
+	}
+}
+
+// coded sets the code of an error which occurred before the execution, if error codes are enabled.
+func (s *Schema) coded(code string, err *qerrors.QueryError) *qerrors.QueryError {
+	if !s.errorCodes || err.Code() != "" {
+		return err
+	}
+	return err.WithCode(code)
+}
+
+// classify adds the code and the rule extensions of the error.
+func (s *Schema) classify(err *qerrors.QueryError) *qerrors.QueryError {
+	if s.errorCodes && err.Code() == "" {
+		err = err.WithCode(errorCode(err))
+	}
+	if s.validationRuleNames && err.Rule != "" {
+		err = err.WithExtension("rule", err.Rule)
+	}
+	return err
+}
+
+// errorCode returns the code of an error of the validation or the execution.
+func errorCode(err *qerrors.QueryError) string {
+	switch {
+	case err.Rule == "VariablesOfCorrectType":
+		return qerrors.CodeBadUserInput
+	case err.Rule != "":
+		return qerrors.CodeValidationFailed
+	case errors.Is(err, context.DeadlineExceeded):
+		return qerrors.CodeTimeout
+	default:
+		return qerrors.CodeInternalServerError
+	}
+}
@@ -0,0 +1,180 @@
+package graphql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+const errorCodesSchema = `
+	type Query {
+		internal: String
+		forbidden: String
+		failed: String
+		slow: String
+		typed(n: Int!): String
+		timed(at: Time!): String
+	}
+
+	scalar Time
+`
+
+type errorCodesQuery struct {
+	maskQuery
+}
+
+func (errorCodesQuery) Forbidden() (*string, error) {
+	return nil, qerrors.Forbidden("not allowed")
+}
+
+func (errorCodesQuery) Failed() (*string, error) {
+	return nil, qerrors.Errorf("failed")
+}
+
+func (errorCodesQuery) Timed(args struct{ At graphql.Time }) *string {
+	return nil
+}
+
+func (errorCodesQuery) Slow(ctx context.Context) *string {
+	<-ctx.Done()
+	return nil
+}
+
+func TestErrorCodes(t *testing.T) {
+	s := graphql.MustParseSchema(errorCodesSchema, &errorCodesQuery{}, graphql.ErrorCodes())
+
+	tests := []struct {
+		name      string
+		req       *graphql.Request
+		timeout   time.Duration
+		wantCode  string
+		wantError string
+	}{
+		{
+			name:      "parse",
+			req:       &graphql.Request{Query: `{ internal`},
+			wantCode:  qerrors.CodeParseFailed,
+			wantError: `syntax error: unexpected "", expecting Ident (line 1, column 11)`,
+		},
+		{
+			name:      "validation",
+			req:       &graphql.Request{Query: `{ unknown }`},
+			wantCode:  qerrors.CodeValidationFailed,
+			wantError: `Cannot query field "unknown" on type "Query". (line 1, column 3)`,
+		},
+		{
+			name:      "variables",
+			req:       &graphql.Request{Query: `query($n: Int!) { typed(n: $n) }`, Variables: map[string]interface{}{"n": "one"}},
+			wantCode:  qerrors.CodeBadUserInput,
+			wantError: `could not unmarshal "one" (string) into int32: incompatible type: string`,
+		},
+		{
+			name:      "variable_coercion",
+			req:       &graphql.Request{Query: `query($at: Time!) { timed(at: $at) }`, Variables: map[string]interface{}{"at": "yesterday"}},
+			wantCode:  qerrors.CodeBadUserInput,
+			wantError: `parsing time "yesterday" as "2006-01-02T15:04:05Z07:00": cannot parse "yesterday" as "2006"`,
+		},
+		{
+			name:      "literal_coercion",
+			req:       &graphql.Request{Query: `{ timed(at: "yesterday") }`},
+			wantCode:  qerrors.CodeValidationFailed,
+			wantError: `parsing time "yesterday" as "2006-01-02T15:04:05Z07:00": cannot parse "yesterday" as "2006"`,
+		},
+		{
+			name:      "operation_name",
+			req:       &graphql.Request{Query: `query A { internal }`, OperationName: "B"},
+			wantCode:  qerrors.CodeOperationResolutionFailure,
+			wantError: `no operation with name "B"`,
+		},
+		{
+			name:      "persisted_query",
+			req:       &graphql.Request{Extensions: persistedQuery(`{ internal }`)},
+			wantCode:  qerrors.CodePersistedQueryNotFound,
+			wantError: graphql.PersistedQueryNotFound,
+		},
+		{
+			name:      "resolver",
+			req:       &graphql.Request{Query: `{ internal }`},
+			wantCode:  qerrors.CodeInternalServerError,
+			wantError: `pq: relation "users" does not exist`,
+		},
+		{
+			name:      "typed_resolver_error",
+			req:       &graphql.Request{Query: `{ forbidden }`},
+			wantCode:  qerrors.CodeForbidden,
+			wantError: "not allowed",
+		},
+		{
+			name:      "query_error_without_code",
+			req:       &graphql.Request{Query: `{ failed }`},
+			wantCode:  qerrors.CodeInternalServerError,
+			wantError: "graphql: failed",
+		},
+		{
+			name:      "timeout",
+			req:       &graphql.Request{Query: `{ slow }`},
+			timeout:   10 * time.Millisecond,
+			wantCode:  qerrors.CodeTimeout,
+			wantError: "context deadline exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.timeout != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			resp := s.ExecRequest(ctx, tt.req)
+			if len(resp.Errors) != 1 {
+				t.Fatalf("got errors %v, want one error", resp.Errors)
+			}
+			err := resp.Errors[0]
+			if err.Code() != tt.wantCode || err.Error() != "graphql: "+tt.wantError {
+				t.Errorf("got error %q with code %q, want %q with code %q", err.Error(), err.Code(), tt.wantError, tt.wantCode)
+			}
+			if _, ok := err.Extensions["rule"]; ok {
+				t.Errorf("got rule extension %v without ValidationRuleNames", err.Extensions)
+			}
+		})
+	}
+}
+
+func TestErrorCodes_Prepare(t *testing.T) {
+	s := graphql.MustParseSchema(errorCodesSchema, &errorCodesQuery{}, graphql.ErrorCodes())
+
+	tests := map[string]struct {
+		query         string
+		operationName string
+		wantCode      string
+	}{
+		"parse":          {query: `{ internal`, wantCode: qerrors.CodeParseFailed},
+		"validation":     {query: `{ unknown }`, wantCode: qerrors.CodeValidationFailed},
+		"operation_name": {query: `query A { internal }`, operationName: "B", wantCode: qerrors.CodeOperationResolutionFailure},
+	}
+	for name, tt := range tests {
+		_, errs := s.Prepare(tt.query, tt.operationName)
+		if len(errs) != 1 || errs[0].Code() != tt.wantCode {
+			t.Errorf("%s: got errors %v, want one error with code %q", name, errs, tt.wantCode)
+		}
+	}
+}
+
+func TestValidationRuleNames(t *testing.T) {
+	s := graphql.MustParseSchema(errorCodesSchema, &errorCodesQuery{}, graphql.ValidationRuleNames())
+
+	resp := s.Exec(context.Background(), `{ unknown }`, "", nil)
+	if len(resp.Errors) != 1 {
+		t.Fatalf("got errors %v, want one error", resp.Errors)
+	}
+	want := map[string]interface{}{"rule": "FieldsOnCorrectTypeRule"}
+	if got := resp.Errors[0].Extensions; len(got) != 1 || got["rule"] != want["rule"] {
+		t.Errorf("got extensions %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package errors
+
+// Codes of the "code" extension of errors, which allow clients to tell the kinds of errors apart.
+const (
+	CodeParseFailed                = "GRAPHQL_PARSE_FAILED"
+	CodeValidationFailed           = "GRAPHQL_VALIDATION_FAILED"
+	CodeBadUserInput               = "BAD_USER_INPUT"
+	CodeBadRequest                 = "BAD_REQUEST"
+	CodeOperationResolutionFailure = "OPERATION_RESOLUTION_FAILURE"
+	CodePersistedQueryNotFound     = "PERSISTED_QUERY_NOT_FOUND"
+	CodePersistedQueryNotSupported = "PERSISTED_QUERY_NOT_SUPPORTED"
+	CodeUnauthenticated            = "UNAUTHENTICATED"
+	CodeForbidden                  = "FORBIDDEN"
+	CodeTimeout                    = "TIMEOUT"
+	CodeInternalServerError        = "INTERNAL_SERVER_ERROR"
+)
+
+// ErrorfWithCode is like Errorf and sets the "code" extension of the error.
+func ErrorfWithCode(code string, format string, a ...interface{}) *QueryError {
+	err := Errorf(format, a...)
+	err.Extensions = map[string]interface{}{"code": code}
+	return err
+}
+
+// ParseFailed creates an error for a document which is not valid GraphQL syntax.
+func ParseFailed(format string, a ...interface{}) *QueryError {
+	return ErrorfWithCode(CodeParseFailed, format, a...)
+}
+
+// ValidationFailed creates an error for a document which violates the validation rule.
+func ValidationFailed(rule string, format string, a ...interface{}) *QueryError {
+	err := ErrorfWithCode(CodeValidationFailed, format, a...)
+	err.Rule = rule
+	return err
+}
+
+// BadUserInput creates an error for invalid variables or arguments.
+func BadUserInput(format string, a ...interface{}) *QueryError {
+	return ErrorfWithCode(CodeBadUserInput, format, a...)
+}
+
+// BadRequest creates an error for a request which can not be executed, e.g. because it is malformed.
+func BadRequest(format string, a ...interface{}) *QueryError {
+	return ErrorfWithCode(CodeBadRequest, format, a...)
+}
+
+// Unauthenticated creates an error for a request which lacks valid authentication.
+func Unauthenticated(format string, a ...interface{}) *QueryError {
+	return ErrorfWithCode(CodeUnauthenticated, format, a...)
+}
+
+// Forbidden creates an error for a request which is not allowed to access a field or perform an operation.
+func Forbidden(format string, a ...interface{}) *QueryError {
+	return ErrorfWithCode(CodeForbidden, format, a...)
+}
+
+// Timeout creates an error for an operation which did not complete in time.
+func Timeout(format string, a ...interface{}) *QueryError {
+	return ErrorfWithCode(CodeTimeout, format, a...)
+}
+
+// Internal creates an error for an unexpected failure of the server.
+func Internal(format string, a ...interface{}) *QueryError {
+	return ErrorfWithCode(CodeInternalServerError, format, a...)
+}
+
+// Code returns the "code" extension of the error or an empty string if it has none.
+func (err *QueryError) Code() string {
+	if err == nil {
+		return ""
+	}
+	code, _ := err.Extensions["code"].(string)
+	return code
+}
+
+// WithCode returns a copy of the error with the "code" extension set to code. The extensions are copied, so the
+// error itself is not modified.
+func (err *QueryError) WithCode(code string) *QueryError {
+	return err.WithExtension("code", code)
+}
+
+// WithExtension returns a copy of the error with the extension set to value. The extensions are copied, so the
+// error itself is not modified.
+func (err *QueryError) WithExtension(key string, value interface{}) *QueryError {
+	e := *err
+	e.Extensions = make(map[string]interface{}, len(err.Extensions)+1)
+	for k, v := range err.Extensions {
+		e.Extensions[k] = v
+	}
+	e.Extensions[key] = value
+	return &e
+}
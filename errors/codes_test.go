@@ -0,0 +1,33 @@
+package errors
+
+import (
+	"io"
+	"testing"
+)
+
+func TestErrorfWithCode(t *testing.T) {
+	err := ErrorfWithCode(CodeBadUserInput, "invalid input: %v", io.EOF)
+	if err.Code() != CodeBadUserInput || err.Message != "invalid input: EOF" || !Is(err, io.EOF) {
+		t.Errorf("got %v with code %q", err, err.Code())
+	}
+
+	err = ValidationFailed("KnownTypeNames", "Unknown type %q.", "Foo")
+	if err.Code() != CodeValidationFailed || err.Rule != "KnownTypeNames" {
+		t.Errorf("got %v with code %q and rule %q", err, err.Code(), err.Rule)
+	}
+}
+
+func TestQueryError_WithCode(t *testing.T) {
+	err := &QueryError{Message: "failed", Extensions: map[string]interface{}{"retry": true}}
+	coded := err.WithCode(CodeTimeout)
+	if coded.Code() != CodeTimeout || coded.Extensions["retry"] != true {
+		t.Errorf("got extensions %v", coded.Extensions)
+	}
+	if err.Code() != "" || len(err.Extensions) != 1 {
+		t.Errorf("got the original error modified: %v", err.Extensions)
+	}
+	var nilErr *QueryError
+	if nilErr.Code() != "" {
+		t.Error("got a code for a nil error")
+	}
+}
@@ -0,0 +1,40 @@
+package errors
+
+// SafeError is implemented by errors whose message may be shown to clients as is. Errors returned by resolvers
+// which implement it, or wrap an error which implements it, are not masked when the schema masks errors.
+type SafeError interface {
+	error
+	Safe() bool
+}
+
+// Safe marks err as safe to be shown to clients. It returns nil if err is nil.
+func Safe(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &safeError{err: err}
+}
+
+type safeError struct {
+	err error
+}
+
+func (e *safeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *safeError) Safe() bool {
+	return true
+}
+
+func (e *safeError) Unwrap() error {
+	return e.err
+}
+
+// Extensions returns the extensions of the wrapped error, so that they are kept in the response.
+func (e *safeError) Extensions() map[string]interface{} {
+	if ex, ok := e.err.(interface{ Extensions() map[string]interface{} }); ok {
+		return ex.Extensions()
+	}
+	return nil
+}
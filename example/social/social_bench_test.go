@@ -0,0 +1,61 @@
+package social_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/example/social"
+)
+
+// BenchmarkExec measures the execution of prepared operations, which leaves out parsing and validation.
+func BenchmarkExec(b *testing.B) {
+	schema := graphql.MustParseSchema(social.Schema, &social.Resolver{}, graphql.UseFieldResolvers(), graphql.MaxParallelism(20))
+
+	benchmarks := []struct {
+		name  string
+		query string
+	}{
+		{
+			name:  "user",
+			query: `{ user(id: "0x01") { id name email role phone address createdAt } }`,
+		},
+		{
+			name: "admin_friends",
+			query: `
+				{
+					admin(id: "0x02", role: USER) {
+						id
+						name
+						... on User {
+							email
+							friends(page: { first: 1 }) { id name email createdAt }
+						}
+					}
+				}
+			`,
+		},
+		{
+			name:  "search",
+			query: `{ search(text: "o") { ... on User { id name email address friends { name role } } } }`,
+		},
+	}
+
+	for _, bm := range benchmarks {
+		bm := bm
+		b.Run(bm.name, func(b *testing.B) {
+			op, errs := schema.Prepare(bm.query, "")
+			if errs != nil {
+				b.Fatal(errs)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				resp := op.Exec(context.Background(), nil)
+				if len(resp.Errors) != 0 {
+					b.Fatal(resp.Errors)
+				}
+			}
+		})
+	}
+}
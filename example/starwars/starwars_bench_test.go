@@ -0,0 +1,88 @@
+package starwars_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/example/starwars"
+)
+
+// BenchmarkExec measures the execution of prepared operations, which leaves out parsing and validation.
+func BenchmarkExec(b *testing.B) {
+	schema := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{})
+
+	benchmarks := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+	}{
+		{
+			name:  "hero",
+			query: `{ hero { id name appearsIn } }`,
+		},
+		{
+			name: "friends_of_friends",
+			query: `
+				query($episode: Episode) {
+					hero(episode: $episode) {
+						__typename
+						name
+						friends {
+							name
+							... on Human { height(unit: FOOT) mass }
+							... on Droid { primaryFunction }
+							friends { __typename name appearsIn }
+						}
+					}
+				}
+			`,
+			variables: map[string]interface{}{"episode": "EMPIRE"},
+		},
+		{
+			name: "search",
+			query: `
+				{
+					search(text: "a") {
+						__typename
+						... on Human { name height starships { name length } }
+						... on Droid { name primaryFunction }
+						... on Starship { name length }
+					}
+				}
+			`,
+		},
+		{
+			name: "connection",
+			query: `
+				{
+					human(id: "1000") {
+						friendsConnection(first: 3) {
+							totalCount
+							edges { cursor node { id name } }
+							pageInfo { startCursor endCursor hasNextPage }
+						}
+					}
+				}
+			`,
+		},
+	}
+
+	for _, bm := range benchmarks {
+		bm := bm
+		b.Run(bm.name, func(b *testing.B) {
+			op, errs := schema.Prepare(bm.query, "")
+			if errs != nil {
+				b.Fatal(errs)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				resp := op.Exec(context.Background(), bm.variables)
+				if len(resp.Errors) != 0 {
+					b.Fatal(resp.Errors)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go/ast"
+	"github.com/graph-gophers/graphql-go/directives"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// Extension is a plugin which hooks into the stages of the execution of an operation, e.g. to report tracing data
+// or the cost of an operation in the response extensions or to write an audit log. In addition to its name, an
+// extension implements one or more of the optional interfaces [RequestStartHook], [ParseHook], [ValidationHook],
+// [ExecutionHook], [ResponseHook] and [directives.FieldMiddleware], which is called for every field.
+//
+// See the [UseExtensions] Schema Option.
+type Extension interface {
+	ExtensionName() string
+}
+
+// RequestStartHook is called when the execution of a request starts. The returned context is used for the rest of
+// the request, which allows the extension to keep the state of the request in the context.
+type RequestStartHook interface {
+	RequestStart(ctx context.Context, req *Request) context.Context
+}
+
+// ParseHook is called after the document of a request was parsed. If the document is not valid, doc is nil and err
+// is the syntax error.
+type ParseHook interface {
+	AfterParse(ctx context.Context, query string, doc *ast.ExecutableDefinition, err *errors.QueryError)
+}
+
+// ValidationHook is called after the document of a request was validated with the errors of the validation. If it
+// returns an error, the request is rejected with the error, even if the document is valid.
+type ValidationHook interface {
+	AfterValidation(ctx context.Context, doc *ast.ExecutableDefinition, errs []*errors.QueryError) *errors.QueryError
+}
+
+// ExecutionHook is called before an operation is executed. The returned context is used for the execution.
+type ExecutionHook interface {
+	BeforeExecution(ctx context.Context, op *ast.OperationDefinition, variables map[string]interface{}) context.Context
+}
+
+// ResponseHook is called with every response before it is returned, including the subsequent responses of
+// incremental delivery and the events of subscriptions. It may add entries to the extensions of the response, which
+// may be nil, and amend its errors.
+type ResponseHook interface {
+	FinalizeResponse(ctx context.Context, resp *Response)
+}
+
+// UseExtensions adds extensions to the schema. Their hooks are called in the order the extensions were added. Their
+// field middleware is called after the middleware added with [FieldMiddleware].
+func UseExtensions(exts ...Extension) SchemaOpt {
+	return func(s *Schema) {
+		s.extensions = append(s.extensions, exts...)
+	}
+}
+
+// extensionHooks holds the hooks of the extensions of a schema by stage.
+type extensionHooks struct {
+	requestStart []RequestStartHook
+	parse        []ParseHook
+	validation   []ValidationHook
+	execution    []ExecutionHook
+	response     []ResponseHook
+}
+
+func newExtensionHooks(exts []Extension) *extensionHooks {
+	if len(exts) == 0 {
+		return nil
+	}
+	h := &extensionHooks{}
+	for _, ext := range exts {
+		if hook, ok := ext.(RequestStartHook); ok {
+			h.requestStart = append(h.requestStart, hook)
+		}
+		if hook, ok := ext.(ParseHook); ok {
+			h.parse = append(h.parse, hook)
+		}
+		if hook, ok := ext.(ValidationHook); ok {
+			h.validation = append(h.validation, hook)
+		}
+		if hook, ok := ext.(ExecutionHook); ok {
+			h.execution = append(h.execution, hook)
+		}
+		if hook, ok := ext.(ResponseHook); ok {
+			h.response = append(h.response, hook)
+		}
+	}
+	return h
+}
+
+// fieldMiddleware returns the extensions which intercept the fields.
+func fieldMiddleware(exts []Extension) []directives.FieldMiddleware {
+	var mw []directives.FieldMiddleware
+	for _, ext := range exts {
+		if m, ok := ext.(directives.FieldMiddleware); ok {
+			mw = append(mw, m)
+		}
+	}
+	return mw
+}
+
+func (s *Schema) requestStart(ctx context.Context, req *Request) context.Context {
+	if s.hooks == nil {
+		return ctx
+	}
+	for _, hook := range s.hooks.requestStart {
+		ctx = hook.RequestStart(ctx, req)
+	}
+	return ctx
+}
+
+func (s *Schema) afterParse(ctx context.Context, query string, doc *ast.ExecutableDefinition, err *errors.QueryError) {
+	if s.hooks == nil {
+		return
+	}
+	for _, hook := range s.hooks.parse {
+		hook.AfterParse(ctx, query, doc, err)
+	}
+}
+
+// afterValidation returns the validation errors and the errors of the extensions which reject the request.
+func (s *Schema) afterValidation(ctx context.Context, doc *ast.ExecutableDefinition, errs []*errors.QueryError) []*errors.QueryError {
+	if s.hooks == nil {
+		return errs
+	}
+	validationErrs := errs
+	for _, hook := range s.hooks.validation {
+		if err := hook.AfterValidation(ctx, doc, validationErrs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func (s *Schema) beforeExecution(ctx context.Context, op *ast.OperationDefinition, variables map[string]interface{}) context.Context {
+	if s.hooks == nil {
+		return ctx
+	}
+	for _, hook := range s.hooks.execution {
+		ctx = hook.BeforeExecution(ctx, op, variables)
+	}
+	return ctx
+}
+
+func (s *Schema) finalizeResponse(ctx context.Context, resp *Response) {
+	if s.hooks == nil {
+		return
+	}
+	for _, hook := range s.hooks.response {
+		hook.FinalizeResponse(ctx, resp)
+	}
+}
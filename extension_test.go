@@ -0,0 +1,139 @@
+package graphql_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/ast"
+	"github.com/graph-gophers/graphql-go/directives"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+type tenantKey struct{}
+
+// auditExtension records the stages of the requests and rejects operations named "Rejected".
+type auditExtension struct {
+	mu     sync.Mutex
+	stages []string
+}
+
+func (e *auditExtension) ExtensionName() string { return "audit" }
+
+func (e *auditExtension) record(format string, a ...interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stages = append(e.stages, fmt.Sprintf(format, a...))
+}
+
+func (e *auditExtension) RequestStart(ctx context.Context, req *graphql.Request) context.Context {
+	e.record("start %s", req.OperationName)
+	return ctx
+}
+
+func (e *auditExtension) AfterParse(ctx context.Context, query string, doc *ast.ExecutableDefinition, err *qerrors.QueryError) {
+	e.record("parse %v", err)
+}
+
+func (e *auditExtension) AfterValidation(ctx context.Context, doc *ast.ExecutableDefinition, errs []*qerrors.QueryError) *qerrors.QueryError {
+	e.record("validation %d", len(errs))
+	if op := doc.Operations.Get("Rejected"); op != nil {
+		return &qerrors.QueryError{Message: "rejected by the audit"}
+	}
+	return nil
+}
+
+func (e *auditExtension) BeforeExecution(ctx context.Context, op *ast.OperationDefinition, variables map[string]interface{}) context.Context {
+	e.record("execution %s", op.Type)
+	return context.WithValue(ctx, tenantKey{}, "acme")
+}
+
+func (e *auditExtension) ResolveField(ctx context.Context, field *directives.FieldInfo, args interface{}, next directives.Resolver) (interface{}, error) {
+	e.record("field %s.%s", field.TypeName, field.Definition.Name)
+	return next.Resolve(ctx, args)
+}
+
+func (e *auditExtension) FinalizeResponse(ctx context.Context, resp *graphql.Response) {
+	e.record("response %d", len(resp.Errors))
+	if resp.Extensions == nil {
+		resp.Extensions = make(map[string]interface{})
+	}
+	resp.Extensions["audited"] = true
+}
+
+type tenantQuery struct{}
+
+func (tenantQuery) Tenant(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+func TestUseExtensions(t *testing.T) {
+	const schema = `type Query { tenant: String! }`
+
+	tests := []struct {
+		name       string
+		query      string
+		opName     string
+		wantData   string
+		wantErrors int
+		wantStages []string
+	}{
+		{
+			name:     "executed",
+			query:    `query Tenant { tenant }`,
+			opName:   "Tenant",
+			wantData: `{"tenant":"acme"}`,
+			wantStages: []string{
+				"start Tenant", "parse <nil>", "validation 0", "execution QUERY", "field Query.tenant", "response 0",
+			},
+		},
+		{
+			name:       "parse_error",
+			query:      `query {`,
+			wantErrors: 1,
+			wantStages: []string{
+				"start ", `parse graphql: syntax error: unexpected "", expecting Ident (line 1, column 8)`, "response 1",
+			},
+		},
+		{
+			name:       "rejected",
+			query:      `query Rejected { tenant }`,
+			wantErrors: 1,
+			wantStages: []string{"start ", "parse <nil>", "validation 0", "response 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ext := &auditExtension{}
+			s := graphql.MustParseSchema(schema, &tenantQuery{}, graphql.UseExtensions(ext))
+
+			resp := s.Exec(context.Background(), tt.query, tt.opName, nil)
+			if len(resp.Errors) != tt.wantErrors || string(resp.Data) != tt.wantData {
+				t.Errorf("got data %s and errors %v, want %s and %d errors", resp.Data, resp.Errors, tt.wantData, tt.wantErrors)
+			}
+			if resp.Extensions["audited"] != true {
+				t.Errorf("got extensions %v, want the response to be audited", resp.Extensions)
+			}
+			if got, want := strings.Join(ext.stages, "\n"), strings.Join(tt.wantStages, "\n"); got != want {
+				t.Errorf("got stages\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestUseExtensions_ToJSON(t *testing.T) {
+	ext := &auditExtension{}
+	s := graphql.MustParseSchema(`type Query { tenant: String! }`, &tenantQuery{}, graphql.UseExtensions(ext))
+	if _, err := s.ToJSON(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ext.stages) != 0 {
+		t.Errorf("got stages %q for the internal introspection request", ext.stages)
+	}
+}
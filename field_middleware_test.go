@@ -0,0 +1,113 @@
+package graphql_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/directives"
+)
+
+const middlewareSchema = `
+	directive @upper on FIELD_DEFINITION
+
+	type Query {
+		greeting(name: String!): String! @upper
+		users: [User!]!
+	}
+
+	type User {
+		name: String!
+	}
+`
+
+type middlewareQuery struct{}
+
+func (middlewareQuery) Greeting(args struct{ Name string }) string {
+	return "hello " + args.Name
+}
+
+func (middlewareQuery) Users() []*middlewareUser {
+	return []*middlewareUser{{"alice"}, {"bob"}}
+}
+
+type middlewareUser struct{ name string }
+
+func (u *middlewareUser) Name() string { return u.name }
+
+// suffixMiddleware appends the suffix to string results and records the resolved fields.
+type suffixMiddleware struct {
+	suffix string
+	mu     sync.Mutex
+	fields []string
+}
+
+func (m *suffixMiddleware) ResolveField(ctx context.Context, field *directives.FieldInfo, args interface{}, next directives.Resolver) (interface{}, error) {
+	m.mu.Lock()
+	m.fields = append(m.fields, fmt.Sprintf("%s.%s %v %v", field.TypeName, field.Definition.Name, field.Path, field.Args))
+	m.mu.Unlock()
+
+	out, err := next.Resolve(ctx, args)
+	if s, ok := out.(string); ok {
+		return s + m.suffix, err
+	}
+	return out, err
+}
+
+func TestFieldMiddleware(t *testing.T) {
+	first, second := &suffixMiddleware{suffix: "-1"}, &suffixMiddleware{suffix: "-2"}
+	s := graphql.MustParseSchema(middlewareSchema, &middlewareQuery{},
+		graphql.Directives(&UpperDirective{}),
+		graphql.FieldMiddleware(first, second),
+	)
+
+	resp := s.Exec(context.Background(), `{ greeting(name: "world") users { name } __typename }`, "", nil)
+	if len(resp.Errors) != 0 {
+		t.Fatal(resp.Errors)
+	}
+	// The directive is called before the middleware, and the first middleware is called first.
+	want := `{"greeting":"HELLO WORLD-2-1","users":[{"name":"alice-2-1"},{"name":"bob-2-1"}],"__typename":"Query"}`
+	if string(resp.Data) != want {
+		t.Errorf("got %s, want %s", resp.Data, want)
+	}
+
+	wantFields := []string{
+		"Query.greeting [greeting] map[name:world]",
+		"Query.users [users] map[]",
+		"User.name [users 0 name] map[]",
+		"User.name [users 1 name] map[]",
+	}
+	for _, m := range []*suffixMiddleware{first, second} {
+		got := append([]string(nil), m.fields...)
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, wantFields) {
+			t.Errorf("got fields %q, want %q", got, wantFields)
+		}
+	}
+}
+
+func TestFieldMiddleware_Error(t *testing.T) {
+	deny := directives.FieldMiddlewareFunc(func(ctx context.Context, field *directives.FieldInfo, args interface{}, next directives.Resolver) (interface{}, error) {
+		if field.TypeName == "User" {
+			return nil, fmt.Errorf("access denied to %s", field.Definition.Name)
+		}
+		return next.Resolve(ctx, args)
+	})
+	s := graphql.MustParseSchema(middlewareSchema, &middlewareQuery{},
+		graphql.Directives(&UpperDirective{}),
+		graphql.FieldMiddleware(deny),
+	)
+
+	resp := s.Exec(context.Background(), `{ greeting(name: "world") users { name } }`, "", nil)
+	if len(resp.Errors) != 2 || !strings.HasPrefix(resp.Errors[0].Message, "access denied to name") {
+		t.Fatalf("got errors %v, want access denied errors", resp.Errors)
+	}
+	if string(resp.Data) != `null` {
+		t.Errorf("got %s, want null as users is non-null", resp.Data)
+	}
+}
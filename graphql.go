@@ -1,9 +1,11 @@
 package graphql
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/graph-gophers/graphql-go/ast"
@@ -15,8 +17,8 @@ import (
 	"github.com/graph-gophers/graphql-go/internal/exec/selected"
 	"github.com/graph-gophers/graphql-go/internal/query"
 	"github.com/graph-gophers/graphql-go/internal/schema"
-	"github.com/graph-gophers/graphql-go/internal/validation"
 	"github.com/graph-gophers/graphql-go/introspection"
+	"github.com/graph-gophers/graphql-go/loader"
 	"github.com/graph-gophers/graphql-go/log"
 	"github.com/graph-gophers/graphql-go/trace/noop"
 	"github.com/graph-gophers/graphql-go/trace/tracer"
@@ -27,15 +29,22 @@ import (
 // resolver, then the schema can not be executed, but it may be inspected (e.g. with [Schema.ToJSON] or [Schema.AST]).
 func ParseSchema(schemaString string, resolver interface{}, opts ...SchemaOpt) (*Schema, error) {
 	s := &Schema{
-		schema:         schema.New(),
-		maxParallelism: 10,
-		tracer:         noop.Tracer{},
-		logger:         &log.DefaultLogger{},
-		panicHandler:   &errors.DefaultPanicHandler{},
+		schema:           schema.New(),
+		maxParallelism:   10,
+		tracer:           noop.Tracer{},
+		logger:           &log.DefaultLogger{},
+		panicHandler:     &errors.DefaultPanicHandler{},
+		persistedQueries: NewPersistedQueryCache(DefaultPersistedQueryCacheSize),
+		id:               atomic.AddUint64(&schemaIDs, 1),
 	}
 	for _, opt := range opts {
 		opt(s)
 	}
+	if s.errorMask != nil {
+		s.panicHandler = &maskingPanicHandler{s.panicHandler}
+	}
+	s.hooks = newExtensionHooks(s.extensions)
+	s.fieldMiddleware = append(s.fieldMiddleware, fieldMiddleware(s.extensions)...)
 
 	if s.validationTracer == nil {
 		if t, ok := s.tracer.(tracer.ValidationTracer); ok {
@@ -52,7 +61,7 @@ func ParseSchema(schemaString string, resolver interface{}, opts ...SchemaOpt) (
 		return nil, err
 	}
 
-	r, err := resolvable.ApplyResolver(s.schema, resolver, s.directives, s.useFieldResolvers)
+	r, err := resolvable.ApplyResolver(s.schema, resolver, s.directives, s.fieldMiddleware, s.useFieldResolvers)
 	if err != nil {
 		return nil, err
 	}
@@ -77,16 +86,35 @@ type Schema struct {
 
 	allowIntrospection       func(ctx context.Context) bool
 	directives               []directives.Directive
+	fieldMiddleware          []directives.FieldMiddleware
+	extensions               []Extension
+	hooks                    *extensionHooks
 	maxQueryLength           int
 	maxDepth                 int
+	maxComplexity            int
+	fieldCost                FieldCostFunc
 	maxParallelism           int
 	tracer                   tracer.Tracer
 	validationTracer         tracer.ValidationTracer
 	logger                   log.Logger
 	panicHandler             errors.PanicHandler
+	errorPresenter           func(ctx context.Context, err *errors.QueryError) *errors.QueryError
+	errorMask                *errorMask
+	errorCodes               bool
+	validationRuleNames      bool
 	useStringDescriptions    bool
 	subscribeResolverTimeout time.Duration
+	deliveryPolicy           DeliveryPolicy
+	deliveryBufferSize       int
+	deliveryTimeout          time.Duration
+	multiplexer              *multiplexer
 	useFieldResolvers        bool
+	persistedQueries         PersistedQueryStore
+	trustedDocuments         *TrustedDocuments
+	logUntrustedDocument     func(ctx context.Context, query string)
+	documentCache            *DocumentCache
+	loaders                  *loader.Registry
+	id                       uint64
 }
 
 // AST returns the abstract syntax tree of the GraphQL schema definition.
@@ -205,7 +233,8 @@ func DisableIntrospection() SchemaOpt {
 
 // SubscribeResolverTimeout is an option to control the amount of time
 // we allow for a single subscribe message resolver to complete it's job
-// before it times out and returns an error to the subscriber.
+// before it times out and returns an error to the subscriber. The delivery
+// of the event is limited separately by [SubscribeDeliveryTimeout].
 func SubscribeResolverTimeout(timeout time.Duration) SchemaOpt {
 	return func(s *Schema) {
 		s.subscribeResolverTimeout = timeout
@@ -220,15 +249,30 @@ func Directives(ds ...directives.Directive) SchemaOpt {
 	}
 }
 
+// FieldMiddleware adds middleware which intercepts the resolvers of all fields of the schema, e.g. for
+// authorization or metrics, without declaring directives on the fields. The middleware is called in the order it
+// was added, before the resolver interceptors of the directives of a field. Fields of the introspection types are
+// not intercepted.
+func FieldMiddleware(mw ...directives.FieldMiddleware) SchemaOpt {
+	return func(s *Schema) {
+		s.fieldMiddleware = append(s.fieldMiddleware, mw...)
+	}
+}
+
 // Response represents a typical response of a GraphQL server. It may be encoded to JSON directly or
 // it may be further processed to a custom response type, for example to include custom error data.
 // Errors are intentionally serialized first based on the advice in the [spec].
 //
 // [spec]: https://github.com/facebook/graphql/commit/7b40390d48680b15cb93e02d46ac5eb249689876#diff-757cea6edf0288677a9eea4cfc801d87R107
 type Response struct {
-	Errors     []*errors.QueryError   `json:"errors,omitempty"`
-	Data       json.RawMessage        `json:"data,omitempty"`
-	Extensions map[string]interface{} `json:"extensions,omitempty"`
+	Errors      []*errors.QueryError   `json:"errors,omitempty"`
+	Data        json.RawMessage        `json:"data,omitempty"`
+	Incremental []*IncrementalResult   `json:"incremental,omitempty"`
+	HasNext     *bool                  `json:"hasNext,omitempty"`
+	Extensions  map[string]interface{} `json:"extensions,omitempty"`
+
+	buf     *bytes.Buffer // the pooled buffer holding Data, see Schema.ExecBuffered
+	encoded []byte        // the cached JSON encoding of a response shared by multiplexed subscriptions
 }
 
 // ArgumentsFromContext returns the arguments for the field.
@@ -236,6 +280,21 @@ func ArgumentsFromContext(ctx context.Context) map[string]interface{} {
 	return exec.ArgsFromContext(ctx)
 }
 
+// AddExtension sets an entry of the extensions of the response from a resolver, e.g. the remaining rate limit or
+// debug information. Entries which are set by the library, e.g. the cost of the operation, take precedence. It is
+// safe to call concurrently. In subscriptions, the entries are added to the response of the current event. It
+// reports false if the context is not the context of an operation.
+func AddExtension(ctx context.Context, key string, value interface{}) bool {
+	return exec.AddExtension(ctx, key, value)
+}
+
+// AddWarning adds a warning to the "warnings" entry of the extensions of the response from a resolver, e.g. a
+// deprecation notice. It is safe to call concurrently. In subscriptions, the warnings are added to the response of
+// the current event. It reports false if the context is not the context of an operation.
+func AddWarning(ctx context.Context, message string) bool {
+	return exec.AddWarning(ctx, message)
+}
+
 // Validate validates the given query with the schema.
 func (s *Schema) Validate(queryString string) []*errors.QueryError {
 	return s.ValidateWithVariables(queryString, nil)
@@ -243,12 +302,12 @@ func (s *Schema) Validate(queryString string) []*errors.QueryError {
 
 // ValidateWithVariables validates the given query with the schema and the input variables.
 func (s *Schema) ValidateWithVariables(queryString string, variables map[string]interface{}) []*errors.QueryError {
-	doc, qErr := query.Parse(queryString)
+	d, qErr := s.parse(queryString)
 	if qErr != nil {
 		return []*errors.QueryError{qErr}
 	}
 
-	return validation.Validate(s.schema, doc, variables, s.maxDepth)
+	return s.validate(d, variables)
 }
 
 // Exec executes the given query with the schema's resolver. It panics if the schema was created
@@ -258,28 +317,83 @@ func (s *Schema) Exec(ctx context.Context, queryString string, operationName str
 	if !s.res.QueryResolver.IsValid() {
 		panic("schema created without resolver, can not exec")
 	}
-	return s.exec(ctx, queryString, operationName, variables, s.res)
+	return s.exec(ctx, &Request{Query: queryString, OperationName: operationName, Variables: variables}, s.res)
+}
+
+func (s *Schema) exec(ctx context.Context, req *Request, res *resolvable.Schema) *Response {
+	return s.execTo(ctx, req, res, new(bytes.Buffer))
+}
+
+// execTo executes the request and writes the data of the response to out.
+func (s *Schema) execTo(ctx context.Context, req *Request, res *resolvable.Schema, out *bytes.Buffer) *Response {
+	ctx = s.requestStart(ctx, req)
+	o, resp := s.prepareExec(ctx, req)
+	if resp != nil {
+		return s.present(ctx, resp)
+	}
+	ctx = s.beforeExecution(ctx, o.op, o.req.Vars)
+	ctx, extensions := exec.WithExtensions(ctx)
+	traceCtx, finish := s.tracer.TraceQuery(ctx, o.query, o.name, o.req.Vars, o.varTypes)
+	errs := o.req.ExecuteTo(traceCtx, res, o.op, nil, out)
+	finish(errs)
+
+	resp = &Response{
+		Errors:     errs,
+		Extensions: extensions.MergeInto(o.extensions),
+	}
+	if out.Len() != 0 {
+		resp.Data = out.Bytes()
+	}
+	return s.present(ctx, resp)
+}
+
+// execOperation is a validated query or mutation which is ready to be executed.
+type execOperation struct {
+	req      *exec.Request
+	op       *ast.OperationDefinition
+	query    string
+	name     string
+	varTypes map[string]*introspection.Type
+
+	// extensions of the response, which report the cost of the operation
+	extensions map[string]interface{}
 }
 
-func (s *Schema) exec(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, res *resolvable.Schema) *Response {
+// prepareExec parses and validates the operation of the request. If the operation can not be executed,
+// the response with the errors is returned instead.
+func (s *Schema) prepareExec(ctx context.Context, req *Request) (*execOperation, *Response) {
+	queryString, persist, qErr := s.requestQuery(ctx, req)
+	if qErr != nil {
+		return nil, &Response{Errors: []*errors.QueryError{s.coded(errors.CodeBadRequest, qErr)}}
+	}
+	operationName, variables := req.OperationName, req.Variables
+
 	if s.maxQueryLength > 0 && len(queryString) > s.maxQueryLength {
-		return &Response{Errors: []*errors.QueryError{errors.Errorf("query length %d exceeds the maximum allowed query length of %d bytes", len(queryString), s.maxQueryLength)}}
+		qErr := errors.Errorf("query length %d exceeds the maximum allowed query length of %d bytes", len(queryString), s.maxQueryLength)
+		return nil, &Response{Errors: []*errors.QueryError{s.coded(errors.CodeBadRequest, qErr)}}
 	}
-	doc, qErr := query.Parse(queryString)
+	d, qErr := s.parse(queryString)
 	if qErr != nil {
-		return &Response{Errors: []*errors.QueryError{qErr}}
+		s.afterParse(ctx, queryString, nil, qErr)
+		return nil, &Response{Errors: []*errors.QueryError{s.coded(errors.CodeParseFailed, qErr)}}
 	}
+	doc := d.doc
+	s.afterParse(ctx, queryString, doc, nil)
 
 	validationFinish := s.validationTracer.TraceValidation(ctx)
-	errs := validation.Validate(s.schema, doc, variables, s.maxDepth)
+	errs := s.validate(d, variables)
 	validationFinish(errs)
+	errs = s.afterValidation(ctx, doc, errs)
 	if len(errs) != 0 {
-		return &Response{Errors: errs}
+		return nil, &Response{Errors: errs}
+	}
+	if persist != nil {
+		persist()
 	}
 
 	op, err := getOperation(doc, operationName)
 	if err != nil {
-		return &Response{Errors: []*errors.QueryError{errors.Errorf("%s", err)}}
+		return nil, &Response{Errors: []*errors.QueryError{s.coded(errors.CodeOperationResolutionFailure, errors.Errorf("%s", err))}}
 	}
 
 	// If the optional "operationName" POST parameter is not provided then
@@ -288,17 +402,51 @@ func (s *Schema) exec(ctx context.Context, queryString string, operationName str
 		operationName = op.Name.Name
 	}
 
+	if qErr := s.checkOperation(op); qErr != nil {
+		return nil, &Response{Errors: []*errors.QueryError{s.coded(errors.CodeBadRequest, qErr)}}
+	}
+	varTypes, qErr := s.variableTypes(op)
+	if qErr != nil {
+		return nil, &Response{Errors: []*errors.QueryError{s.coded(errors.CodeValidationFailed, qErr)}}
+	}
+	r := s.newExecRequest(ctx, doc, op, variables)
+	extensions, qErr := s.complexity(doc, op, r.Vars)
+	if qErr != nil {
+		return nil, &Response{Errors: []*errors.QueryError{qErr}, Extensions: extensions}
+	}
+	return &execOperation{req: r, op: op, query: queryString, name: operationName, varTypes: varTypes, extensions: extensions}, nil
+}
+
+// checkOperation reports an error if the operation can not be executed by Exec.
+func (s *Schema) checkOperation(op *ast.OperationDefinition) *errors.QueryError {
 	// Subscriptions are not valid in Exec. Use schema.Subscribe() instead.
 	if op.Type == query.Subscription {
-		return &Response{Errors: []*errors.QueryError{{Message: "graphql-ws protocol header is missing"}}}
+		return &errors.QueryError{Message: "graphql-ws protocol header is missing"}
 	}
 	if op.Type == query.Mutation {
 		if _, ok := s.schema.RootOperationTypes["mutation"]; !ok {
-			return &Response{Errors: []*errors.QueryError{{Message: "no mutations are offered by the schema"}}}
+			return &errors.QueryError{Message: "no mutations are offered by the schema"}
 		}
 	}
+	return nil
+}
 
-	// Fill in variables with the defaults from the operation
+// variableTypes returns the types of the variables of the operation, which are reported to the tracer.
+func (s *Schema) variableTypes(op *ast.OperationDefinition) (map[string]*introspection.Type, *errors.QueryError) {
+	varTypes := make(map[string]*introspection.Type)
+	for _, v := range op.Vars {
+		t, err := common.ResolveType(v.Type, s.schema.Resolve)
+		if err != nil {
+			return nil, err
+		}
+		varTypes[v.Name.Name] = introspection.WrapType(t)
+	}
+	return varTypes, nil
+}
+
+// newExecRequest returns the request which executes the operation with the variables. Missing variables are
+// filled in with the defaults from the operation.
+func (s *Schema) newExecRequest(ctx context.Context, doc *ast.ExecutableDefinition, op *ast.OperationDefinition, variables map[string]interface{}) *exec.Request {
 	if variables == nil {
 		variables = make(map[string]interface{}, len(op.Vars))
 	}
@@ -308,7 +456,7 @@ func (s *Schema) exec(ctx context.Context, queryString string, operationName str
 		}
 	}
 
-	r := &exec.Request{
+	return &exec.Request{
 		Request: selected.Request{
 			Doc:                doc,
 			Vars:               variables,
@@ -319,22 +467,7 @@ func (s *Schema) exec(ctx context.Context, queryString string, operationName str
 		Tracer:       s.tracer,
 		Logger:       s.logger,
 		PanicHandler: s.panicHandler,
-	}
-	varTypes := make(map[string]*introspection.Type)
-	for _, v := range op.Vars {
-		t, err := common.ResolveType(v.Type, s.schema.Resolve)
-		if err != nil {
-			return &Response{Errors: []*errors.QueryError{err}}
-		}
-		varTypes[v.Name.Name] = introspection.WrapType(t)
-	}
-	traceCtx, finish := s.tracer.TraceQuery(ctx, queryString, operationName, variables, varTypes)
-	data, errs := r.Execute(traceCtx, res, op)
-	finish(errs)
-
-	return &Response{
-		Data:   data,
-		Errors: errs,
+		Loaders:      batchLookup(s.loaders),
 	}
 }
 
@@ -379,6 +512,31 @@ func validateRootOp(s *ast.Schema, name string, mandatory bool) error {
 	return nil
 }
 
+// OperationType returns the type of the operation which the request selects, e.g. to refuse mutations in GET
+// requests before they are executed. The query is looked up and parsed like by [Schema.Exec]: documents which are
+// not trusted, see [UseTrustedDocuments], are rejected before they are parsed, and the document is subject to the
+// [MaxQueryLength] and kept in the [DocumentCache] for the execution. It returns an empty type if the request fails
+// before the execution, which reports the error.
+func (s *Schema) OperationType(ctx context.Context, req *Request) ast.OperationType {
+	queryString, _, _, qErr := s.lookupQuery(ctx, req)
+	if qErr != nil || s.maxQueryLength > 0 && len(queryString) > s.maxQueryLength {
+		return ""
+	}
+	d, qErr := s.parse(queryString)
+	if qErr != nil {
+		return ""
+	}
+	if s.documentCache != nil {
+		// the document is validated to be stored in the cache, the errors are reported by the execution
+		s.validateDocument(d)
+	}
+	op, err := getOperation(d.doc, req.OperationName)
+	if err != nil {
+		return ""
+	}
+	return op.Type
+}
+
 func getOperation(document *ast.ExecutableDefinition, operationName string) (*ast.OperationDefinition, error) {
 	if len(document.Operations) == 0 {
 		return nil, fmt.Errorf("no operations in query document")
@@ -0,0 +1,97 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/exec"
+)
+
+// IncrementalDeliveryDirectives declares the @defer and @stream directives. Incremental delivery is enabled by
+// adding these declarations to the schema, which makes them available to clients via introspection as well.
+const IncrementalDeliveryDirectives = `
+	directive @defer(label: String, if: Boolean! = true) on FRAGMENT_SPREAD | INLINE_FRAGMENT
+	directive @stream(label: String, if: Boolean! = true, initialCount: Int = 0) on FIELD
+`
+
+// IncrementalResult is the result of a deferred fragment or of streamed list items, which is delivered in
+// a subsequent payload of an operation executed with [Schema.ExecIncremental].
+type IncrementalResult struct {
+	Errors []*errors.QueryError `json:"errors,omitempty"`
+	Data   json.RawMessage      `json:"data,omitempty"`
+	Items  json.RawMessage      `json:"items,omitempty"`
+	Path   []interface{}        `json:"path"`
+	Label  string               `json:"label,omitempty"`
+}
+
+// ExecIncremental executes the given request like [Schema.ExecRequest], but delivers fragments marked with @defer
+// and list items marked with @stream in subsequent payloads. The first response holds the initial result. If more
+// payloads follow, HasNext is set to true and the subsequent responses hold the incremental results, until a response
+// with HasNext set to false closes the channel. Operations without deferred or streamed selections result in a
+// single response without HasNext. It panics if the schema was created without a resolver.
+//
+// Other ways to execute an operation ignore the @defer and @stream directives.
+func (s *Schema) ExecIncremental(ctx context.Context, req *Request) <-chan *Response {
+	if !s.res.QueryResolver.IsValid() {
+		panic("schema created without resolver, can not exec")
+	}
+
+	c := make(chan *Response, 1)
+	ctx = s.requestStart(ctx, req)
+	o, resp := s.prepareExec(ctx, req)
+	if resp != nil {
+		c <- s.present(ctx, resp)
+		close(c)
+		return c
+	}
+	ctx = s.beforeExecution(ctx, o.op, o.req.Vars)
+	ctx, extensions := exec.WithExtensions(ctx)
+
+	traceCtx, finish := s.tracer.TraceQuery(ctx, o.query, o.name, o.req.Vars, o.varTypes)
+	data, errs, payloads := o.req.ExecuteIncremental(traceCtx, s.res, o.op)
+	if payloads == nil {
+		finish(errs)
+		c <- s.present(ctx, &Response{Data: data, Errors: errs, Extensions: extensions.MergeInto(o.extensions)})
+		close(c)
+		return c
+	}
+
+	c <- s.present(ctx, &Response{Data: data, Errors: errs, HasNext: boolPtr(true), Extensions: extensions.MergeInto(o.extensions)})
+	go func() {
+		defer close(c)
+		allErrs := errs
+		finished := false
+		for p := range payloads {
+			allErrs = append(allErrs, p.Errors...)
+			if !p.HasNext {
+				// the tracer is finished before the last payload, so that the extensions it adds are delivered
+				finish(allErrs)
+				finished = true
+			}
+			resp := &Response{
+				Incremental: []*IncrementalResult{{
+					Errors: p.Errors,
+					Data:   p.Data,
+					Items:  p.Items,
+					Path:   p.Path,
+					Label:  p.Label,
+				}},
+				HasNext:    boolPtr(p.HasNext),
+				Extensions: extensions.MergeInto(nil),
+			}
+			select {
+			case c <- s.present(ctx, resp):
+			case <-ctx.Done():
+			}
+		}
+		if !finished {
+			finish(allErrs)
+		}
+	}()
+	return c
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
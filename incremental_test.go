@@ -0,0 +1,278 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/introspection"
+)
+
+const incrementalSchema = graphql.IncrementalDeliveryDirectives + `
+	type Query {
+		hero: Hero!
+		rival: Hero
+		numbers: [Int!]!
+	}
+
+	type Mutation {
+		like: Hero!
+	}
+
+	type Hero {
+		name: String!
+		friends: [Hero!]!
+		bio: String!
+		broken: String!
+	}
+`
+
+type incrementalResolver struct{}
+
+func (incrementalResolver) Hero() *heroResolver  { return &heroResolver{name: "Luke"} }
+func (incrementalResolver) Rival() *heroResolver { return &heroResolver{name: "Vader"} }
+func (incrementalResolver) Like() *heroResolver  { return &heroResolver{name: "Luke"} }
+func (incrementalResolver) Numbers() []int32     { return []int32{1, 2, 3} }
+
+type heroResolver struct {
+	name string
+}
+
+func (h *heroResolver) Name() string { return h.name }
+func (h *heroResolver) Bio() string  { return h.name + " is a hero" }
+
+func (h *heroResolver) Broken() (string, error) {
+	return "", errors.New("broken")
+}
+
+func (h *heroResolver) Friends() []*heroResolver {
+	return []*heroResolver{{name: "Han"}, {name: "Leia"}, {name: "R2-D2"}}
+}
+
+func collectIncremental(t *testing.T, s *graphql.Schema, query string) []string {
+	t.Helper()
+	var got []string
+	for resp := range s.ExecIncremental(context.Background(), &graphql.Request{Query: query}) {
+		b, err := json.Marshal(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(b))
+	}
+	return got
+}
+
+func TestExecIncremental(t *testing.T) {
+	t.Parallel()
+
+	s := graphql.MustParseSchema(incrementalSchema, &incrementalResolver{})
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+		// the order of the subsequent payloads is not deterministic if there are several
+		unordered bool
+	}{
+		{
+			name:  "no_incremental_selections",
+			query: `{ hero { name } }`,
+			want:  []string{`{"data":{"hero":{"name":"Luke"}}}`},
+		},
+		{
+			name:  "defer_inline_fragment",
+			query: `{ hero { name ... @defer(label: "bio") { bio } } }`,
+			want: []string{
+				`{"data":{"hero":{"name":"Luke"}},"hasNext":true}`,
+				`{"incremental":[{"data":{"bio":"Luke is a hero"},"path":["hero"],"label":"bio"}],"hasNext":false}`,
+			},
+		},
+		{
+			name:  "defer_fragment_spread",
+			query: `{ hero { name ...Bio @defer } } fragment Bio on Hero { bio }`,
+			want: []string{
+				`{"data":{"hero":{"name":"Luke"}},"hasNext":true}`,
+				`{"incremental":[{"data":{"bio":"Luke is a hero"},"path":["hero"]}],"hasNext":false}`,
+			},
+		},
+		{
+			name:  "defer_disabled",
+			query: `{ hero { name ... @defer(if: false) { bio } } }`,
+			want:  []string{`{"data":{"hero":{"name":"Luke","bio":"Luke is a hero"}}}`},
+		},
+		{
+			name:  "nested_defer",
+			query: `{ hero { ... @defer(label: "outer") { name ... @defer(label: "inner") { bio } } } }`,
+			want: []string{
+				`{"data":{"hero":{}},"hasNext":true}`,
+				`{"incremental":[{"data":{"name":"Luke"},"path":["hero"],"label":"outer"}],"hasNext":true}`,
+				`{"incremental":[{"data":{"bio":"Luke is a hero"},"path":["hero"],"label":"inner"}],"hasNext":false}`,
+			},
+		},
+		{
+			name:  "deferred_error",
+			query: `{ hero { name ... @defer { broken } } }`,
+			want: []string{
+				`{"data":{"hero":{"name":"Luke"}},"hasNext":true}`,
+				`{"incremental":[{"errors":[{"message":"broken","path":["hero","broken"]}],"data":null,"path":["hero"]}],"hasNext":false}`,
+			},
+		},
+		{
+			name:  "stream",
+			query: `{ numbers @stream(initialCount: 1, label: "numbers") }`,
+			want: []string{
+				`{"data":{"numbers":[1]},"hasNext":true}`,
+				`{"incremental":[{"items":[2],"path":["numbers",1],"label":"numbers"}],"hasNext":true}`,
+				`{"incremental":[{"items":[3],"path":["numbers",2],"label":"numbers"}],"hasNext":false}`,
+			},
+		},
+		{
+			name:  "stream_objects",
+			query: `{ hero { friends @stream(initialCount: 2) { name } } }`,
+			want: []string{
+				`{"data":{"hero":{"friends":[{"name":"Han"},{"name":"Leia"}]}},"hasNext":true}`,
+				`{"incremental":[{"items":[{"name":"R2-D2"}],"path":["hero","friends",2]}],"hasNext":false}`,
+			},
+		},
+		{
+			name:  "stream_initial_count_covers_list",
+			query: `{ numbers @stream(initialCount: 5) }`,
+			want:  []string{`{"data":{"numbers":[1,2,3]}}`},
+		},
+		{
+			name:      "defer_in_list",
+			query:     `{ hero { friends { name ... @defer { bio } } } }`,
+			unordered: true,
+			want: []string{
+				`{"data":{"hero":{"friends":[{"name":"Han"},{"name":"Leia"},{"name":"R2-D2"}]}},"hasNext":true}`,
+				`{"incremental":[{"data":{"bio":"Han is a hero"},"path":["hero","friends",0]}],"hasNext":`,
+				`{"incremental":[{"data":{"bio":"Leia is a hero"},"path":["hero","friends",1]}],"hasNext":`,
+				`{"incremental":[{"data":{"bio":"R2-D2 is a hero"},"path":["hero","friends",2]}],"hasNext":`,
+			},
+		},
+		{
+			name:  "nulled_parent",
+			query: `{ hero { broken ... @defer { bio } } }`,
+			want:  []string{`{"errors":[{"message":"broken","path":["hero","broken"]}],"data":null}`},
+		},
+		{
+			name:  "nulled_nullable_parent",
+			query: `{ hero { name } rival { friends { name ... @defer { bio } } broken } }`,
+			want:  []string{`{"errors":[{"message":"broken","path":["rival","broken"]}],"data":{"hero":{"name":"Luke"},"rival":null}}`},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got := collectIncremental(t, s, tt.query)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d payloads, want %d:\n%s", len(got), len(tt.want), strings.Join(got, "\n"))
+			}
+			if !tt.unordered {
+				for i := range got {
+					if got[i] != tt.want[i] {
+						t.Errorf("payload %d: got %s, want %s", i, got[i], tt.want[i])
+					}
+				}
+				return
+			}
+
+			if got[0] != tt.want[0] {
+				t.Errorf("got initial payload %s, want %s", got[0], tt.want[0])
+			}
+			for _, want := range tt.want[1:] {
+				found := false
+				for _, g := range got[1:] {
+					found = found || strings.HasPrefix(g, want)
+				}
+				if !found {
+					t.Errorf("missing payload %s in:\n%s", want, strings.Join(got, "\n"))
+				}
+			}
+			if last := got[len(got)-1]; !strings.HasSuffix(last, `"hasNext":false}`) {
+				t.Errorf("got last payload %s, want hasNext false", last)
+			}
+		})
+	}
+}
+
+// extensionTracer adds an extension when the tracing of a query finishes.
+type extensionTracer struct{}
+
+func (extensionTracer) TraceQuery(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, varTypes map[string]*introspection.Type) (context.Context, func([]*qerrors.QueryError)) {
+	return ctx, func(errs []*qerrors.QueryError) {
+		graphql.AddExtension(ctx, "traced", len(errs))
+	}
+}
+
+func (extensionTracer) TraceField(ctx context.Context, label, typeName, fieldName string, trivial bool, args map[string]interface{}) (context.Context, func(*qerrors.QueryError)) {
+	return ctx, func(*qerrors.QueryError) {}
+}
+
+func TestExecIncremental_TracerExtensions(t *testing.T) {
+	t.Parallel()
+
+	s := graphql.MustParseSchema(incrementalSchema, &incrementalResolver{}, graphql.Tracer(extensionTracer{}))
+	got := collectIncremental(t, s, `{ hero { name ... @defer { broken } } }`)
+	want := []string{
+		`{"data":{"hero":{"name":"Luke"}},"hasNext":true}`,
+		`{"incremental":[{"errors":[{"message":"broken","path":["hero","broken"]}],"data":null,"path":["hero"]}],"hasNext":false,"extensions":{"traced":1}}`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExec_IgnoresIncrementalDirectives(t *testing.T) {
+	t.Parallel()
+
+	s := graphql.MustParseSchema(incrementalSchema, &incrementalResolver{})
+	resp := s.Exec(context.Background(), `{ hero { name ... @defer { bio } friends @stream { name } } }`, "", nil)
+	want := `{"data":{"hero":{"name":"Luke","bio":"Luke is a hero","friends":[{"name":"Han"},{"name":"Leia"},{"name":"R2-D2"}]}}}`
+	if got := marshalResponse(t, resp); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestIncrementalValidation(t *testing.T) {
+	t.Parallel()
+
+	s := graphql.MustParseSchema(incrementalSchema, &incrementalResolver{})
+	tests := map[string]struct {
+		query string
+		want  string
+	}{
+		"stream_on_non_list": {
+			query: `{ hero @stream { name } }`,
+			want:  `Stream directive cannot be used on non-list field "hero" on type "Query".`,
+		},
+		"defer_on_mutation_root": {
+			query: `mutation { ... @defer { like { name } } }`,
+			want:  `Directive "@defer" cannot be used on root mutation type.`,
+		},
+		"duplicate_label": {
+			query: `{ hero { ... @defer(label: "a") { name } ... @defer(label: "a") { bio } } }`,
+			want:  `Defer/Stream directive label argument must be unique.`,
+		},
+		"dynamic_label": {
+			query: `query($label: String) { hero { ... @defer(label: $label) { name } } }`,
+			want:  `Directive "@defer"'s label argument must be a static string.`,
+		},
+	}
+	for name, tt := range tests {
+		errs := s.Validate(tt.query)
+		if len(errs) != 1 || errs[0].Message != tt.want {
+			t.Errorf("%s: got %v, want %q", name, errs, tt.want)
+		}
+	}
+
+	// Without the declarations, the directives are unknown.
+	plain := graphql.MustParseSchema(`type Query { hello: String! }`, &helloWorldResolver1{})
+	if errs := plain.Validate(`{ ... @defer { hello } }`); len(errs) != 1 || errs[0].Message != `Unknown directive "@defer".` {
+		t.Errorf("got %v, want an unknown directive error", errs)
+	}
+}
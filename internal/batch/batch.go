@@ -0,0 +1,324 @@
+// Package batch schedules the batched loads of an execution. Loads are queued until every goroutine of the
+// execution either waits for a load or has nothing left to do, and are then dispatched together. As the executor
+// resolves the fields of a level concurrently, a batch contains the loads of all fields of the same depth.
+package batch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/trace/tracer"
+)
+
+// Func loads the values of the keys. It returns a value and an error slice, each with an entry per key in the
+// order of the keys. The error slice may be nil if no key failed.
+type Func func(ctx context.Context, keys []interface{}) ([]interface{}, []error)
+
+// Lookup returns the batch function registered for the type.
+type Lookup func(typ string) (Func, bool)
+
+type ctxKey string
+
+const (
+	schedulerKey ctxKey = "scheduler"
+	limiterKey   ctxKey = "limiter"
+)
+
+// Scheduler tracks the running goroutines of an execution and dispatches the queued loads when all of them are
+// blocked. Every goroutine of the execution must be registered with a [Group], otherwise the loads are dispatched
+// before the goroutine queued its keys or, if it waits for a load itself, never.
+type Scheduler struct {
+	ctx    context.Context
+	lookup Lookup
+	tracer tracer.Tracer
+
+	mu       sync.Mutex
+	running  int
+	inflight int
+	loaders  map[string]*loader
+	queued   []*loader
+}
+
+type loader struct {
+	typ     string
+	fn      Func
+	err     error
+	cache   map[interface{}]*result
+	keys    []interface{}
+	results []*result
+}
+
+type result struct {
+	finished bool
+	waiters  []*waiter
+	value    interface{}
+	err      error
+}
+
+// waiter is a goroutine waiting for the results of a load, which is woken up once all of them are finished.
+type waiter struct {
+	pending int
+	done    chan struct{}
+}
+
+// NewContext returns a context with a new scheduler for an execution, which is started by the calling goroutine.
+// The returned function must be called when the calling goroutine is done with the execution.
+func NewContext(ctx context.Context, lookup Lookup, t tracer.Tracer) (context.Context, func()) {
+	s := &Scheduler{
+		lookup:  lookup,
+		tracer:  t,
+		running: 1,
+		loaders: make(map[string]*loader),
+	}
+	s.ctx = context.WithValue(ctx, schedulerKey, s)
+	return s.ctx, s.stop
+}
+
+// FromContext returns the scheduler of the execution, or nil if batching is not enabled.
+func FromContext(ctx context.Context) *Scheduler {
+	s, _ := ctx.Value(schedulerKey).(*Scheduler)
+	return s
+}
+
+// WithLimiter marks that the goroutine resolving with the context holds a slot of the limiter, which is released
+// while the goroutine waits for a load.
+func WithLimiter(ctx context.Context, limiter chan struct{}) context.Context {
+	return context.WithValue(ctx, limiterKey, limiter)
+}
+
+// Load queues the keys for the batch function of the type and waits until they are loaded. The keys must be
+// comparable, as the values are cached for the execution.
+func (s *Scheduler) Load(ctx context.Context, typ string, keys []interface{}) ([]interface{}, []error) {
+	values := make([]interface{}, len(keys))
+	errs := make([]error, len(keys))
+
+	s.mu.Lock()
+	l, ok := s.loaders[typ]
+	if !ok {
+		l = &loader{typ: typ, cache: make(map[interface{}]*result)}
+		if l.fn, ok = s.lookup(typ); !ok {
+			l.err = fmt.Errorf("no batch function is registered for type %q", typ)
+		}
+		s.loaders[typ] = l
+	}
+	if l.err != nil {
+		s.mu.Unlock()
+		for i := range errs {
+			errs[i] = l.err
+		}
+		return values, errs
+	}
+
+	results := make([]*result, len(keys))
+	w := &waiter{done: make(chan struct{})}
+	for i, key := range keys {
+		res, ok := l.cache[key]
+		if !ok {
+			res = &result{}
+			l.cache[key] = res
+			if len(l.keys) == 0 {
+				s.queued = append(s.queued, l)
+			}
+			l.keys = append(l.keys, key)
+			l.results = append(l.results, res)
+		}
+		results[i] = res
+		if !res.finished {
+			w.pending++
+			res.waiters = append(res.waiters, w)
+		}
+	}
+
+	if w.pending == 0 {
+		s.mu.Unlock()
+	} else {
+		s.park()
+		s.mu.Unlock()
+
+		limiter, _ := ctx.Value(limiterKey).(chan struct{})
+		if limiter != nil {
+			<-limiter
+		}
+		err := s.wait(ctx, w)
+		if limiter != nil {
+			limiter <- struct{}{}
+		}
+		if err != nil {
+			for i := range errs {
+				errs[i] = err
+			}
+			return values, errs
+		}
+	}
+
+	for i, res := range results {
+		values[i], errs[i] = res.value, res.err
+	}
+	return values, errs
+}
+
+// wait waits until the results of the waiter are loaded or the context is done.
+func (s *Scheduler) wait(ctx context.Context, w *waiter) error {
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	abandoned := w.pending > 0
+	if abandoned {
+		// The waiter is never woken up, so the goroutine has to count itself as running again.
+		w.pending = -1
+		s.running++
+	}
+	s.mu.Unlock()
+	if !abandoned {
+		<-w.done
+		return nil
+	}
+	return ctx.Err()
+}
+
+// park marks the calling goroutine as blocked and dispatches the queued loads if no goroutine is running anymore.
+// s.mu must be held.
+func (s *Scheduler) park() {
+	s.running--
+	if s.running == 0 && s.inflight == 0 && len(s.queued) != 0 {
+		s.dispatch()
+	}
+}
+
+func (s *Scheduler) stop() {
+	s.mu.Lock()
+	s.park()
+	s.mu.Unlock()
+}
+
+// dispatch starts the queued batches. s.mu must be held.
+func (s *Scheduler) dispatch() {
+	for _, l := range s.queued {
+		keys, results := l.keys, l.results
+		l.keys, l.results = nil, nil
+		s.inflight++
+		go s.run(l, keys, results)
+	}
+	s.queued = nil
+}
+
+func (s *Scheduler) run(l *loader, keys []interface{}, results []*result) {
+	values, errs := s.call(l, keys)
+
+	var woken []*waiter
+	s.mu.Lock()
+	for i, res := range results {
+		res.value = values[i]
+		if errs != nil {
+			res.err = errs[i]
+		}
+		res.finished = true
+		for _, w := range res.waiters {
+			if w.pending == -1 {
+				continue // abandoned because the context of the load is done
+			}
+			if w.pending--; w.pending == 0 {
+				// The woken goroutine is counted as running before it gets scheduled, so that the loads it
+				// queues next are not dispatched too early.
+				s.running++
+				woken = append(woken, w)
+			}
+		}
+		res.waiters = nil
+	}
+	s.inflight--
+	if s.running == 0 && s.inflight == 0 && len(s.queued) != 0 {
+		s.dispatch()
+	}
+	s.mu.Unlock()
+
+	for _, w := range woken {
+		close(w.done)
+	}
+}
+
+// call calls the batch function and traces it. It ensures that there is a value and an error per key.
+func (s *Scheduler) call(l *loader, keys []interface{}) (values []interface{}, errs []error) {
+	ctx, finish := traceBatch(s.ctx, s.tracer, l.typ, keys)
+	defer func() {
+		if v := recover(); v != nil {
+			values, errs = failAll(keys, fmt.Errorf("panic occurred in the batch function of type %q: %v", l.typ, v))
+		}
+		finish(errs)
+	}()
+
+	values, errs = l.fn(ctx, keys)
+	if len(values) != len(keys) || (errs != nil && len(errs) != len(keys)) {
+		return failAll(keys, fmt.Errorf("the batch function of type %q returned %d values and %d errors for %d keys", l.typ, len(values), len(errs), len(keys)))
+	}
+	return values, errs
+}
+
+func failAll(keys []interface{}, err error) ([]interface{}, []error) {
+	errs := make([]error, len(keys))
+	for i := range errs {
+		errs[i] = err
+	}
+	return make([]interface{}, len(keys)), errs
+}
+
+func traceBatch(ctx context.Context, t tracer.Tracer, typ string, keys []interface{}) (context.Context, tracer.BatchFinishFunc) {
+	if bt, ok := t.(tracer.BatchTracer); ok {
+		return bt.TraceBatch(ctx, typ, keys)
+	}
+	traceCtx, finish := t.TraceField(ctx, "GraphQL batch: "+typ, typ, "", false, map[string]interface{}{"keys": len(keys)})
+	return traceCtx, func(errs []error) {
+		for _, err := range errs {
+			if err != nil {
+				finish(errors.Errorf("%s", err))
+				return
+			}
+		}
+		finish(nil)
+	}
+}
+
+// Group waits for goroutines of an execution like a sync.WaitGroup. While a goroutine waits for the group it
+// is not counted as running, the last goroutine of the group hands its running state over to it instead. A group
+// of a nil scheduler doesn't track the goroutines.
+type Group struct {
+	s    *Scheduler
+	wg   sync.WaitGroup
+	n    int
+	left int32
+}
+
+// NewGroup returns a group of n goroutines, which are counted as running from now on.
+func (s *Scheduler) NewGroup(n int) *Group {
+	g := &Group{s: s, n: n, left: int32(n)}
+	g.wg.Add(n)
+	if s != nil {
+		s.mu.Lock()
+		s.running += n
+		s.mu.Unlock()
+	}
+	return g
+}
+
+// Done marks a goroutine of the group as done.
+func (g *Group) Done() {
+	if g.s != nil && atomic.AddInt32(&g.left, -1) != 0 {
+		g.s.stop()
+	}
+	g.wg.Done()
+}
+
+// Wait waits until all goroutines of the group are done.
+func (g *Group) Wait() {
+	if g.s != nil && g.n != 0 {
+		g.s.stop()
+	}
+	g.wg.Wait()
+}
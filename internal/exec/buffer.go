@@ -0,0 +1,34 @@
+package exec
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBufferSize is the capacity up to which buffers are returned to the pool. Larger buffers are left to the
+// garbage collector, so that a single huge response doesn't pin its memory.
+const maxPooledBufferSize = 4 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// GetBuffer returns an empty buffer from the pool which is shared by all executions.
+func GetBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// PutBuffer returns the buffer to the pool. The buffer and the slices returned by its Bytes method must not be used
+// afterwards.
+func PutBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBufferSize {
+		return
+	}
+	b.Reset()
+	bufferPool.Put(b)
+}
+
+// isNull reports whether the serialized value is null.
+func isNull(b []byte) bool {
+	return string(b) == "null"
+}
@@ -6,11 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
-	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/graph-gophers/graphql-go/ast"
 	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/batch"
 	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
 	"github.com/graph-gophers/graphql-go/internal/exec/selected"
 	"github.com/graph-gophers/graphql-go/internal/query"
@@ -32,6 +33,13 @@ type Request struct {
 	Logger                   log.Logger
 	PanicHandler             errors.PanicHandler
 	SubscribeResolverTimeout time.Duration
+	// Loaders returns the batch functions of the loader package. Batching is disabled if it is nil.
+	Loaders batch.Lookup
+
+	// sched schedules the batched loads of the execution, it is nil if batching is disabled.
+	sched *batch.Scheduler
+	// pending collects the deferred fragments and streamed list items of an incremental execution.
+	pending *pendingTasks
 }
 
 func (r *Request) handlePanic(ctx context.Context) {
@@ -41,15 +49,56 @@ func (r *Request) handlePanic(ctx context.Context) {
 	}
 }
 
+// startBatching creates the scheduler of the batched loads for an execution, which is started by the calling
+// goroutine. The returned function must be called when the goroutine is done with the execution.
+func (r *Request) startBatching(ctx context.Context) (context.Context, func()) {
+	if r.Loaders == nil {
+		return ctx, func() {}
+	}
+	ctx, stop := batch.NewContext(ctx, r.Loaders, r.Tracer)
+	r.sched = batch.FromContext(ctx)
+	return ctx, stop
+}
+
 type extensionser interface {
 	Extensions() map[string]interface{}
 }
 
 func (r *Request) Execute(ctx context.Context, s *resolvable.Schema, op *ast.OperationDefinition) ([]byte, []*errors.QueryError) {
+	return r.ExecutePlan(ctx, s, op, nil)
+}
+
+// ExecutePlan executes the operation like Execute with the selections returned by selected.ApplyOperation for
+// the operation. As the selections depend on the variables and the request, they may only be reused for operations
+// without variables and with the same AllowIntrospection setting. If sels is nil, the selections are applied.
+func (r *Request) ExecutePlan(ctx context.Context, s *resolvable.Schema, op *ast.OperationDefinition, sels []selected.Selection) ([]byte, []*errors.QueryError) {
 	var out bytes.Buffer
+	errs := r.ExecuteTo(ctx, s, op, sels, &out)
+	if out.Len() == 0 {
+		return nil, errs
+	}
+	return out.Bytes(), errs
+}
+
+// ExecuteTo executes the operation like ExecutePlan and appends the serialized data to out, which is usually a
+// buffer of GetBuffer. The values of the fields are written to out directly unless they are resolved concurrently,
+// then they are written to pooled buffers first. Nothing is appended if the execution failed without data.
+func (r *Request) ExecuteTo(ctx context.Context, s *resolvable.Schema, op *ast.OperationDefinition, sels []selected.Selection, out *bytes.Buffer) []*errors.QueryError {
+	start := out.Len()
 	func() {
+		ctx, stop := r.startBatching(ctx)
+		defer stop()
+		completed := false
+		defer func() {
+			// A panic leaves a partially written value behind, which is not valid JSON.
+			if !completed {
+				out.Truncate(start)
+			}
+		}()
 		defer r.handlePanic(ctx)
-		sels := selected.ApplyOperation(&r.Request, s, op)
+		if sels == nil {
+			sels = selected.ApplyOperation(&r.Request, s, op)
+		}
 		var resolver reflect.Value
 		switch op.Type {
 		case query.Query:
@@ -64,18 +113,21 @@ func (r *Request) Execute(ctx context.Context, s *resolvable.Schema, op *ast.Ope
 
 		if errs := validateSelections(ctx, sels, nil, s); errs != nil {
 			r.Errs = errs
-			out.Write([]byte("null"))
+			out.WriteString("null")
+			completed = true
 			return
 		}
 
-		r.execSelections(ctx, sels, nil, s, resolver, &out, op.Type == query.Mutation)
+		r.execSelections(ctx, sels, nil, s, resolver, out, op.Type == query.Mutation)
+		completed = true
 	}()
 
 	if err := ctx.Err(); err != nil {
-		return nil, []*errors.QueryError{errors.Errorf("%s", err)}
+		out.Truncate(start)
+		return []*errors.QueryError{errors.Errorf("%s", err)}
 	}
 
-	return out.Bytes(), r.Errs
+	return r.Errs
 }
 
 type fieldToValidate struct {
@@ -90,50 +142,60 @@ type fieldToExec struct {
 	out      *bytes.Buffer
 }
 
-func (f *fieldToExec) resolve(ctx context.Context) (output interface{}, err error) {
-	return f.field.Resolve(ctx, f.resolver)
+func (f *fieldToExec) resolve(ctx context.Context, path *pathSegment) (output interface{}, err error) {
+	var p []interface{}
+	if len(f.field.Middleware) != 0 {
+		p = path.toSlice()
+	}
+	return f.field.Resolve(ctx, f.resolver, p)
 }
 
 func resolvedToNull(b *bytes.Buffer) bool {
-	return bytes.Equal(b.Bytes(), []byte("null"))
+	return isNull(b.Bytes())
+}
+
+// handleValuePanic is like handlePanic for a goroutine which writes the value at path to its own buffer. The
+// partially written value is replaced with null, so that the buffer holds valid JSON.
+func (r *Request) handleValuePanic(ctx context.Context, path *pathSegment, out *bytes.Buffer) {
+	if value := recover(); value != nil {
+		r.Logger.LogPanic(ctx, value)
+		r.AddError(r.PanicHandler.MakePanicError(ctx, value))
+		out.Reset()
+		out.WriteString("null")
+		r.pending.null(path)
+	}
 }
 
 func (r *Request) execSelections(ctx context.Context, sels []selected.Selection, path *pathSegment, s *resolvable.Schema, resolver reflect.Value, out *bytes.Buffer, serially bool) {
 	async := !serially && selected.HasAsyncSel(sels)
 
 	var fields []*fieldToExec
-	collectFieldsToResolve(sels, s, resolver, &fields, make(map[string]*fieldToExec))
+	var deferred []*deferredFragment
+	collectDeferred := &deferred
+	if r.pending == nil {
+		collectDeferred = nil
+	}
+	collectFieldsToResolve(sels, s, resolver, &fields, make(map[string]*fieldToExec), collectDeferred)
 
 	if async {
-		var wg sync.WaitGroup
-		wg.Add(len(fields))
+		wg := r.sched.NewGroup(len(fields))
 		for _, f := range fields {
+			f.out = GetBuffer()
 			go func(f *fieldToExec) {
 				defer wg.Done()
-				defer r.handlePanic(ctx)
-				f.out = new(bytes.Buffer)
-				execFieldSelection(ctx, r, s, f, &pathSegment{path, f.field.Alias}, true)
+				fieldPath := &pathSegment{path, f.field.Alias}
+				defer r.handleValuePanic(ctx, fieldPath, f.out)
+				execFieldSelection(ctx, r, s, f, fieldPath, true)
 			}(f)
 		}
 		wg.Wait()
-	} else {
-		for _, f := range fields {
-			f.out = new(bytes.Buffer)
-			execFieldSelection(ctx, r, s, f, &pathSegment{path, f.field.Alias}, true)
-		}
 	}
 
+	// The fields are written to out directly, if one of them turns its parent into null the object is truncated.
+	start := out.Len()
+	nulled := false
 	out.WriteByte('{')
 	for i, f := range fields {
-		// If a non-nullable child resolved to null, an error was added to the
-		// "errors" list in the response, so this field resolves to null.
-		// If this field is non-nullable, the error is propagated to its parent.
-		if _, ok := f.field.Type.(*ast.NonNull); ok && resolvedToNull(f.out) {
-			out.Reset()
-			out.Write([]byte("null"))
-			return
-		}
-
 		if i > 0 {
 			out.WriteByte(',')
 		}
@@ -141,12 +203,39 @@ func (r *Request) execSelections(ctx context.Context, sels []selected.Selection,
 		out.WriteString(f.field.Alias)
 		out.WriteByte('"')
 		out.WriteByte(':')
-		out.Write(f.out.Bytes())
+		valueStart := out.Len()
+		if async {
+			out.Write(f.out.Bytes())
+			PutBuffer(f.out)
+		} else {
+			f.out = out
+			execFieldSelection(ctx, r, s, f, &pathSegment{path, f.field.Alias}, true)
+		}
+		f.out = nil
+
+		// If a non-nullable child resolved to null, an error was added to the
+		// "errors" list in the response, so this field resolves to null.
+		// If this field is non-nullable, the error is propagated to its parent.
+		if _, ok := f.field.Type.(*ast.NonNull); ok && isNull(out.Bytes()[valueStart:]) {
+			nulled = true
+		}
 	}
 	out.WriteByte('}')
+	if nulled {
+		out.Truncate(start)
+		out.WriteString("null")
+		r.pending.null(path)
+		return
+	}
+
+	for _, d := range deferred {
+		r.pending.add(&task{ctx: ctx, label: d.Label, path: path, sels: d.Sels, resolver: d.resolver})
+	}
 }
 
-func collectFieldsToResolve(sels []selected.Selection, s *resolvable.Schema, resolver reflect.Value, fields *[]*fieldToExec, fieldByAlias map[string]*fieldToExec) {
+// collectFieldsToResolve collects the fields of the selections. Deferred fragments are collected separately,
+// if deferred is nil they are resolved with the other fields.
+func collectFieldsToResolve(sels []selected.Selection, s *resolvable.Schema, resolver reflect.Value, fields *[]*fieldToExec, fieldByAlias map[string]*fieldToExec, deferred *[]*deferredFragment) {
 	for _, sel := range sels {
 		switch sel := sel.(type) {
 		case *selected.SchemaField:
@@ -177,11 +266,18 @@ func collectFieldsToResolve(sels []selected.Selection, s *resolvable.Schema, res
 			}
 
 		case *selected.TypeAssertion:
-			out := resolver.Method(sel.MethodIndex).Call(nil)
-			if !out[1].Bool() {
+			res, ok := sel.Assert(resolver)
+			if !ok {
 				continue
 			}
-			collectFieldsToResolve(sel.Sels, s, out[0], fields, fieldByAlias)
+			collectFieldsToResolve(sel.Sels, s, res, fields, fieldByAlias, deferred)
+
+		case *selected.DeferredFragment:
+			if deferred == nil {
+				collectFieldsToResolve(sel.Sels, s, resolver, fields, fieldByAlias, nil)
+				continue
+			}
+			*deferred = append(*deferred, &deferredFragment{DeferredFragment: sel, resolver: resolver})
 
 		default:
 			panic("unreachable")
@@ -194,8 +290,7 @@ func typeOf(tf *selected.TypenameField, resolver reflect.Value) string {
 		return tf.Name
 	}
 	for name, a := range tf.TypeAssertions {
-		out := resolver.Method(a.MethodIndex).Call(nil)
-		if out[1].Bool() {
+		if _, ok := a.Assert(resolver); ok {
 			return name
 		}
 	}
@@ -216,6 +311,22 @@ func contextWithArguments(ctx context.Context, args map[string]interface{}) cont
 	return context.WithValue(ctx, argumentsKey, args)
 }
 
+// traceField traces the field with the path if the tracer implements tracer.FieldPathTracer.
+func traceField(ctx context.Context, t tracer.Tracer, f *fieldToExec, path *pathSegment) (context.Context, tracer.FieldFinishFunc) {
+	if pt, ok := t.(tracer.FieldPathTracer); ok {
+		return pt.TraceFieldPath(ctx, &tracer.FieldInfo{
+			Label:      f.field.TraceLabel,
+			TypeName:   f.field.TypeName,
+			FieldName:  f.field.Name,
+			ReturnType: f.field.Type.String(),
+			Trivial:    !f.field.Async,
+			Args:       f.field.Args,
+			Path:       path.toSlice(),
+		})
+	}
+	return t.TraceField(ctx, f.field.TraceLabel, f.field.TypeName, f.field.Name, !f.field.Async, f.field.Args)
+}
+
 func execFieldSelection(ctx context.Context, r *Request, s *resolvable.Schema, f *fieldToExec, path *pathSegment, applyLimiter bool) {
 	if applyLimiter {
 		r.Limiter <- struct{}{}
@@ -224,7 +335,7 @@ func execFieldSelection(ctx context.Context, r *Request, s *resolvable.Schema, f
 	var result reflect.Value
 	var err *errors.QueryError
 
-	traceCtx, finish := r.Tracer.TraceField(ctx, f.field.TraceLabel, f.field.TypeName, f.field.Name, !f.field.Async, f.field.Args)
+	traceCtx, finish := traceField(ctx, r.Tracer, f, path)
 	defer func() {
 		finish(err)
 	}()
@@ -247,7 +358,11 @@ func execFieldSelection(ctx context.Context, r *Request, s *resolvable.Schema, f
 			return errors.Errorf("%s", err) // don't execute any more resolvers if context got cancelled
 		}
 
-		res, resolverErr := f.resolve(ctx)
+		resolveCtx := ctx
+		if r.sched != nil {
+			resolveCtx = batch.WithLimiter(ctx, r.Limiter)
+		}
+		res, resolverErr := f.resolve(resolveCtx, path)
 		if len(f.field.Args) != 0 {
 			traceCtx = contextWithArguments(traceCtx, f.field.Args)
 		}
@@ -258,6 +373,10 @@ func execFieldSelection(ctx context.Context, r *Request, s *resolvable.Schema, f
 			err.ResolverError = resolverErr
 			if ex, ok := resolverErr.(extensionser); ok {
 				err.Extensions = ex.Extensions()
+			} else if qErr, ok := resolverErr.(*errors.QueryError); ok && qErr.Code() != "" {
+				// the errors of the coded constructors are reported as they are
+				err.Message = qErr.Message
+				err.Extensions = qErr.Extensions
 			}
 			return err
 		}
@@ -279,6 +398,9 @@ func execFieldSelection(ctx context.Context, r *Request, s *resolvable.Schema, f
 		return
 	}
 
+	if f.field.Stream != nil {
+		result = r.streamList(traceCtx, f, path, result)
+	}
 	r.execSelectionSet(traceCtx, f.sels, f.field.Type, path, s, result, f.out)
 }
 
@@ -316,6 +438,9 @@ func (r *Request) execSelectionSet(ctx context.Context, sels []selected.Selectio
 		r.execList(ctx, sels, t, path, s, resolver, out)
 
 	case *ast.ScalarTypeDefinition:
+		if writeBuiltinScalar(out, resolver) {
+			return
+		}
 		v := resolver.Interface()
 		data, err := json.Marshal(v)
 		if err != nil {
@@ -354,9 +479,37 @@ func (r *Request) execSelectionSet(ctx context.Context, sels []selected.Selectio
 
 func (r *Request) execList(ctx context.Context, sels []selected.Selection, typ *ast.List, path *pathSegment, s *resolvable.Schema, resolver reflect.Value, out *bytes.Buffer) {
 	l := resolver.Len()
-	entryouts := make([]bytes.Buffer, l)
+	var entryouts []*bytes.Buffer
 
 	if selected.HasAsyncSel(sels) {
+		entryouts = make([]*bytes.Buffer, l)
+		for i := range entryouts {
+			entryouts[i] = GetBuffer()
+		}
+	}
+	if selected.HasAsyncSel(sels) && r.sched != nil {
+		// The elements are resolved by as many workers as the Limiter allows. The workers belong to a group of the
+		// scheduler, so that the loads of the elements which they resolve at the same time end up in the same batches.
+		workers := cap(r.Limiter)
+		if workers > l {
+			workers = l
+		}
+		next := int32(-1)
+		wg := r.sched.NewGroup(workers)
+		for w := 0; w < workers; w++ {
+			go func() {
+				defer wg.Done()
+				for i := int(atomic.AddInt32(&next, 1)); i < l; i = int(atomic.AddInt32(&next, 1)) {
+					func() {
+						elemPath := &pathSegment{path, i}
+						defer r.handleValuePanic(ctx, elemPath, entryouts[i])
+						r.execSelectionSet(ctx, sels, typ.OfType, elemPath, s, resolver.Index(i), entryouts[i])
+					}()
+				}
+			}()
+		}
+		wg.Wait()
+	} else if selected.HasAsyncSel(sels) {
 		// Limit the number of concurrent goroutines spawned as it can lead to large
 		// memory spikes for large lists.
 		concurrency := cap(r.Limiter)
@@ -365,37 +518,46 @@ func (r *Request) execList(ctx context.Context, sels []selected.Selection, typ *
 			sem <- struct{}{}
 			go func(i int) {
 				defer func() { <-sem }()
-				defer r.handlePanic(ctx)
-				r.execSelectionSet(ctx, sels, typ.OfType, &pathSegment{path, i}, s, resolver.Index(i), &entryouts[i])
+				elemPath := &pathSegment{path, i}
+				defer r.handleValuePanic(ctx, elemPath, entryouts[i])
+				r.execSelectionSet(ctx, sels, typ.OfType, elemPath, s, resolver.Index(i), entryouts[i])
 			}(i)
 		}
 		for i := 0; i < concurrency; i++ {
 			sem <- struct{}{}
 		}
-	} else {
-		for i := 0; i < l; i++ {
-			r.execSelectionSet(ctx, sels, typ.OfType, &pathSegment{path, i}, s, resolver.Index(i), &entryouts[i])
-		}
 	}
 
 	_, listOfNonNull := typ.OfType.(*ast.NonNull)
 
+	// Synchronous elements are written to out directly, if one of them turns the list into null it is truncated.
+	start := out.Len()
+	nulled := false
 	out.WriteByte('[')
-	for i, entryout := range entryouts {
-		// If the list wraps a non-null type and one of the list elements
-		// resolves to null, then the entire list resolves to null.
-		if listOfNonNull && resolvedToNull(&entryout) {
-			out.Reset()
-			out.WriteString("null")
-			return
-		}
-
+	for i := 0; i < l; i++ {
 		if i > 0 {
 			out.WriteByte(',')
 		}
-		out.Write(entryout.Bytes())
+		entryStart := out.Len()
+		if entryouts != nil {
+			out.Write(entryouts[i].Bytes())
+			PutBuffer(entryouts[i])
+		} else {
+			r.execSelectionSet(ctx, sels, typ.OfType, &pathSegment{path, i}, s, resolver.Index(i), out)
+		}
+
+		// If the list wraps a non-null type and one of the list elements
+		// resolves to null, then the entire list resolves to null.
+		if listOfNonNull && isNull(out.Bytes()[entryStart:]) {
+			nulled = true
+		}
 	}
 	out.WriteByte(']')
+	if nulled {
+		out.Truncate(start)
+		out.WriteString("null")
+		r.pending.null(path)
+	}
 }
 
 func unwrapNonNull(t ast.Type) (ast.Type, bool) {
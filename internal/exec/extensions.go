@@ -0,0 +1,78 @@
+package exec
+
+import (
+	"context"
+	"sync"
+)
+
+const extensionsKey ctxKey = "extensions"
+
+// Extensions collects the response extensions added by resolvers. It is safe for concurrent use.
+type Extensions struct {
+	mu       sync.Mutex
+	values   map[string]interface{}
+	warnings []string
+}
+
+// WithExtensions returns a context in which the response extensions added by resolvers are collected.
+func WithExtensions(ctx context.Context) (context.Context, *Extensions) {
+	e := &Extensions{}
+	return context.WithValue(ctx, extensionsKey, e), e
+}
+
+// AddExtension sets the response extension in the collector of the context. It reports false if the context has no
+// collector.
+func AddExtension(ctx context.Context, key string, value interface{}) bool {
+	e, ok := ctx.Value(extensionsKey).(*Extensions)
+	if !ok {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.values == nil {
+		e.values = make(map[string]interface{})
+	}
+	e.values[key] = value
+	return true
+}
+
+// AddWarning adds the warning to the collector of the context. It reports false if the context has no collector.
+func AddWarning(ctx context.Context, message string) bool {
+	e, ok := ctx.Value(extensionsKey).(*Extensions)
+	if !ok {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.warnings = append(e.warnings, message)
+	return true
+}
+
+// MergeInto adds the collected extensions, which are removed from the collector, to the extensions of a response.
+// The warnings are added in the "warnings" extension. Extensions which are already set are kept. It returns the
+// extensions, which are created if they are nil and there is anything to add.
+func (e *Extensions) MergeInto(extensions map[string]interface{}) map[string]interface{} {
+	e.mu.Lock()
+	values, warnings := e.values, e.warnings
+	e.values, e.warnings = nil, nil
+	e.mu.Unlock()
+
+	if len(values) == 0 && len(warnings) == 0 {
+		return extensions
+	}
+	if extensions == nil {
+		extensions = make(map[string]interface{}, len(values)+1)
+	}
+	for k, v := range values {
+		if _, ok := extensions[k]; !ok {
+			extensions[k] = v
+		}
+	}
+	if len(warnings) != 0 {
+		if w, ok := extensions["warnings"].([]string); ok {
+			warnings = append(w, warnings...)
+		}
+		extensions["warnings"] = warnings
+	}
+	return extensions
+}
@@ -0,0 +1,289 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/graph-gophers/graphql-go/ast"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
+	"github.com/graph-gophers/graphql-go/internal/exec/selected"
+)
+
+// IncrementalPayload is a subsequent payload of an operation executed with ExecuteIncremental. It either holds
+// the data of a deferred fragment or the items of a streamed list.
+type IncrementalPayload struct {
+	Data    json.RawMessage
+	Items   json.RawMessage
+	Path    []interface{}
+	Label   string
+	Errors  []*errors.QueryError
+	HasNext bool
+}
+
+type deferredFragment struct {
+	*selected.DeferredFragment
+	resolver reflect.Value
+}
+
+// task is a deferred fragment or the remainder of a streamed list, which is executed after the payload
+// it is part of has been delivered.
+type task struct {
+	ctx   context.Context
+	label string
+	path  *pathSegment
+
+	// set for deferred fragments
+	sels     []selected.Selection
+	resolver reflect.Value
+
+	// set for streamed lists
+	stream    bool
+	elemType  ast.Type
+	items     []reflect.Value
+	nextIndex int
+}
+
+// pendingTasks collects the tasks of an execution and the paths of the values which were turned into null after
+// their tasks had been added.
+type pendingTasks struct {
+	mu     sync.Mutex
+	tasks  []*task
+	nulled map[*pathSegment]struct{}
+}
+
+func (p *pendingTasks) add(t *task) {
+	p.mu.Lock()
+	p.tasks = append(p.tasks, t)
+	p.mu.Unlock()
+}
+
+// null records that the value at path was replaced with null, e.g. because of a non-null child which resolved to
+// null. It does nothing if p is nil, i.e. if the execution is not incremental.
+func (p *pendingTasks) null(path *pathSegment) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	if p.nulled == nil {
+		p.nulled = make(map[*pathSegment]struct{})
+	}
+	p.nulled[path] = struct{}{}
+	p.mu.Unlock()
+}
+
+// take returns the tasks which are still reachable in the data of the execution. Tasks at or below a value which
+// was nulled because of an error are dropped.
+func (p *pendingTasks) take() []*task {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tasks, nulled := p.tasks, p.nulled
+	p.tasks, p.nulled = nil, nil
+	if len(nulled) == 0 {
+		return tasks
+	}
+
+	var reachable []*task
+	for _, t := range tasks {
+		if !isNulled(t.path, nulled) {
+			reachable = append(reachable, t)
+		}
+	}
+	return reachable
+}
+
+// isNulled reports whether path or one of its parents is nulled. The path segments of the values below a value
+// share its segment, so they are compared by identity.
+func isNulled(path *pathSegment, nulled map[*pathSegment]struct{}) bool {
+	for ; path != nil; path = path.parent {
+		if _, ok := nulled[path]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// streamList registers the list items after the initial count of a streamed field as a task and returns the
+// list of the items which are executed with the field.
+func (r *Request) streamList(ctx context.Context, f *fieldToExec, path *pathSegment, result reflect.Value) reflect.Value {
+	if r.pending == nil {
+		return result
+	}
+	list := result
+	for (list.Kind() == reflect.Ptr || list.Kind() == reflect.Interface) && !list.IsNil() {
+		list = list.Elem()
+	}
+	if list.Kind() != reflect.Slice || list.Len() <= f.field.Stream.InitialCount {
+		return result
+	}
+
+	t, _ := unwrapNonNull(f.field.Type)
+	listType, ok := t.(*ast.List)
+	if !ok {
+		return result
+	}
+	n := f.field.Stream.InitialCount
+	items := make([]reflect.Value, 0, list.Len()-n)
+	for i := n; i < list.Len(); i++ {
+		items = append(items, list.Index(i))
+	}
+	r.pending.add(&task{
+		ctx:       ctx,
+		label:     f.field.Stream.Label,
+		path:      path,
+		sels:      f.sels,
+		stream:    true,
+		elemType:  listType.OfType,
+		items:     items,
+		nextIndex: n,
+	})
+	return list.Slice(0, n)
+}
+
+// ExecuteIncremental executes the operation like Execute, but fragments marked with @defer and list items
+// marked with @stream are delivered with the returned channel after the initial result. The channel is nil
+// if there is nothing to deliver after the initial result, otherwise it is closed after the payload with
+// HasNext set to false.
+func (r *Request) ExecuteIncremental(ctx context.Context, s *resolvable.Schema, op *ast.OperationDefinition) ([]byte, []*errors.QueryError, <-chan *IncrementalPayload) {
+	r.Incremental = true
+	r.pending = &pendingTasks{}
+	data, errs := r.Execute(ctx, s, op)
+	if data == nil {
+		return data, errs, nil
+	}
+	tasks := r.pending.take()
+	if len(tasks) == 0 {
+		return data, errs, nil
+	}
+
+	inc := &incremental{
+		ctx:         ctx,
+		parent:      r,
+		schema:      s,
+		out:         make(chan *IncrementalPayload),
+		outstanding: len(tasks),
+	}
+	for _, t := range tasks {
+		go inc.run(t)
+	}
+	return data, errs, inc.out
+}
+
+// incremental delivers the payloads of the tasks of an operation.
+type incremental struct {
+	ctx    context.Context
+	parent *Request
+	schema *resolvable.Schema
+	out    chan *IncrementalPayload
+
+	mu          sync.Mutex
+	outstanding int
+	sending     sync.WaitGroup // the payloads being sent before the last one
+}
+
+// child returns a request to execute a task, which collects its own errors and tasks.
+func (inc *incremental) child() *Request {
+	r := inc.parent
+	return &Request{
+		Request: selected.Request{
+			Schema:             r.Schema,
+			Doc:                r.Doc,
+			Vars:               r.Vars,
+			AllowIntrospection: r.AllowIntrospection,
+			Incremental:        true,
+		},
+		Limiter:      r.Limiter,
+		Tracer:       r.Tracer,
+		Logger:       r.Logger,
+		PanicHandler: r.PanicHandler,
+		Loaders:      r.Loaders,
+		pending:      &pendingTasks{},
+	}
+}
+
+func (inc *incremental) run(t *task) {
+	if !t.stream {
+		r := inc.child()
+		var out bytes.Buffer
+		func() {
+			ctx, stop := r.startBatching(t.ctx)
+			defer stop()
+			defer r.handleValuePanic(ctx, t.path, &out)
+			r.execSelections(ctx, t.sels, t.path, inc.schema, t.resolver, &out, false)
+		}()
+		if out.Len() == 0 {
+			out.WriteString("null")
+		}
+		inc.emit(&IncrementalPayload{
+			Data:   out.Bytes(),
+			Path:   t.path.toSlice(),
+			Label:  t.label,
+			Errors: r.Errs,
+		}, true, r.pending.take())
+		return
+	}
+
+	_, nonNullItems := t.elemType.(*ast.NonNull)
+	for i, item := range t.items {
+		r := inc.child()
+		path := &pathSegment{t.path, t.nextIndex + i}
+		var out bytes.Buffer
+		func() {
+			ctx, stop := r.startBatching(t.ctx)
+			defer stop()
+			defer r.handleValuePanic(ctx, path, &out)
+			r.execSelectionSet(ctx, t.sels, t.elemType, path, inc.schema, item, &out)
+		}()
+		if out.Len() == 0 {
+			out.WriteString("null")
+		}
+
+		last := i == len(t.items)-1
+		p := &IncrementalPayload{Path: path.toSlice(), Label: t.label, Errors: r.Errs}
+		if nonNullItems && resolvedToNull(&out) {
+			// A null item of a list of non-null items would null the whole list, which has already been
+			// delivered, so the stream ends with the error instead.
+			p.Items = json.RawMessage("null")
+			inc.emit(p, true, nil)
+			return
+		}
+		p.Items = append(append([]byte{'['}, out.Bytes()...), ']')
+		inc.emit(p, last, r.pending.take())
+	}
+}
+
+// emit delivers a payload and starts the tasks found while executing it. done marks the task of the payload
+// as finished, which determines if further payloads are expected.
+func (inc *incremental) emit(p *IncrementalPayload, done bool, tasks []*task) {
+	inc.mu.Lock()
+	inc.outstanding += len(tasks)
+	if done {
+		inc.outstanding--
+	}
+	p.HasNext = inc.outstanding > 0
+	if p.HasNext {
+		inc.sending.Add(1)
+	}
+	inc.mu.Unlock()
+
+	if !p.HasNext {
+		// no task is left to send a payload, but the payloads of other tasks may still be on their way
+		inc.sending.Wait()
+	}
+	select {
+	case inc.out <- p:
+	case <-inc.ctx.Done():
+	}
+	if p.HasNext {
+		inc.sending.Done()
+	} else {
+		close(inc.out)
+	}
+
+	for _, t := range tasks {
+		go inc.run(t)
+	}
+}
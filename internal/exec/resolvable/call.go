@@ -0,0 +1,134 @@
+package resolvable
+
+import (
+	"context"
+	"reflect"
+)
+
+// resolverCall resolves a field with the resolver. It is built once per field by makeResolverCall for the shape of
+// the resolver, so that resolving a field doesn't have to inspect the field again.
+type resolverCall func(ctx context.Context, resolver reflect.Value, args interface{}) (interface{}, error)
+
+// makeResolverCall builds the call of the resolver of the field. Methods of concrete resolver types, which have a
+// receiver, are called with their method expression m, which saves looking up the method on the resolver value,
+// and the arguments are passed in a slice of the exact size instead of being appended.
+func makeResolverCall(f *Field, m reflect.Method, methodHasReceiver bool) resolverCall {
+	if !f.UseMethodResolver() {
+		return makeStructFieldCall(f.FieldIndex)
+	}
+
+	result := valueResult
+	if f.HasError {
+		result = valueErrorResult
+	}
+	hasArgs := f.ArgsPacker != nil
+
+	if f.IsFieldFunc || !methodHasReceiver {
+		// The method or function has to be looked up on the resolver value.
+		lookup := func(resolver reflect.Value) reflect.Value {
+			if f.IsFieldFunc {
+				if resolver.Kind() == reflect.Ptr {
+					resolver = resolver.Elem()
+				}
+				return resolver.FieldByIndex(f.FieldIndex)
+			}
+			return resolver.Method(f.MethodIndex)
+		}
+		switch {
+		case f.HasContext && hasArgs:
+			return func(ctx context.Context, resolver reflect.Value, args interface{}) (interface{}, error) {
+				return result(lookup(resolver).Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(args)}))
+			}
+		case f.HasContext:
+			return func(ctx context.Context, resolver reflect.Value, args interface{}) (interface{}, error) {
+				return result(lookup(resolver).Call([]reflect.Value{reflect.ValueOf(ctx)}))
+			}
+		case hasArgs:
+			return func(ctx context.Context, resolver reflect.Value, args interface{}) (interface{}, error) {
+				return result(lookup(resolver).Call([]reflect.Value{reflect.ValueOf(args)}))
+			}
+		default:
+			return func(ctx context.Context, resolver reflect.Value, args interface{}) (interface{}, error) {
+				return result(lookup(resolver).Call(nil))
+			}
+		}
+	}
+
+	// The method expression takes the receiver as its first argument. The method lookup is kept as a fallback for
+	// resolver values of another type than the one the field was built for.
+	fn, resolverType := m.Func, m.Type.In(0)
+	call := func(resolver reflect.Value, in []reflect.Value) []reflect.Value {
+		if resolver.Type() != resolverType {
+			return resolver.Method(f.MethodIndex).Call(in[1:])
+		}
+		in[0] = resolver
+		return fn.Call(in)
+	}
+	switch {
+	case f.HasContext && hasArgs:
+		return func(ctx context.Context, resolver reflect.Value, args interface{}) (interface{}, error) {
+			return result(call(resolver, []reflect.Value{{}, reflect.ValueOf(ctx), reflect.ValueOf(args)}))
+		}
+	case f.HasContext:
+		return func(ctx context.Context, resolver reflect.Value, args interface{}) (interface{}, error) {
+			return result(call(resolver, []reflect.Value{{}, reflect.ValueOf(ctx)}))
+		}
+	case hasArgs:
+		return func(ctx context.Context, resolver reflect.Value, args interface{}) (interface{}, error) {
+			return result(call(resolver, []reflect.Value{{}, reflect.ValueOf(args)}))
+		}
+	default:
+		return func(ctx context.Context, resolver reflect.Value, args interface{}) (interface{}, error) {
+			return result(call(resolver, []reflect.Value{{}}))
+		}
+	}
+}
+
+func makeStructFieldCall(index []int) resolverCall {
+	if len(index) == 1 {
+		i := index[0]
+		return func(ctx context.Context, resolver reflect.Value, args interface{}) (interface{}, error) {
+			if resolver.Kind() == reflect.Ptr {
+				resolver = resolver.Elem()
+			}
+			return resolver.Field(i).Interface(), nil
+		}
+	}
+	return func(ctx context.Context, resolver reflect.Value, args interface{}) (interface{}, error) {
+		if resolver.Kind() == reflect.Ptr {
+			resolver = resolver.Elem()
+		}
+		return resolver.FieldByIndex(index).Interface(), nil
+	}
+}
+
+func valueResult(out []reflect.Value) (interface{}, error) {
+	return out[0].Interface(), nil
+}
+
+func valueErrorResult(out []reflect.Value) (interface{}, error) {
+	if out[1].IsNil() {
+		return out[0].Interface(), nil
+	}
+	return out[0].Interface(), out[1].Interface().(error)
+}
+
+// makeTypeAssertion builds the call of the method converting a resolver to the resolver of a possible type.
+func makeTypeAssertion(resolverType reflect.Type, methodIndex int) func(reflect.Value) (reflect.Value, bool) {
+	if resolverType.Kind() == reflect.Interface {
+		return func(resolver reflect.Value) (reflect.Value, bool) {
+			out := resolver.Method(methodIndex).Call(nil)
+			return out[0], out[1].Bool()
+		}
+	}
+	fn := resolverType.Method(methodIndex).Func
+	return func(resolver reflect.Value) (reflect.Value, bool) {
+		var out []reflect.Value
+		if resolver.Type() != resolverType {
+			out = resolver.Method(methodIndex).Call(nil)
+		} else {
+			out = fn.Call([]reflect.Value{resolver})
+		}
+		return out[0], out[1].Bool()
+	}
+}
@@ -50,8 +50,14 @@ type Field struct {
 	IsFieldFunc bool
 	ArgsPacker  *packer.StructPacker
 	Visitors    *FieldVisitors
+	Middleware  []directives.FieldMiddleware
 	ValueExec   Resolvable
 	TraceLabel  string
+	// SubscriptionResult is set if the events of the subscription field carry a value or an error, which are
+	// returned by their SubscriptionResult method.
+	SubscriptionResult bool
+
+	call resolverCall
 }
 
 type FieldVisitors struct {
@@ -63,10 +69,12 @@ func (f *Field) UseMethodResolver() bool {
 	return f.MethodIndex != -1 || f.IsFieldFunc
 }
 
-func (f *Field) Resolve(ctx context.Context, resolver reflect.Value, args interface{}) (output interface{}, err error) {
+// Resolve resolves the field with the resolver. The field middleware, which receives info, is called first, in the
+// order it was added, and then the resolver interceptors of the directives. Info is only used by the middleware.
+func (f *Field) Resolve(ctx context.Context, resolver reflect.Value, args interface{}, info *directives.FieldInfo) (output interface{}, err error) {
 	// Short circuit case to avoid wrapping functions
 	v := f.Visitors.Interceptors
-	if len(v) == 0 {
+	if len(v) == 0 && len(f.Middleware) == 0 {
 		return f.resolve(ctx, resolver, args)
 	}
 
@@ -83,6 +91,15 @@ func (f *Field) Resolve(ctx context.Context, resolver reflect.Value, args interf
 		}
 	}
 
+	for i := len(f.Middleware) - 1; i >= 0; i-- {
+		m := f.Middleware[i]
+		innerResolver := wrapResolver
+
+		wrapResolver = func(ctx context.Context, args interface{}) (output interface{}, err error) {
+			return m.ResolveField(ctx, info, args, resolverFunc(innerResolver))
+		}
+	}
+
 	return wrapResolver(ctx, args)
 }
 
@@ -105,45 +122,7 @@ func (f *Field) Validate(ctx context.Context, args interface{}) []error {
 }
 
 func (f *Field) resolve(ctx context.Context, resolver reflect.Value, args interface{}) (output interface{}, err error) {
-	if !f.UseMethodResolver() {
-		res := resolver
-
-		// TODO extract out unwrapping ptr logic to a common place
-		if res.Kind() == reflect.Ptr {
-			res = res.Elem()
-		}
-
-		return res.FieldByIndex(f.FieldIndex).Interface(), nil
-	}
-
-	var in []reflect.Value
-	var callOut []reflect.Value
-
-	if f.HasContext {
-		in = append(in, reflect.ValueOf(ctx))
-	}
-
-	if f.ArgsPacker != nil {
-		in = append(in, reflect.ValueOf(args))
-	}
-
-	if f.IsFieldFunc { // resolver is a struct field of type func
-		res := resolver
-		if res.Kind() == reflect.Pointer {
-			res = resolver.Elem()
-		}
-		callOut = res.FieldByIndex(f.FieldIndex).Call(in)
-	} else {
-		callOut = resolver.Method(f.MethodIndex).Call(in)
-	}
-	result := callOut[0]
-
-	if f.HasError && !callOut[1].IsNil() {
-		resolverErr := callOut[1].Interface().(error)
-		return result.Interface(), resolverErr
-	}
-
-	return result.Interface(), nil
+	return f.call(ctx, resolver, args)
 }
 
 type resolverFunc func(ctx context.Context, args interface{}) (output interface{}, err error)
@@ -155,6 +134,14 @@ func (f resolverFunc) Resolve(ctx context.Context, args interface{}) (output int
 type TypeAssertion struct {
 	MethodIndex int
 	TypeExec    Resolvable
+
+	assert func(reflect.Value) (reflect.Value, bool)
+}
+
+// Assert converts the resolver to the resolver of the type of the assertion. It reports false if the resolver
+// doesn't resolve the type.
+func (a *TypeAssertion) Assert(resolver reflect.Value) (reflect.Value, bool) {
+	return a.assert(resolver)
 }
 
 type List struct {
@@ -167,7 +154,7 @@ func (*Object) isResolvable() {}
 func (*List) isResolvable()   {}
 func (*Scalar) isResolvable() {}
 
-func ApplyResolver(s *ast.Schema, resolver interface{}, dirs []directives.Directive, useFieldResolvers bool) (*Schema, error) {
+func ApplyResolver(s *ast.Schema, resolver interface{}, dirs []directives.Directive, middleware []directives.FieldMiddleware, useFieldResolvers bool) (*Schema, error) {
 	if resolver == nil {
 		return &Schema{Meta: newMeta(s), Schema: *s}, nil
 	}
@@ -183,6 +170,7 @@ func ApplyResolver(s *ast.Schema, resolver interface{}, dirs []directives.Direct
 	}
 
 	b := newBuilder(s, directivePackers, useFieldResolvers)
+	b.middleware = middleware
 
 	var query, mutation, subscription Resolvable
 
@@ -328,6 +316,7 @@ type execBuilder struct {
 	directivePackers  map[string]*packer.StructPacker
 	packerBuilder     *packer.Builder
 	useFieldResolvers bool
+	middleware        []directives.FieldMiddleware
 }
 
 type typePair struct {
@@ -520,6 +509,7 @@ func (b *execBuilder) makeObjectExec(typeName string, fields ast.FieldsDefinitio
 			}
 			a := &TypeAssertion{
 				MethodIndex: methodIndex,
+				assert:      makeTypeAssertion(resolverType, methodIndex),
 			}
 			if err := b.assignExec(&a.TypeExec, impl, resolverType.Method(methodIndex).Type.Out(0)); err != nil {
 				return nil, err
@@ -541,6 +531,20 @@ func (b *execBuilder) makeObjectExec(typeName string, fields ast.FieldsDefinitio
 	}, nil
 }
 
+// subscriptionResultType returns the type of the values of subscription events of type t, which carry a value or an
+// error if t has a method SubscriptionResult() (T, error).
+func subscriptionResultType(t reflect.Type) (reflect.Type, bool) {
+	m, ok := t.MethodByName("SubscriptionResult")
+	receiver := 1
+	if t.Kind() == reflect.Interface {
+		receiver = 0
+	}
+	if !ok || m.Type.NumIn() != receiver || m.Type.NumOut() != 2 || m.Type.Out(1) != errorType {
+		return nil, false
+	}
+	return m.Type.Out(0), true
+}
+
 var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
 var errorType = reflect.TypeOf((*error)(nil)).Elem()
 
@@ -619,9 +623,11 @@ func (b *execBuilder) makeFieldExec(typeName string, f *ast.FieldDefinition, m r
 		HasContext:      hasContext,
 		ArgsPacker:      argsPacker,
 		Visitors:        visitors,
+		Middleware:      b.middleware,
 		HasError:        hasError,
 		TraceLabel:      fmt.Sprintf("GraphQL field: %s.%s", typeName, f.Name),
 	}
+	fe.call = makeResolverCall(fe, m, methodHasReceiver)
 
 	var out reflect.Type
 	if methodIndex != -1 || isFieldFunc {
@@ -629,6 +635,10 @@ func (b *execBuilder) makeFieldExec(typeName string, f *ast.FieldDefinition, m r
 		sub, ok := b.schema.RootOperationTypes["subscription"]
 		if ok && typeName == sub.TypeName() && out.Kind() == reflect.Chan {
 			out = m.Type.Out(0).Elem()
+			if value, ok := subscriptionResultType(out); ok {
+				out = value
+				fe.SubscriptionResult = true
+			}
 		}
 	} else {
 		out = sf.Type
@@ -0,0 +1,86 @@
+package exec
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strconv"
+	"unicode/utf8"
+)
+
+var (
+	stringType  = reflect.TypeOf("")
+	intType     = reflect.TypeOf(int(0))
+	int32Type   = reflect.TypeOf(int32(0))
+	int64Type   = reflect.TypeOf(int64(0))
+	float64Type = reflect.TypeOf(float64(0))
+	boolType    = reflect.TypeOf(false)
+)
+
+// writeBuiltinScalar writes the JSON encoding of a value of a built-in Go type without json.Marshal. The output is
+// the same as the one of json.Marshal. It reports false if the value has to be marshaled, e.g. because of its type
+// or because a string has to be escaped.
+func writeBuiltinScalar(out *bytes.Buffer, v reflect.Value) bool {
+	var buf [32]byte
+	switch v.Type() {
+	case stringType:
+		s := v.String()
+		if !isPlainString(s) {
+			return false
+		}
+		out.WriteByte('"')
+		out.WriteString(s)
+		out.WriteByte('"')
+	case int32Type, intType, int64Type:
+		out.Write(strconv.AppendInt(buf[:0], v.Int(), 10))
+	case boolType:
+		out.Write(strconv.AppendBool(buf[:0], v.Bool()))
+	case float64Type:
+		f := v.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return false // json.Marshal reports the error
+		}
+		out.Write(appendFloat(buf[:0], f))
+	default:
+		return false
+	}
+	return true
+}
+
+// isPlainString reports whether json.Marshal encodes the string without escaping any character.
+func isPlainString(s string) bool {
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c < 0x20 || c == '"' || c == '\\' || c == '<' || c == '>' || c == '&' {
+				return false
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 || r == '\u2028' || r == '\u2029' {
+			return false
+		}
+		i += size
+	}
+	return true
+}
+
+// appendFloat formats the float like json.Marshal, which uses the exponent format for very small and large values.
+func appendFloat(b []byte, f float64) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	b = strconv.AppendFloat(b, f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
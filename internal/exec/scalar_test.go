@@ -0,0 +1,38 @@
+package exec
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestWriteBuiltinScalar(t *testing.T) {
+	type named string
+	for _, v := range []interface{}{
+		"", "hello", "héllo wörld 🎉", "a\"b", "a\\b", "<b>&", "line\nbreak", " ", "\xff",
+		0, -1, int32(math.MaxInt32), int32(math.MinInt32), int64(math.MaxInt64),
+		0.0, 1.5, -2.25, 1e20, 1e21, 1e-6, 1e-7, 123456789.125, math.SmallestNonzeroFloat64, math.MaxFloat64,
+		true, false,
+		named("named"),
+	} {
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if !writeBuiltinScalar(&out, reflect.ValueOf(v)) {
+			continue // marshaled by the executor
+		}
+		if out.String() != string(want) {
+			t.Errorf("got %s for %#v, want %s", out.String(), v, want)
+		}
+	}
+
+	for _, v := range []interface{}{"a\"b", "<", "\xff", math.NaN(), math.Inf(1), named("named")} {
+		if writeBuiltinScalar(new(bytes.Buffer), reflect.ValueOf(v)) {
+			t.Errorf("got %#v written, want it to be marshaled", v)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/graph-gophers/graphql-go/ast"
+	"github.com/graph-gophers/graphql-go/directives"
 	"github.com/graph-gophers/graphql-go/errors"
 	"github.com/graph-gophers/graphql-go/internal/exec/packer"
 	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
@@ -21,6 +22,9 @@ type Request struct {
 	Mu                 sync.Mutex
 	Errs               []*errors.QueryError
 	AllowIntrospection bool
+	// Incremental enables the @defer and @stream directives. Otherwise they are ignored and the
+	// selections are executed with the rest of the operation.
+	Incremental bool
 }
 
 func (r *Request) AddError(err *errors.QueryError) {
@@ -54,16 +58,34 @@ type SchemaField struct {
 	Sels        []Selection
 	Async       bool
 	FixedResult reflect.Value
+	Stream      *Stream
 }
 
-func (f *SchemaField) Resolve(ctx context.Context, resolver reflect.Value) (output interface{}, err error) {
+// Stream holds the arguments of the @stream directive of a list field.
+type Stream struct {
+	Label        string
+	InitialCount int
+}
+
+// DeferredFragment is a fragment marked with the @defer directive.
+type DeferredFragment struct {
+	Label string
+	Sels  []Selection
+}
+
+// Resolve resolves the field with the resolver. The path of the field is only used by the field middleware.
+func (f *SchemaField) Resolve(ctx context.Context, resolver reflect.Value, path []interface{}) (output interface{}, err error) {
 	var args interface{}
 
 	if f.ArgsPacker != nil {
 		args = f.PackedArgs.Interface()
 	}
 
-	return f.Field.Resolve(ctx, resolver, args)
+	var info *directives.FieldInfo
+	if len(f.Middleware) != 0 {
+		info = &directives.FieldInfo{TypeName: f.TypeName, Definition: &f.FieldDefinition, Args: f.Args, Path: path}
+	}
+	return f.Field.Resolve(ctx, resolver, args, info)
 }
 
 type TypeAssertion struct {
@@ -76,9 +98,10 @@ type TypenameField struct {
 	Alias string
 }
 
-func (*SchemaField) isSelection()   {}
-func (*TypeAssertion) isSelection() {}
-func (*TypenameField) isSelection() {}
+func (*SchemaField) isSelection()      {}
+func (*TypeAssertion) isSelection()    {}
+func (*TypenameField) isSelection()    {}
+func (*DeferredFragment) isSelection() {}
 
 func applySelectionSet(r *Request, s *resolvable.Schema, e *resolvable.Object, sels []ast.Selection) (flattenedSels []Selection) {
 	for _, sel := range sels {
@@ -114,7 +137,7 @@ func applySelectionSet(r *Request, s *resolvable.Schema, e *resolvable.Object, s
 					p := packer.ValuePacker{ValueType: reflect.TypeOf("")}
 					v, err := p.Pack(field.Arguments.MustGet("name").Deserialize(r.Vars))
 					if err != nil {
-						r.AddError(errors.Errorf("%s", err))
+						r.addInputError(err, field.Arguments)
 						return nil
 					}
 
@@ -146,7 +169,7 @@ func applySelectionSet(r *Request, s *resolvable.Schema, e *resolvable.Object, s
 					var err error
 					packedArgs, err = fe.ArgsPacker.Pack(args)
 					if err != nil {
-						r.AddError(errors.Errorf("%s", err))
+						r.addInputError(err, field.Arguments)
 						return
 					}
 				}
@@ -159,6 +182,7 @@ func applySelectionSet(r *Request, s *resolvable.Schema, e *resolvable.Object, s
 					PackedArgs: packedArgs,
 					Sels:       fieldSels,
 					Async:      fe.HasContext || fe.ArgsPacker != nil || len(fe.Visitors.Interceptors) > 0 || fe.HasError || HasAsyncSel(fieldSels),
+					Stream:     streamByDirective(r, field.Directives),
 				})
 			}
 
@@ -167,14 +191,24 @@ func applySelectionSet(r *Request, s *resolvable.Schema, e *resolvable.Object, s
 			if skipByDirective(r, frag.Directives) {
 				continue
 			}
-			flattenedSels = append(flattenedSels, applyFragment(r, s, e, &frag.Fragment)...)
+			sels := applyFragment(r, s, e, &frag.Fragment)
+			if label, ok := deferByDirective(r, frag.Directives); ok {
+				flattenedSels = append(flattenedSels, &DeferredFragment{Label: label, Sels: sels})
+				continue
+			}
+			flattenedSels = append(flattenedSels, sels...)
 
 		case *ast.FragmentSpread:
 			spread := sel
 			if skipByDirective(r, spread.Directives) {
 				continue
 			}
-			flattenedSels = append(flattenedSels, applyFragment(r, s, e, &r.Doc.Fragments.Get(spread.Name.Name).Fragment)...)
+			sels := applyFragment(r, s, e, &r.Doc.Fragments.Get(spread.Name.Name).Fragment)
+			if label, ok := deferByDirective(r, spread.Directives); ok {
+				flattenedSels = append(flattenedSels, &DeferredFragment{Label: label, Sels: sels})
+				continue
+			}
+			flattenedSels = append(flattenedSels, sels...)
 
 		default:
 			panic("invalid type")
@@ -267,12 +301,48 @@ func applyField(r *Request, s *resolvable.Schema, e resolvable.Resolvable, sels
 	}
 }
 
+// addInputError adds the error of an argument value which can not be coerced to its type. If the arguments refer to
+// variables, the error is caused by their values and reported for the VariablesOfCorrectType rule, otherwise it is
+// caused by a literal and reported for the ArgumentsOfCorrectType rule.
+func (r *Request) addInputError(err error, args ast.ArgumentList) {
+	qErr := errors.Errorf("%s", err)
+	qErr.Rule = "ArgumentsOfCorrectType"
+	for _, arg := range args {
+		if usesVariable(arg.Value) {
+			qErr.Rule = "VariablesOfCorrectType"
+			break
+		}
+	}
+	r.AddError(qErr)
+}
+
+// usesVariable reports whether the value is or contains a variable.
+func usesVariable(v ast.Value) bool {
+	switch v := v.(type) {
+	case *ast.Variable:
+		return true
+	case *ast.ListValue:
+		for _, entry := range v.Values {
+			if usesVariable(entry) {
+				return true
+			}
+		}
+	case *ast.ObjectValue:
+		for _, f := range v.Fields {
+			if usesVariable(f.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func skipByDirective(r *Request, directives ast.DirectiveList) bool {
 	if d := directives.Get("skip"); d != nil {
 		p := packer.ValuePacker{ValueType: reflect.TypeOf(false)}
 		v, err := p.Pack(d.Arguments.MustGet("if").Deserialize(r.Vars))
 		if err != nil {
-			r.AddError(errors.Errorf("%s", err))
+			r.addInputError(err, d.Arguments)
 		}
 		if err == nil && v.Bool() {
 			return true
@@ -283,7 +353,7 @@ func skipByDirective(r *Request, directives ast.DirectiveList) bool {
 		p := packer.ValuePacker{ValueType: reflect.TypeOf(false)}
 		v, err := p.Pack(d.Arguments.MustGet("if").Deserialize(r.Vars))
 		if err != nil {
-			r.AddError(errors.Errorf("%s", err))
+			r.addInputError(err, d.Arguments)
 		}
 		if err == nil && !v.Bool() {
 			return true
@@ -306,9 +376,68 @@ func HasAsyncSel(sels []Selection) bool {
 			}
 		case *TypenameField:
 			// sync
+		case *DeferredFragment:
+			// executed after the selections it is part of
 		default:
 			panic("unreachable")
 		}
 	}
 	return false
 }
+
+// deferByDirective returns the label of the @defer directive, if the fragment is deferred.
+func deferByDirective(r *Request, directives ast.DirectiveList) (string, bool) {
+	if !r.Incremental {
+		return "", false
+	}
+	d := directives.Get("defer")
+	if d == nil || !incrementalEnabled(r, d) {
+		return "", false
+	}
+	return directiveLabel(r, d), true
+}
+
+// streamByDirective returns the arguments of the @stream directive, if the list field is streamed.
+func streamByDirective(r *Request, directives ast.DirectiveList) *Stream {
+	if !r.Incremental {
+		return nil
+	}
+	d := directives.Get("stream")
+	if d == nil || !incrementalEnabled(r, d) {
+		return nil
+	}
+	stream := &Stream{Label: directiveLabel(r, d)}
+	if v, ok := d.Arguments.Get("initialCount"); ok {
+		p := packer.ValuePacker{ValueType: reflect.TypeOf(int32(0))}
+		count, err := p.Pack(v.Deserialize(r.Vars))
+		if err != nil {
+			r.addInputError(err, d.Arguments)
+		} else if count.Int() > 0 {
+			stream.InitialCount = int(count.Int())
+		}
+	}
+	return stream
+}
+
+func incrementalEnabled(r *Request, d *ast.Directive) bool {
+	v, ok := d.Arguments.Get("if")
+	if !ok {
+		return true
+	}
+	p := packer.ValuePacker{ValueType: reflect.TypeOf(false)}
+	enabled, err := p.Pack(v.Deserialize(r.Vars))
+	if err != nil {
+		r.addInputError(err, d.Arguments)
+		return false
+	}
+	return enabled.Bool()
+}
+
+func directiveLabel(r *Request, d *ast.Directive) string {
+	v, ok := d.Arguments.Get("label")
+	if !ok {
+		return ""
+	}
+	label, _ := v.Deserialize(r.Vars).(string)
+	return label
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"reflect"
 	"time"
@@ -12,14 +13,19 @@ import (
 	"github.com/graph-gophers/graphql-go/errors"
 	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
 	"github.com/graph-gophers/graphql-go/internal/exec/selected"
+	"github.com/graph-gophers/graphql-go/trace/tracer"
 )
 
 type Response struct {
-	Data   json.RawMessage
-	Errors []*errors.QueryError
+	Data       json.RawMessage
+	Errors     []*errors.QueryError
+	Extensions map[string]interface{}
 }
 
-func (r *Request) Subscribe(ctx context.Context, s *resolvable.Schema, op *ast.OperationDefinition) <-chan *Response {
+// Subscribe executes the subscription and returns the context of its trace with the channel of its responses.
+func (r *Request) Subscribe(ctx context.Context, s *resolvable.Schema, op *ast.OperationDefinition) (context.Context, <-chan *Response) {
+	ctx, finish := r.traceSubscription(ctx, op)
+
 	var result reflect.Value
 	var f *fieldToExec
 	var err *errors.QueryError
@@ -28,7 +34,7 @@ func (r *Request) Subscribe(ctx context.Context, s *resolvable.Schema, op *ast.O
 
 		sels := selected.ApplyOperation(&r.Request, s, op)
 		var fields []*fieldToExec
-		collectFieldsToResolve(sels, s, s.SubscriptionResolver, &fields, make(map[string]*fieldToExec))
+		collectFieldsToResolve(sels, s, s.SubscriptionResolver, &fields, make(map[string]*fieldToExec), nil)
 
 		// TODO: move this check into validation.Validate
 		if len(fields) != 1 {
@@ -62,35 +68,41 @@ func (r *Request) Subscribe(ctx context.Context, s *resolvable.Schema, op *ast.O
 
 	// Handles the case where the locally executed func above panicked
 	if len(r.Request.Errs) > 0 {
-		return sendAndReturnClosed(&Response{Errors: r.Request.Errs})
+		finish(tracer.SubscriptionFailed, r.Request.Errs[0])
+		return ctx, sendAndReturnClosed(&Response{Errors: r.Request.Errs})
 	}
 
 	if f == nil {
-		return sendAndReturnClosed(&Response{Errors: []*errors.QueryError{err}})
+		finish(tracer.SubscriptionFailed, err)
+		return ctx, sendAndReturnClosed(&Response{Errors: []*errors.QueryError{err}})
 	}
 
 	if err != nil {
+		finish(tracer.SubscriptionFailed, err)
 		if _, nonNullChild := f.field.Type.(*ast.NonNull); nonNullChild {
-			return sendAndReturnClosed(&Response{Errors: []*errors.QueryError{err}})
+			return ctx, sendAndReturnClosed(&Response{Errors: []*errors.QueryError{err}})
 		}
-		return sendAndReturnClosed(&Response{Data: []byte(fmt.Sprintf(`{"%s":null}`, f.field.Alias)), Errors: []*errors.QueryError{err}})
+		return ctx, sendAndReturnClosed(&Response{Data: []byte(fmt.Sprintf(`{"%s":null}`, f.field.Alias)), Errors: []*errors.QueryError{err}})
 	}
 
 	if ctxErr := ctx.Err(); ctxErr != nil {
-		return sendAndReturnClosed(&Response{Errors: []*errors.QueryError{errors.Errorf("%s", ctxErr)}})
+		finish(tracer.SubscriptionCancelled, nil)
+		return ctx, sendAndReturnClosed(&Response{Errors: []*errors.QueryError{errors.Errorf("%s", ctxErr)}})
 	}
 
 	c := make(chan *Response)
 	// TODO: handle resolver nil channel better?
 	if result.IsZero() {
+		finish(tracer.SubscriptionCompleted, nil)
 		close(c)
-		return c
+		return ctx, c
 	}
 
 	go func() {
+		defer close(c)
 		for {
 			// Check subscription context
-			chosen, resp, ok := reflect.Select([]reflect.SelectCase{
+			chosen, event, ok := reflect.Select([]reflect.SelectCase{
 				{
 					Dir:  reflect.SelectRecv,
 					Chan: reflect.ValueOf(ctx.Done()),
@@ -103,72 +115,130 @@ func (r *Request) Subscribe(ctx context.Context, s *resolvable.Schema, op *ast.O
 			switch chosen {
 			// subscription context done
 			case 0:
-				close(c)
+				finish(tracer.SubscriptionCancelled, nil)
 				return
 			// upstream received
 			case 1:
 				// upstream closed
 				if !ok {
-					close(c)
+					finish(tracer.SubscriptionCompleted, nil)
 					return
 				}
-
-				subR := &Request{
-					Request: selected.Request{
-						Doc:    r.Request.Doc,
-						Vars:   r.Request.Vars,
-						Schema: r.Request.Schema,
-					},
-					Limiter: r.Limiter,
-					Tracer:  r.Tracer,
-					Logger:  r.Logger,
+				if reason, endErr := r.handleEvent(ctx, s, f, event, c); reason != "" {
+					finish(reason, endErr)
+					return
 				}
-				var out bytes.Buffer
-				func() {
-					timeout := r.SubscribeResolverTimeout
-					if timeout == 0 {
-						timeout = time.Second
-					}
-
-					subCtx, cancel := context.WithTimeout(ctx, timeout)
-					defer cancel()
-
-					// resolve response
-					func() {
-						defer subR.handlePanic(subCtx)
-
-						var buf bytes.Buffer
-						subR.execSelectionSet(subCtx, f.sels, f.field.Type, &pathSegment{nil, f.field.Alias}, s, resp, &buf)
-
-						propagateChildError := false
-						if _, nonNullChild := f.field.Type.(*ast.NonNull); nonNullChild && resolvedToNull(&buf) {
-							propagateChildError = true
-						}
-
-						if !propagateChildError {
-							out.WriteString(fmt.Sprintf(`{"%s":`, f.field.Alias))
-							out.Write(buf.Bytes())
-							out.WriteString(`}`)
-						}
-					}()
-
-					if err := subCtx.Err(); err != nil {
-						c <- &Response{Errors: []*errors.QueryError{errors.Errorf("%s", err)}}
-						return
-					}
-
-					// Send response within timeout
-					// TODO: maybe block until sent?
-					select {
-					case <-subCtx.Done():
-					case c <- &Response{Data: out.Bytes(), Errors: subR.Errs}:
-					}
-				}()
 			}
 		}
 	}()
 
-	return c
+	return ctx, c
+}
+
+// handleEvent resolves a subscription event and sends its response to c. If the subscription ends, it returns the
+// reason and the error which ended it, if any.
+func (r *Request) handleEvent(ctx context.Context, s *resolvable.Schema, f *fieldToExec, event reflect.Value, c chan<- *Response) (tracer.SubscriptionCloseReason, *errors.QueryError) {
+	eventCtx, finish := r.traceSubscriptionEvent(ctx, f)
+	var errs []*errors.QueryError
+	defer func() {
+		finish(errs)
+	}()
+
+	// send reports false if the subscription was cancelled before the response was received
+	send := func(resp *Response) bool {
+		errs = resp.Errors
+		select {
+		case <-ctx.Done():
+			return false
+		case c <- resp:
+			return true
+		}
+	}
+
+	if f.field.SubscriptionResult {
+		value, eventErr, end := r.subscriptionResult(eventCtx, event)
+		if eventErr != nil && !send(eventErrorResponse(f, eventErr)) {
+			return tracer.SubscriptionCancelled, nil
+		}
+		switch {
+		case end && eventErr != nil:
+			return tracer.SubscriptionFailed, eventErr
+		case end:
+			return tracer.SubscriptionCompleted, nil
+		case eventErr != nil:
+			return "", nil
+		}
+		event = value
+	}
+
+	subR := &Request{
+		Request: selected.Request{
+			Doc:    r.Request.Doc,
+			Vars:   r.Request.Vars,
+			Schema: r.Request.Schema,
+		},
+		Limiter:      r.Limiter,
+		Tracer:       r.Tracer,
+		Logger:       r.Logger,
+		PanicHandler: r.PanicHandler,
+		Loaders:      r.Loaders,
+	}
+	timeout := r.SubscribeResolverTimeout
+	if timeout == 0 {
+		timeout = time.Second
+	}
+
+	subCtx, cancel := context.WithTimeout(eventCtx, timeout)
+	defer cancel()
+	subCtx, extensions := WithExtensions(subCtx)
+
+	// resolve response
+	var out bytes.Buffer
+	func() {
+		subCtx, stop := subR.startBatching(subCtx)
+		defer stop()
+		defer subR.handlePanic(subCtx)
+
+		var buf bytes.Buffer
+		subR.execSelectionSet(subCtx, f.sels, f.field.Type, &pathSegment{nil, f.field.Alias}, s, event, &buf)
+
+		propagateChildError := false
+		if _, nonNullChild := f.field.Type.(*ast.NonNull); nonNullChild && resolvedToNull(&buf) {
+			propagateChildError = true
+		}
+
+		if !propagateChildError {
+			out.WriteString(fmt.Sprintf(`{"%s":`, f.field.Alias))
+			out.Write(buf.Bytes())
+			out.WriteString(`}`)
+		}
+	}()
+
+	var resp *Response
+	if err := subCtx.Err(); err != nil {
+		resp = &Response{Errors: []*errors.QueryError{errors.Errorf("%s", err)}}
+	} else {
+		resp = &Response{Data: out.Bytes(), Errors: subR.Errs, Extensions: extensions.MergeInto(nil)}
+	}
+	// the delivery policy is applied by the consumer of c, so the timeout only limits the resolution
+	if !send(resp) {
+		return tracer.SubscriptionCancelled, nil
+	}
+	return "", nil
+}
+
+func (r *Request) traceSubscription(ctx context.Context, op *ast.OperationDefinition) (context.Context, tracer.SubscriptionFinishFunc) {
+	if t, ok := r.Tracer.(tracer.SubscriptionTracer); ok {
+		return t.TraceSubscription(ctx, op.Name.Name, r.Vars)
+	}
+	return ctx, func(tracer.SubscriptionCloseReason, *errors.QueryError) {}
+}
+
+func (r *Request) traceSubscriptionEvent(ctx context.Context, f *fieldToExec) (context.Context, tracer.SubscriptionEventFinishFunc) {
+	if t, ok := r.Tracer.(tracer.SubscriptionTracer); ok {
+		return t.TraceSubscriptionEvent(ctx, f.field.Name)
+	}
+	return ctx, func([]*errors.QueryError) {}
 }
 
 func sendAndReturnClosed(resp *Response) chan *Response {
@@ -177,3 +247,62 @@ func sendAndReturnClosed(resp *Response) chan *Response {
 	close(c)
 	return c
 }
+
+// endOfStream is implemented by the errors which end a subscription, see graphql.EndSubscription. It may be wrapped
+// by the error of the event, which is sent as the final error of the subscription if the end has a cause.
+type endOfStream interface {
+	error
+	EndsSubscription() bool
+	Unwrap() error
+}
+
+// subscriptionResult returns the value or the error carried by a subscription event and whether the subscription
+// ends. Panics are reported as the error of the event.
+func (r *Request) subscriptionResult(ctx context.Context, event reflect.Value) (value reflect.Value, err *errors.QueryError, end bool) {
+	defer func() {
+		if panicValue := recover(); panicValue != nil {
+			r.Logger.LogPanic(ctx, panicValue)
+			err = r.PanicHandler.MakePanicError(ctx, panicValue)
+		}
+	}()
+
+	if event.Kind() == reflect.Ptr && event.IsNil() {
+		return event, nil, false
+	}
+	out := event.MethodByName("SubscriptionResult").Call(nil)
+	if out[1].IsNil() {
+		return out[0], nil, false
+	}
+	resolverErr := out[1].Interface().(error)
+	var e endOfStream
+	if stderrors.As(resolverErr, &e) && e.EndsSubscription() {
+		end = true
+		cause := e.Unwrap()
+		if cause == nil {
+			return value, nil, true
+		}
+		// an error which wraps the end of the subscription is reported with its own message
+		if resolverErr == error(e) {
+			resolverErr = cause
+		}
+	}
+	err = errors.Errorf("%s", resolverErr)
+	err.ResolverError = resolverErr
+	if ex, ok := resolverErr.(extensionser); ok {
+		err.Extensions = ex.Extensions()
+	} else if qErr, ok := resolverErr.(*errors.QueryError); ok && qErr.Code() != "" {
+		// the errors of the coded constructors are reported as they are
+		err.Message = qErr.Message
+		err.Extensions = qErr.Extensions
+	}
+	return value, err, end
+}
+
+// eventErrorResponse returns the response to a subscription event which carries an error.
+func eventErrorResponse(f *fieldToExec, err *errors.QueryError) *Response {
+	err.Path = []interface{}{f.field.Alias}
+	if _, nonNull := f.field.Type.(*ast.NonNull); nonNull {
+		return &Response{Errors: []*errors.QueryError{err}}
+	}
+	return &Response{Data: []byte(fmt.Sprintf(`{"%s":null}`, f.field.Alias)), Errors: []*errors.QueryError{err}}
+}
@@ -25,6 +25,8 @@ func collectFieldsToValidate(sels []selected.Selection, s *resolvable.Schema, fi
 			// Ignore __typename, which has no directives
 		case *selected.TypeAssertion:
 			collectFieldsToValidate(sel.Sels, s, fields, fieldByAlias)
+		case *selected.DeferredFragment:
+			collectFieldsToValidate(sel.Sels, s, fields, fieldByAlias)
 		default:
 			panic(fmt.Sprintf("unexpected selection type %T", sel))
 		}
@@ -0,0 +1,35 @@
+package lru_test
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/internal/lru"
+)
+
+func TestCache(t *testing.T) {
+	c := lru.New(2)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("got %v, %v for a, want 1, true", v, ok)
+	}
+
+	// b is the least recently used entry now.
+	c.Add("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if v, ok := c.Get(key); !ok || v != want {
+			t.Errorf("got %v, %v for %s, want %d, true", v, ok, key, want)
+		}
+	}
+
+	c.Add("a", 4)
+	if v, _ := c.Get("a"); v != 4 {
+		t.Errorf("got %v for a, want 4", v)
+	}
+	if n := c.Len(); n != 2 {
+		t.Errorf("got length %d, want 2", n)
+	}
+}
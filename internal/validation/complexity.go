@@ -0,0 +1,282 @@
+package validation
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go/ast"
+)
+
+// DefaultFieldCost is the weight of a field without a cost function result or a @cost directive.
+const DefaultFieldCost = 1
+
+// maxCost caps the cost, so that huge multipliers can not overflow.
+const maxCost = math.MaxInt32
+
+// CostFunc returns the cost of a field of the type, including the cost of its selections which is passed as
+// childCost. The arguments hold the values of the arguments of the field. If ok is false, the cost is computed from
+// the @cost directive of the field or from the defaults.
+type CostFunc func(typeName, fieldName string, args map[string]interface{}, childCost int) (cost int, ok bool)
+
+type complexityContext struct {
+	schema    *ast.Schema
+	doc       *ast.ExecutableDefinition
+	vars      map[string]interface{}
+	fn        CostFunc
+	fragments map[fragmentKey]int
+}
+
+type fragmentKey struct {
+	name string
+	typ  string
+}
+
+// Complexity returns the static cost of the operation. The operation must be valid.
+//
+// The cost of a field is (weight + cost of the selections) * multiplier. The weight is the weight argument of the
+// @cost directive of the field definition or DefaultFieldCost. The multiplier is the sum of the values of the
+// arguments named by the multipliers argument of the directive, where list values count with their length, or 1 if
+// none of them is given. Fields returning lists without a @cost directive use the first and last arguments as
+// multipliers. Introspection fields and skipped selections are free, and of the fragments on different object
+// types of an abstract type only the most expensive one counts.
+func Complexity(s *ast.Schema, doc *ast.ExecutableDefinition, op *ast.OperationDefinition, variables map[string]interface{}, fn CostFunc) int {
+	t, ok := s.RootOperationTypes[strings.ToLower(string(op.Type))]
+	if !ok {
+		return 0
+	}
+	vars := make(map[string]interface{}, len(op.Vars))
+	for _, v := range op.Vars {
+		if v.Default != nil {
+			vars[v.Name.Name] = v.Default.Deserialize(nil)
+		}
+	}
+	for name, v := range variables {
+		vars[name] = v
+	}
+	c := &complexityContext{
+		schema:    s,
+		doc:       doc,
+		vars:      vars,
+		fn:        fn,
+		fragments: make(map[fragmentKey]int),
+	}
+	return c.selectionsCost(op.Selections, t)
+}
+
+func (c *complexityContext) selectionsCost(sels []ast.Selection, t ast.NamedType) int {
+	cost := 0
+	branches := make(map[string]int)
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if c.skipped(sel.Directives) {
+				continue
+			}
+			cost = addCost(cost, c.fieldCost(sel, t))
+
+		case *ast.InlineFragment:
+			if c.skipped(sel.Directives) {
+				continue
+			}
+			fragType := t
+			if sel.On.Name != "" {
+				fragType = c.schema.Types[sel.On.Name]
+			}
+			if fragType == nil {
+				continue
+			}
+			c.addFragmentCost(&cost, branches, t, fragType, c.selectionsCost(sel.Selections, fragType))
+
+		case *ast.FragmentSpread:
+			if c.skipped(sel.Directives) {
+				continue
+			}
+			frag := c.doc.Fragments.Get(sel.Name.Name)
+			if frag == nil || c.schema.Types[frag.On.Name] == nil {
+				continue
+			}
+			fragType := c.schema.Types[frag.On.Name]
+			key := fragmentKey{frag.Name.Name, fragType.TypeName()}
+			fragCost, ok := c.fragments[key]
+			if !ok {
+				fragCost = c.selectionsCost(frag.Selections, fragType)
+				c.fragments[key] = fragCost
+			}
+			c.addFragmentCost(&cost, branches, t, fragType, fragCost)
+		}
+	}
+
+	branchCost := 0
+	for _, bc := range branches {
+		if bc > branchCost {
+			branchCost = bc
+		}
+	}
+	return addCost(cost, branchCost)
+}
+
+// addFragmentCost adds the cost of a fragment to the cost of the selections. Fragments on an object type other
+// than the type of the selections only apply to some of the results, so they are collected as branches of which
+// the most expensive one counts.
+func (c *complexityContext) addFragmentCost(cost *int, branches map[string]int, t, fragType ast.NamedType, fragCost int) {
+	if _, ok := fragType.(*ast.ObjectTypeDefinition); ok && fragType != t {
+		branches[fragType.TypeName()] = addCost(branches[fragType.TypeName()], fragCost)
+		return
+	}
+	*cost = addCost(*cost, fragCost)
+}
+
+func (c *complexityContext) fieldCost(f *ast.Field, t ast.NamedType) int {
+	if strings.HasPrefix(f.Name.Name, "__") {
+		return 0
+	}
+	fd := fields(t).Get(f.Name.Name)
+	if fd == nil {
+		return 0
+	}
+
+	childCost := 0
+	if len(f.SelectionSet) != 0 {
+		childCost = c.selectionsCost(f.SelectionSet, unwrapType(fd.Type))
+	}
+
+	var args map[string]interface{}
+	if c.fn != nil {
+		args = c.arguments(f, fd)
+		if cost, ok := c.fn(t.TypeName(), fd.Name, args, childCost); ok {
+			return clampCost(cost)
+		}
+	}
+
+	weight := DefaultFieldCost
+	var multipliers []string
+	if d := fd.Directives.Get("cost"); d != nil {
+		if v, ok := d.Arguments.Get("weight"); ok && v != nil {
+			if w, ok := intValue(v.Deserialize(nil)); ok {
+				weight = clampCost(w)
+			}
+		}
+		if v, ok := d.Arguments.Get("multipliers"); ok && v != nil {
+			if l, ok := v.Deserialize(nil).([]interface{}); ok {
+				for _, m := range l {
+					if name, ok := m.(string); ok {
+						multipliers = append(multipliers, name)
+					}
+				}
+			}
+		}
+	} else if _, ok := unwrapNonNull(fd.Type).(*ast.List); ok {
+		multipliers = []string{"first", "last"}
+	}
+
+	cost := addCost(weight, childCost)
+	if len(multipliers) == 0 {
+		return cost
+	}
+	if args == nil {
+		args = c.arguments(f, fd)
+	}
+	multiplier, found := 0, false
+	for _, name := range multipliers {
+		n, ok := listSize(args[name])
+		if !ok {
+			continue
+		}
+		multiplier = addCost(multiplier, n)
+		found = true
+	}
+	if !found {
+		return cost
+	}
+	return mulCost(cost, multiplier)
+}
+
+// arguments returns the values of the arguments of the field, including the defaults of the missing ones.
+func (c *complexityContext) arguments(f *ast.Field, fd *ast.FieldDefinition) map[string]interface{} {
+	args := make(map[string]interface{}, len(fd.Arguments))
+	for _, a := range fd.Arguments {
+		if v, ok := f.Arguments.Get(a.Name.Name); ok {
+			args[a.Name.Name] = v.Deserialize(c.vars)
+		} else if a.Default != nil {
+			args[a.Name.Name] = a.Default.Deserialize(nil)
+		}
+	}
+	return args
+}
+
+func (c *complexityContext) skipped(directives ast.DirectiveList) bool {
+	if d := directives.Get("skip"); d != nil {
+		if v, ok := d.Arguments.Get("if"); ok {
+			if b, ok := v.Deserialize(c.vars).(bool); ok && b {
+				return true
+			}
+		}
+	}
+	if d := directives.Get("include"); d != nil {
+		if v, ok := d.Arguments.Get("if"); ok {
+			if b, ok := v.Deserialize(c.vars).(bool); ok && !b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// listSize returns the size of a multiplier argument, which is either a number or a list.
+func listSize(v interface{}) (int, bool) {
+	if l, ok := v.([]interface{}); ok {
+		return len(l), true
+	}
+	n, ok := intValue(v)
+	if !ok {
+		return 0, false
+	}
+	return clampCost(n), true
+}
+
+func intValue(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		return clampCost64(v), true
+	case float64:
+		return clampCost64(int64(math.Min(math.Max(v, -maxCost), maxCost))), true
+	case json.Number:
+		n, err := v.Int64()
+		return clampCost64(n), err == nil
+	default:
+		return 0, false
+	}
+}
+
+func clampCost(n int) int {
+	return clampCost64(int64(n))
+}
+
+func clampCost64(n int64) int {
+	if n < 0 {
+		return 0
+	}
+	if n > maxCost {
+		return maxCost
+	}
+	return int(n)
+}
+
+func addCost(a, b int) int {
+	return clampCost64(int64(a) + int64(b))
+}
+
+func mulCost(a, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	if int64(a) > maxCost/int64(b) {
+		return maxCost
+	}
+	return int(int64(a) * int64(b))
+}
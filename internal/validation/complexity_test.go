@@ -0,0 +1,153 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/internal/query"
+	"github.com/graph-gophers/graphql-go/internal/schema"
+)
+
+const costSchema = `
+	directive @cost(weight: Int! = 1, multipliers: [String!]) on FIELD_DEFINITION
+
+	schema {
+		query: Query
+	}
+
+	type Query {
+		users(first: Int, last: Int): [User!]!
+		user(id: ID!): User
+		search(ids: [ID!]!): [Node!]! @cost(weight: 3, multipliers: ["ids"])
+		stats: Int! @cost(weight: 10)
+		node: Node
+	}
+
+	interface Node {
+		id: ID!
+	}
+
+	type User implements Node {
+		id: ID!
+		name: String!
+		friends(first: Int = 10): [User!]!
+	}
+
+	type Post implements Node {
+		id: ID!
+		title: String!
+		body: String! @cost(weight: 5)
+	}
+`
+
+func TestComplexity(t *testing.T) {
+	s, err := schema.ParseSchema(costSchema, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		query string
+		vars  map[string]interface{}
+		fn    CostFunc
+		want  int
+	}{
+		{
+			name:  "default cost",
+			query: `{ user(id: "1") { id name } }`,
+			want:  3,
+		},
+		{
+			name:  "list without multiplier",
+			query: `{ users { id } }`,
+			want:  2,
+		},
+		{
+			name:  "first",
+			query: `{ users(first: 100) { id name } }`,
+			want:  300,
+		},
+		{
+			name:  "first and last",
+			query: `{ users(first: 2, last: 3) { id } }`,
+			want:  10,
+		},
+		{
+			name:  "nested multipliers",
+			query: `{ users(first: 1000) { friends(first: 1000) { id } } }`,
+			want:  2001000,
+		},
+		{
+			name:  "argument default",
+			query: `{ users(first: 2) { friends { id } } }`,
+			want:  42,
+		},
+		{
+			name:  "variables",
+			query: `query($n: Int, $m: Int = 4) { users(first: $n) { friends(first: $m) { id } } }`,
+			vars:  map[string]interface{}{"n": float64(5)},
+			want:  45,
+		},
+		{
+			name:  "directive weight",
+			query: `{ stats }`,
+			want:  10,
+		},
+		{
+			name:  "directive list multiplier",
+			query: `{ search(ids: ["1", "2", "3"]) { id } }`,
+			want:  12,
+		},
+		{
+			name:  "most expensive branch",
+			query: `{ node { id ... on User { name } ... on Post { title body } } }`,
+			want:  8,
+		},
+		{
+			name:  "fragment on interface",
+			query: `{ node { ...node } } fragment node on Node { id }`,
+			want:  2,
+		},
+		{
+			name:  "skipped",
+			query: `query($skip: Boolean!) { stats @skip(if: $skip) user(id: "1") @include(if: false) { id } }`,
+			vars:  map[string]interface{}{"skip": true},
+			want:  0,
+		},
+		{
+			name:  "introspection is free",
+			query: `{ __typename __schema { types { name } } }`,
+			want:  0,
+		},
+		{
+			name:  "cost function",
+			query: `{ users(first: 10) { id name } stats }`,
+			fn: func(typeName, fieldName string, args map[string]interface{}, childCost int) (int, bool) {
+				if typeName == "Query" && fieldName == "users" {
+					return int(args["first"].(int32)) + childCost, true
+				}
+				return 0, false
+			},
+			want: 22,
+		},
+		{
+			name:  "overflow",
+			query: `{ users(first: 2000000000) { friends(first: 2000000000) { id } } }`,
+			want:  maxCost,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			doc, qErr := query.Parse(tc.query)
+			if qErr != nil {
+				t.Fatal(qErr)
+			}
+			if errs := Validate(s, doc, tc.vars, 0); len(errs) != 0 {
+				t.Fatal(errs)
+			}
+			if got := Complexity(s, doc, doc.Operations[0], tc.vars, tc.fn); got != tc.want {
+				t.Errorf("got cost %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package validation
+
+import (
+	"strings"
+
+	"github.com/graph-gophers/graphql-go/ast"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/query"
+)
+
+// validateDeferStream validates the usage of the @defer and @stream directives of incremental delivery. Their
+// general usage, e.g. the locations and arguments, is checked with the other directives, if the schema declares them.
+func validateDeferStream(c *opContext, op *ast.OperationDefinition) {
+	if c.schema.Directives["defer"] == nil && c.schema.Directives["stream"] == nil {
+		return
+	}
+	v := &deferStreamValidator{
+		c:       c,
+		labels:  make(map[string]*ast.Directive),
+		visited: make(map[*ast.FragmentDefinition]struct{}),
+	}
+	v.validateSelections(op.Selections, op.Type, true)
+}
+
+type deferStreamValidator struct {
+	c       *opContext
+	labels  map[string]*ast.Directive
+	visited map[*ast.FragmentDefinition]struct{}
+}
+
+func (v *deferStreamValidator) validateSelections(sels []ast.Selection, opType ast.OperationType, root bool) {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if d := sel.Directives.Get("stream"); d != nil {
+				v.validateDirective(d, opType, root)
+				if fi, ok := v.c.fieldMap[sel]; ok && fi.sf != nil {
+					if _, ok := unwrapNonNull(fi.sf.Type).(*ast.List); !ok {
+						v.c.addErr(d.Name.Loc, "StreamDirectiveOnListFieldRule", "Stream directive cannot be used on non-list field %q on type %q.", sel.Name.Name, fi.parent)
+					}
+				}
+			}
+			v.validateSelections(sel.SelectionSet, opType, false)
+
+		case *ast.InlineFragment:
+			if d := sel.Directives.Get("defer"); d != nil {
+				v.validateDirective(d, opType, root)
+			}
+			v.validateSelections(sel.Selections, opType, root)
+
+		case *ast.FragmentSpread:
+			if d := sel.Directives.Get("defer"); d != nil {
+				v.validateDirective(d, opType, root)
+			}
+			frag := v.c.doc.Fragments.Get(sel.Name.Name)
+			if frag == nil {
+				continue
+			}
+			if _, ok := v.visited[frag]; ok {
+				continue
+			}
+			v.visited[frag] = struct{}{}
+			v.validateSelections(frag.Selections, opType, root)
+		}
+	}
+}
+
+func (v *deferStreamValidator) validateDirective(d *ast.Directive, opType ast.OperationType, root bool) {
+	if root && opType != query.Query {
+		v.c.addErr(d.Name.Loc, "DeferStreamDirectiveOnRootFieldRule", "Directive %q cannot be used on root %s type.", "@"+d.Name.Name, strings.ToLower(string(opType)))
+	}
+
+	label, ok := d.Arguments.Get("label")
+	if !ok {
+		return
+	}
+	lit, ok := label.(*ast.PrimitiveValue)
+	if !ok {
+		v.c.addErr(label.Location(), "DeferStreamDirectiveLabelRule", "Directive %q's label argument must be a static string.", "@"+d.Name.Name)
+		return
+	}
+	if other, ok := v.labels[lit.Text]; ok && other != d {
+		v.c.addErrMultiLoc([]errors.Location{other.Name.Loc, d.Name.Loc}, "DeferStreamDirectiveLabelRule", "Defer/Stream directive label argument must be unique.")
+		return
+	}
+	v.labels[lit.Text] = d
+}
+
+func unwrapNonNull(t ast.Type) ast.Type {
+	if nn, ok := t.(*ast.NonNull); ok {
+		return nn.OfType
+	}
+	return t
+}
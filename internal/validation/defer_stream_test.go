@@ -0,0 +1,116 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/internal/query"
+	"github.com/graph-gophers/graphql-go/internal/schema"
+)
+
+const deferStreamSchema = `
+	directive @defer(label: String, if: Boolean! = true) on FRAGMENT_SPREAD | INLINE_FRAGMENT
+	directive @stream(label: String, if: Boolean! = true, initialCount: Int = 0) on FIELD
+
+	schema {
+		query: Query
+		mutation: Mutation
+		subscription: Subscription
+	}
+
+	type Query {
+		hero: Character
+		characters: [Character!]!
+	}
+
+	type Mutation {
+		like: Character
+	}
+
+	type Subscription {
+		characters: [Character!]!
+	}
+
+	type Character {
+		name: String!
+		friends: [Character]
+	}`
+
+func TestDeferStream(t *testing.T) {
+	s, err := schema.ParseSchema(deferStreamSchema, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		rules []string
+	}{
+		{
+			name:  "valid",
+			query: `{ hero { name ... @defer(label: "a") { friends @stream(label: "b", initialCount: 1) { name } } } characters @stream { ...F @defer } } fragment F on Character { name }`,
+		},
+		{
+			name:  "defer_on_query_root",
+			query: `{ ... @defer { hero { name } } }`,
+		},
+		{
+			name:  "defer_on_mutation_root",
+			query: `mutation { ... @defer { like { name } } }`,
+			rules: []string{"DeferStreamDirectiveOnRootFieldRule"},
+		},
+		{
+			name:  "stream_on_subscription_root",
+			query: `subscription { characters @stream { name } }`,
+			rules: []string{"DeferStreamDirectiveOnRootFieldRule"},
+		},
+		{
+			name:  "defer_in_subscription_fragment",
+			query: `subscription { ...F } fragment F on Subscription { ... @defer { characters { name } } }`,
+			rules: []string{"DeferStreamDirectiveOnRootFieldRule"},
+		},
+		{
+			name:  "defer_below_mutation_root",
+			query: `mutation { like { ... @defer { name } } }`,
+		},
+		{
+			name:  "stream_on_non_list",
+			query: `{ hero @stream { name } }`,
+			rules: []string{"StreamDirectiveOnListFieldRule"},
+		},
+		{
+			name:  "stream_on_non_list_in_fragment",
+			query: `{ ...F } fragment F on Query { hero { name @stream } }`,
+			rules: []string{"StreamDirectiveOnListFieldRule"},
+		},
+		{
+			name:  "duplicate_labels",
+			query: `{ hero { ... @defer(label: "a") { name } } characters @stream(label: "a") { name } }`,
+			rules: []string{"DeferStreamDirectiveLabelRule"},
+		},
+		{
+			name:  "variable_label",
+			query: `query($label: String) { hero { ... @defer(label: $label) { name } } }`,
+			rules: []string{"DeferStreamDirectiveLabelRule"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			doc, qErr := query.Parse(tc.query)
+			if qErr != nil {
+				t.Fatal(qErr)
+			}
+			errs := Validate(s, doc, nil, 0)
+			if len(errs) != len(tc.rules) {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), len(tc.rules), errs)
+			}
+			for i, err := range errs {
+				if err.Rule != tc.rules[i] {
+					t.Errorf("got rule %q, want %q: %s", err.Rule, tc.rules[i], err.Message)
+				}
+			}
+		})
+	}
+}
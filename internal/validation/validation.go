@@ -66,6 +66,29 @@ func newContext(s *ast.Schema, doc *ast.ExecutableDefinition, maxDepth int) *con
 }
 
 func Validate(s *ast.Schema, doc *ast.ExecutableDefinition, variables map[string]interface{}, maxDepth int) []*errors.QueryError {
+	return validate(s, doc, variables, true, maxDepth)
+}
+
+// ValidateDocument validates the document like Validate, but skips the validation of the variable values. The
+// result only depends on the schema and the document, so it can be reused for every execution of the document
+// together with ValidateVariables.
+func ValidateDocument(s *ast.Schema, doc *ast.ExecutableDefinition, maxDepth int) []*errors.QueryError {
+	return validate(s, doc, nil, false, maxDepth)
+}
+
+// ValidateVariables validates the variable values of the operations of a document which passed ValidateDocument.
+func ValidateVariables(s *ast.Schema, doc *ast.ExecutableDefinition, variables map[string]interface{}) []*errors.QueryError {
+	c := newContext(s, doc, 0)
+	for _, op := range doc.Operations {
+		opc := &opContext{c, []*ast.OperationDefinition{op}}
+		for _, v := range op.Vars {
+			validateValue(opc, v, variables[v.Name.Name], resolveType(c, v.Type))
+		}
+	}
+	return c.errs
+}
+
+func validate(s *ast.Schema, doc *ast.ExecutableDefinition, variables map[string]interface{}, checkVariables bool, maxDepth int) []*errors.QueryError {
 	c := newContext(s, doc, maxDepth)
 
 	opNames := make(nameSet, len(doc.Operations))
@@ -98,7 +121,9 @@ func Validate(s *ast.Schema, doc *ast.ExecutableDefinition, variables map[string
 			if !canBeInput(t) {
 				c.addErr(v.TypeLoc, "VariablesAreInputTypesRule", "Variable %q cannot be non-input type %q.", "$"+v.Name.Name, t)
 			}
-			validateValue(opc, v, variables[v.Name.Name], t)
+			if checkVariables {
+				validateValue(opc, v, variables[v.Name.Name], t)
+			}
 
 			if v.Default != nil {
 				validateLiteral(opc, v.Default)
@@ -173,6 +198,11 @@ func Validate(s *ast.Schema, doc *ast.ExecutableDefinition, variables map[string
 		validateName(c, locs, n, "UniqueFragmentNamesRule", "fragment")
 	}
 
+	// The fields of fragments are only resolved once all fragments are validated.
+	for _, op := range doc.Operations {
+		validateDeferStream(&opContext{c, []*ast.OperationDefinition{op}}, op)
+	}
+
 	for _, frag := range doc.Fragments {
 		if len(fragUsedBy[frag]) == 0 {
 			c.addErr(frag.Loc, "NoUnusedFragmentsRule", "Fragment %q is never used.", frag.Name.Name)
@@ -15,7 +15,10 @@ func (s *Schema) Inspect() *introspection.Schema {
 
 // ToJSON encodes the schema in a JSON format used by tools like Relay.
 func (s *Schema) ToJSON() ([]byte, error) {
-	result := s.exec(context.Background(), introspectionQuery, "", nil, &resolvable.Schema{
+	// The extensions are not called for the internal request, as they could reject it.
+	internal := *s
+	internal.hooks = nil
+	result := internal.exec(context.Background(), &Request{Query: introspectionQuery, internal: true}, &resolvable.Schema{
 		Meta:   s.res.Meta,
 		Query:  &resolvable.Object{},
 		Schema: *s.schema,
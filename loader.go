@@ -0,0 +1,48 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go/internal/batch"
+	"github.com/graph-gophers/graphql-go/loader"
+)
+
+// Loaders enables batched loading with the batch functions of the registry. Resolvers load values with
+// [loader.Load] and [loader.LoadMany], which are batched per type for all resolvers of the same depth. The elements
+// of a list are resolved by at most [MaxParallelism] goroutines, so the loads of longer lists are split into several
+// batches.
+func Loaders(registry *loader.Registry) SchemaOpt {
+	return func(s *Schema) {
+		s.loaders = registry
+	}
+}
+
+// batchLookup adapts the batch functions of the registry to the executor.
+func batchLookup(registry *loader.Registry) batch.Lookup {
+	if registry == nil {
+		return nil
+	}
+	return func(typ string) (batch.Func, bool) {
+		fn, ok := registry.BatchFunc(typ)
+		if !ok {
+			return nil, false
+		}
+		return func(ctx context.Context, keys []interface{}) ([]interface{}, []error) {
+			results := fn(ctx, keys)
+			if len(results) != len(keys) {
+				return nil, nil
+			}
+			values := make([]interface{}, len(keys))
+			errs := make([]error, len(keys))
+			for i, res := range results {
+				if res == nil {
+					errs[i] = fmt.Errorf("the batch function of type %q returned no result for key %v", typ, keys[i])
+					continue
+				}
+				values[i], errs[i] = res.Data, res.Error
+			}
+			return values, errs
+		}, true
+	}
+}
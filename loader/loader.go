@@ -0,0 +1,79 @@
+// Package loader provides batched loading of values to avoid the N+1 problem of resolvers.
+//
+// Batch functions are registered per type in a [Registry], which is passed to the schema with the
+// graphql.Loaders option. During an execution, resolvers call [Load] or [LoadMany] with their context. The
+// keys are not loaded right away; instead the executor waits until every resolver of the current depth either
+// queued its keys or finished, and then calls each batch function once with all keys queued for its type.
+// Loaded values are cached for the rest of the execution.
+//
+// Loads must be called from the goroutine of the resolver. The executor only knows about its own goroutines, so
+// a resolver which waits for goroutines that call Load blocks the execution until its context is done.
+package loader
+
+import (
+	"context"
+	"errors"
+
+	"github.com/graph-gophers/graphql-go/internal/batch"
+)
+
+// BatchFunc loads the values of the keys in a single call. It must return a result per key in the order of the
+// keys. The context is the context of the execution.
+type BatchFunc func(ctx context.Context, keys []interface{}) []*Result
+
+// Result is the value loaded for a key or the error which occurred.
+type Result struct {
+	Data  interface{}
+	Error error
+}
+
+// Registry holds the batch functions of the types. It must not be modified while it is used by a schema.
+type Registry struct {
+	funcs map[string]BatchFunc
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{funcs: make(map[string]BatchFunc)}
+}
+
+// Register registers the batch function of the type. The type is an arbitrary name, usually the name of the
+// GraphQL type of the loaded values.
+func (r *Registry) Register(typ string, fn BatchFunc) {
+	r.funcs[typ] = fn
+}
+
+// BatchFunc returns the batch function registered for the type.
+func (r *Registry) BatchFunc(typ string) (BatchFunc, bool) {
+	fn, ok := r.funcs[typ]
+	return fn, ok
+}
+
+// ErrNoLoaders is returned by [Load] and [LoadMany] if the context doesn't belong to an execution of a schema
+// with loaders.
+var ErrNoLoaders = errors.New("loader: the schema has no loaders or the context does not belong to an execution")
+
+// Load loads the value of the key with the batch function of the type. The key must be comparable. The call
+// blocks until the batch containing the key was loaded.
+func Load(ctx context.Context, typ string, key interface{}) (interface{}, error) {
+	s := batch.FromContext(ctx)
+	if s == nil {
+		return nil, ErrNoLoaders
+	}
+	values, errs := s.Load(ctx, typ, []interface{}{key})
+	return values[0], errs[0]
+}
+
+// LoadMany loads the values of several keys with the batch function of the type. The keys must be comparable.
+// The returned slices hold the value and the error of every key in the order of the keys.
+func LoadMany(ctx context.Context, typ string, keys []interface{}) ([]interface{}, []error) {
+	s := batch.FromContext(ctx)
+	if s == nil {
+		errs := make([]error, len(keys))
+		for i := range errs {
+			errs[i] = ErrNoLoaders
+		}
+		return make([]interface{}, len(keys)), errs
+	}
+	return s.Load(ctx, typ, keys)
+}
@@ -0,0 +1,314 @@
+package loader_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/introspection"
+	"github.com/graph-gophers/graphql-go/loader"
+	"github.com/graph-gophers/graphql-go/trace/noop"
+)
+
+const schema = `
+	type Query {
+		users: [User!]!
+		user(id: ID!): User
+	}
+
+	type User {
+		id: ID!
+		name: String!
+		bestFriend: User
+		friends: [User!]!
+		pet: String
+	}
+`
+
+var friends = map[string][]string{
+	"1": {"2", "3"},
+	"2": {"1"},
+	"3": {"1", "2"},
+	"4": {},
+}
+
+type root struct{}
+
+func (root) Users() []*userResolver {
+	return []*userResolver{{"1"}, {"2"}, {"3"}, {"4"}}
+}
+
+func (root) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	return loadUser(ctx, string(args.ID))
+}
+
+type userResolver struct {
+	id string
+}
+
+func loadUser(ctx context.Context, id string) (*userResolver, error) {
+	v, err := loader.Load(ctx, "User", id)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return &userResolver{v.(string)}, nil
+}
+
+func (u *userResolver) ID() graphql.ID { return graphql.ID(u.id) }
+func (u *userResolver) Name() string   { return "user " + u.id }
+
+func (u *userResolver) BestFriend(ctx context.Context) (*userResolver, error) {
+	if len(friends[u.id]) == 0 {
+		return nil, nil
+	}
+	return loadUser(ctx, friends[u.id][0])
+}
+
+func (u *userResolver) Friends(ctx context.Context) ([]*userResolver, error) {
+	keys := make([]interface{}, len(friends[u.id]))
+	for i, id := range friends[u.id] {
+		keys[i] = id
+	}
+	values, errs := loader.LoadMany(ctx, "User", keys)
+	users := make([]*userResolver, len(values))
+	for i := range values {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		users[i] = &userResolver{values[i].(string)}
+	}
+	return users, nil
+}
+
+func (u *userResolver) Pet(ctx context.Context) (*string, error) {
+	v, err := loader.Load(ctx, "Pet", u.id)
+	if err != nil {
+		return nil, err
+	}
+	pet := v.(string)
+	return &pet, nil
+}
+
+// recorder records the keys of every call of the batch functions.
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) record(typ string, keys []interface{}) {
+	ids := make([]string, len(keys))
+	for i, k := range keys {
+		ids[i] = k.(string)
+	}
+	sort.Strings(ids)
+	r.mu.Lock()
+	r.calls = append(r.calls, fmt.Sprintf("%s%v", typ, ids))
+	r.mu.Unlock()
+}
+
+func (r *recorder) registry() *loader.Registry {
+	reg := loader.NewRegistry()
+	reg.Register("User", func(ctx context.Context, keys []interface{}) []*loader.Result {
+		r.record("User", keys)
+		results := make([]*loader.Result, len(keys))
+		for i, k := range keys {
+			if _, ok := friends[k.(string)]; !ok {
+				results[i] = &loader.Result{Error: fmt.Errorf("user %s not found", k)}
+				continue
+			}
+			results[i] = &loader.Result{Data: k}
+		}
+		return results
+	})
+	reg.Register("Pet", func(ctx context.Context, keys []interface{}) []*loader.Result {
+		r.record("Pet", keys)
+		results := make([]*loader.Result, len(keys))
+		for i, k := range keys {
+			results[i] = &loader.Result{Data: "pet of " + k.(string)}
+		}
+		return results
+	})
+	return reg
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []graphql.SchemaOpt
+		query string
+		want  string
+		calls []string
+	}{
+		{
+			name:  "batch_per_depth",
+			query: `{ users { bestFriend { name bestFriend { id } } } }`,
+			want:  `{"data":{"users":[{"bestFriend":{"name":"user 2","bestFriend":{"id":"1"}}},{"bestFriend":{"name":"user 1","bestFriend":{"id":"2"}}},{"bestFriend":{"name":"user 1","bestFriend":{"id":"2"}}},{"bestFriend":null}]}}`,
+			calls: []string{"User[1 2]"},
+		},
+		{
+			name:  "types_in_parallel",
+			query: `{ users { pet friends { id pet } } }`,
+			want:  `{"data":{"users":[{"pet":"pet of 1","friends":[{"id":"2","pet":"pet of 2"},{"id":"3","pet":"pet of 3"}]},{"pet":"pet of 2","friends":[{"id":"1","pet":"pet of 1"}]},{"pet":"pet of 3","friends":[{"id":"1","pet":"pet of 1"},{"id":"2","pet":"pet of 2"}]},{"pet":"pet of 4","friends":[]}]}}`,
+			calls: []string{"Pet[1 2 3 4]", "User[1 2 3]"},
+		},
+		{
+			name:  "limited_parallelism",
+			opts:  []graphql.SchemaOpt{graphql.MaxParallelism(1)},
+			query: `{ users { friends { pet } } }`,
+			want:  `{"data":{"users":[{"friends":[{"pet":"pet of 2"},{"pet":"pet of 3"}]},{"friends":[{"pet":"pet of 1"}]},{"friends":[{"pet":"pet of 1"},{"pet":"pet of 2"}]},{"friends":[]}]}}`,
+			// the elements of a list are resolved one at a time, so their loads are batched separately
+			calls: []string{"User[1]", "User[2 3]", "Pet[1]", "Pet[2]", "Pet[3]"},
+		},
+		{
+			name:  "aliases",
+			query: `{ a: user(id: "1") { name } b: user(id: "1") { id } c: user(id: "5") { id } }`,
+			want:  `{"errors":[{"message":"user 5 not found","path":["c"]}],"data":{"a":{"name":"user 1"},"b":{"id":"1"},"c":null}}`,
+			calls: []string{"User[1 5]"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{}
+			opts := append([]graphql.SchemaOpt{graphql.Loaders(rec.registry())}, tt.opts...)
+			s := graphql.MustParseSchema(schema, &root{}, opts...)
+			resp := s.Exec(context.Background(), tt.query, "", nil)
+			got, err := json.Marshal(resp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			sort.Strings(rec.calls)
+			sort.Strings(tt.calls)
+			if !reflect.DeepEqual(rec.calls, tt.calls) {
+				t.Errorf("got batches %v, want %v", rec.calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Run("no_loaders", func(t *testing.T) {
+		s := graphql.MustParseSchema(schema, &root{})
+		resp := s.Exec(context.Background(), `{ user(id: "1") { id } }`, "", nil)
+		if len(resp.Errors) != 1 || !errors.Is(resp.Errors[0].ResolverError, loader.ErrNoLoaders) {
+			t.Errorf("got errors %v, want %v", resp.Errors, loader.ErrNoLoaders)
+		}
+	})
+
+	t.Run("unregistered_type", func(t *testing.T) {
+		s := graphql.MustParseSchema(schema, &root{}, graphql.Loaders(loader.NewRegistry()))
+		resp := s.Exec(context.Background(), `{ user(id: "1") { id } }`, "", nil)
+		if len(resp.Errors) != 1 || resp.Errors[0].Message != `no batch function is registered for type "User"` {
+			t.Errorf("got errors %v", resp.Errors)
+		}
+	})
+
+	t.Run("wrong_number_of_results", func(t *testing.T) {
+		reg := loader.NewRegistry()
+		reg.Register("User", func(ctx context.Context, keys []interface{}) []*loader.Result {
+			return nil
+		})
+		s := graphql.MustParseSchema(schema, &root{}, graphql.Loaders(reg))
+		resp := s.Exec(context.Background(), `{ user(id: "1") { id } }`, "", nil)
+		if len(resp.Errors) != 1 || resp.Errors[0].Message != `the batch function of type "User" returned 0 values and 0 errors for 1 keys` {
+			t.Errorf("got errors %v", resp.Errors)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		reg := loader.NewRegistry()
+		reg.Register("User", func(ctx context.Context, keys []interface{}) []*loader.Result {
+			panic("boom")
+		})
+		s := graphql.MustParseSchema(schema, &root{}, graphql.Loaders(reg))
+		resp := s.Exec(context.Background(), `{ user(id: "1") { id } }`, "", nil)
+		if len(resp.Errors) != 1 || resp.Errors[0].Message != `panic occurred in the batch function of type "User": boom` {
+			t.Errorf("got errors %v", resp.Errors)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		reg := loader.NewRegistry()
+		reg.Register("User", func(ctx context.Context, keys []interface{}) []*loader.Result {
+			cancel()
+			<-ctx.Done()
+			return []*loader.Result{{Error: ctx.Err()}}
+		})
+		s := graphql.MustParseSchema(schema, &root{}, graphql.Loaders(reg))
+		resp := s.Exec(ctx, `{ user(id: "1") { id } }`, "", nil)
+		if len(resp.Errors) != 1 || resp.Errors[0].Message != "context canceled" {
+			t.Errorf("got errors %v", resp.Errors)
+		}
+	})
+}
+
+type batchTracer struct {
+	noop.Tracer
+	mu      sync.Mutex
+	batches []string
+}
+
+func (t *batchTracer) TraceBatch(ctx context.Context, typeName string, keys []interface{}) (context.Context, func([]error)) {
+	return ctx, func(errs []error) {
+		failed := 0
+		for _, err := range errs {
+			if err != nil {
+				failed++
+			}
+		}
+		t.mu.Lock()
+		t.batches = append(t.batches, fmt.Sprintf("%s: %d keys, %d errors", typeName, len(keys), failed))
+		t.mu.Unlock()
+	}
+}
+
+type fieldTracer struct {
+	mu     sync.Mutex
+	labels []string
+}
+
+func (t *fieldTracer) TraceQuery(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, varTypes map[string]*introspection.Type) (context.Context, func([]*qerrors.QueryError)) {
+	return ctx, func([]*qerrors.QueryError) {}
+}
+
+func (t *fieldTracer) TraceField(ctx context.Context, label, typeName, fieldName string, trivial bool, args map[string]interface{}) (context.Context, func(*qerrors.QueryError)) {
+	t.mu.Lock()
+	t.labels = append(t.labels, label)
+	t.mu.Unlock()
+	return ctx, func(*qerrors.QueryError) {}
+}
+
+func TestLoad_Tracing(t *testing.T) {
+	const query = `{ a: user(id: "1") { id } b: user(id: "9") { id } }`
+
+	bt := &batchTracer{}
+	s := graphql.MustParseSchema(schema, &root{}, graphql.Loaders((&recorder{}).registry()), graphql.Tracer(bt))
+	s.Exec(context.Background(), query, "", nil)
+	if want := []string{"User: 2 keys, 1 errors"}; !reflect.DeepEqual(bt.batches, want) {
+		t.Errorf("got batches %v, want %v", bt.batches, want)
+	}
+
+	// Tracers without TraceBatch trace the batches like fields.
+	ft := &fieldTracer{}
+	s = graphql.MustParseSchema(schema, &root{}, graphql.Loaders((&recorder{}).registry()), graphql.Tracer(ft))
+	s.Exec(context.Background(), query, "", nil)
+	found := false
+	for _, label := range ft.labels {
+		found = found || label == "GraphQL batch: User"
+	}
+	if !found {
+		t.Errorf("got labels %v, want a batch", ft.labels)
+	}
+}
@@ -11,6 +11,18 @@ type Logger interface {
 	LogPanic(ctx context.Context, value interface{})
 }
 
+// ErrorLogger is an optional interface of a Logger. It logs the errors which are masked in responses, together
+// with the correlation ID that is returned to the client instead of the error message.
+type ErrorLogger interface {
+	LogError(ctx context.Context, correlationID string, err error)
+}
+
+// DocumentLogger is an optional interface of a Logger. It logs the documents which are executed although they are
+// not trusted, see graphql.LogUntrustedDocuments.
+type DocumentLogger interface {
+	LogUntrustedDocument(ctx context.Context, query string)
+}
+
 // DefaultLogger is the default logger used to log panics that occur during query execution
 type DefaultLogger struct{}
 
@@ -21,3 +33,13 @@ func (l *DefaultLogger) LogPanic(ctx context.Context, value interface{}) {
 	buf = buf[:runtime.Stack(buf, false)]
 	log.Printf("graphql: panic occurred: %v\n%s\ncontext: %v", value, buf, ctx)
 }
+
+// LogError is used to log errors which are masked in responses
+func (l *DefaultLogger) LogError(ctx context.Context, correlationID string, err error) {
+	log.Printf("graphql: error %s: %v\ncontext: %v", correlationID, err, ctx)
+}
+
+// LogUntrustedDocument is used to log documents which are executed although they are not trusted
+func (l *DefaultLogger) LogUntrustedDocument(ctx context.Context, query string) {
+	log.Printf("graphql: untrusted document executed: %q", query)
+}
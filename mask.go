@@ -0,0 +1,143 @@
+package graphql
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/log"
+)
+
+// MaskedErrorMessage replaces the message of the errors masked by [MaskErrors].
+const MaskedErrorMessage = "internal server error"
+
+// ErrorPresenter sets a function which is called with every error of a response before the response is returned.
+// The error returned by fn replaces the error in the response. The function receives a copy of the error, so the
+// tracer still receives the original error. If errors are masked with [MaskErrors], fn receives the masked errors,
+// and if [ErrorCodes] are enabled, it receives the errors with their codes.
+func ErrorPresenter(fn func(ctx context.Context, err *qerrors.QueryError) *qerrors.QueryError) SchemaOpt {
+	return func(s *Schema) {
+		s.errorPresenter = fn
+	}
+}
+
+// MaskErrors hides the messages of the errors returned by resolvers and of panics from clients. The message of a
+// masked error is replaced with [MaskedErrorMessage] and its extensions only hold a "correlationId", which is
+// logged together with the full error by loggers that implement [log.ErrorLogger]. The tracer receives the full
+// errors. Errors which implement [qerrors.SafeError], errors of type *[qerrors.QueryError], e.g. those created with
+// the constructors of the errors package, and errors matching one of the allowed errors, as reported by errors.Is,
+// are not masked. Errors of the parsing, the validation and the execution itself are never masked.
+func MaskErrors(allowed ...error) SchemaOpt {
+	return func(s *Schema) {
+		s.errorMask = &errorMask{allowed: allowed}
+	}
+}
+
+// errorMask holds the errors which are not masked by MaskErrors.
+type errorMask struct {
+	allowed []error
+}
+
+// masks reports whether the error of a resolver has to be masked.
+func (m *errorMask) masks(resolverErr error) bool {
+	var safe qerrors.SafeError
+	if errors.As(resolverErr, &safe) && safe.Safe() {
+		return false
+	}
+	var qErr *qerrors.QueryError
+	if errors.As(resolverErr, &qErr) {
+		return false
+	}
+	for _, target := range m.allowed {
+		if errors.Is(resolverErr, target) {
+			return false
+		}
+	}
+	return true
+}
+
+// present finalizes the response with the extensions, masks and classifies its errors and passes them to the error
+// presenter. The errors are replaced, not modified, so that the tracer keeps the original errors. It returns the
+// response.
+func (s *Schema) present(ctx context.Context, resp *Response) *Response {
+	s.finalizeResponse(ctx, resp)
+	if s.errorMask == nil && s.errorPresenter == nil && !s.errorCodes && !s.validationRuleNames {
+		return resp
+	}
+	resp.Errors = s.presentErrors(ctx, resp.Errors)
+	for _, r := range resp.Incremental {
+		r.Errors = s.presentErrors(ctx, r.Errors)
+	}
+	return resp
+}
+
+func (s *Schema) presentErrors(ctx context.Context, errs []*qerrors.QueryError) []*qerrors.QueryError {
+	if len(errs) == 0 {
+		return errs
+	}
+	presented := make([]*qerrors.QueryError, len(errs))
+	for i, err := range errs {
+		if s.errorMask != nil && err.ResolverError != nil && s.errorMask.masks(err.ResolverError) {
+			err = s.mask(ctx, err)
+		}
+		err = s.classify(err)
+		if s.errorPresenter != nil {
+			e := *err
+			if p := s.errorPresenter(ctx, &e); p != nil {
+				err = p
+			}
+		}
+		presented[i] = err
+	}
+	return presented
+}
+
+// mask logs the error with a new correlation ID and returns the masked error, which only reveals the ID.
+func (s *Schema) mask(ctx context.Context, err *qerrors.QueryError) *qerrors.QueryError {
+	id := newCorrelationID()
+	if l, ok := s.logger.(log.ErrorLogger); ok {
+		l.LogError(ctx, id, err)
+	}
+	return &qerrors.QueryError{
+		Err:           err,
+		Message:       MaskedErrorMessage,
+		Locations:     err.Locations,
+		Path:          err.Path,
+		Rule:          err.Rule,
+		ResolverError: err.ResolverError,
+		Extensions:    map[string]interface{}{"correlationId": id},
+	}
+}
+
+func newCorrelationID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("graphql: can not create a correlation ID: %v", err))
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// maskingPanicHandler sets the resolver error of panic errors, so that they are masked like the errors returned
+// by resolvers.
+type maskingPanicHandler struct {
+	qerrors.PanicHandler
+}
+
+func (h *maskingPanicHandler) MakePanicError(ctx context.Context, value interface{}) *qerrors.QueryError {
+	err := h.PanicHandler.MakePanicError(ctx, value)
+	if err.ResolverError == nil {
+		err.ResolverError = &panicError{value: value}
+	}
+	return err
+}
+
+type panicError struct {
+	value interface{}
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic occurred: %v", e.value)
+}
@@ -0,0 +1,178 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+const maskSchema = `
+	type Query {
+		internal: String
+		safe: String
+		notFound: String
+		panic: String
+		typed(n: Int!): String
+	}
+`
+
+var errNotFound = errors.New("not found")
+
+type maskQuery struct{}
+
+func (maskQuery) Internal() (*string, error) {
+	return nil, errors.New(`pq: relation "users" does not exist`)
+}
+
+func (maskQuery) Safe() (*string, error) {
+	return nil, qerrors.Safe(errors.New("invalid cursor"))
+}
+
+func (maskQuery) NotFound() (*string, error) {
+	return nil, fmt.Errorf("user 1: %w", errNotFound)
+}
+
+func (maskQuery) Panic() *string {
+	panic("secret")
+}
+
+func (maskQuery) Typed(args struct{ N int32 }) *string {
+	return nil
+}
+
+type maskLogger struct {
+	mu     sync.Mutex
+	logged map[string]error
+}
+
+func (l *maskLogger) LogPanic(ctx context.Context, value interface{}) {}
+
+func (l *maskLogger) LogError(ctx context.Context, correlationID string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.logged[correlationID] = err
+}
+
+func TestMaskErrors(t *testing.T) {
+	logger := &maskLogger{logged: make(map[string]error)}
+	tracer := &testTracer{mu: &sync.Mutex{}}
+	s := graphql.MustParseSchema(maskSchema, &maskQuery{},
+		graphql.MaskErrors(errNotFound),
+		graphql.Logger(logger),
+		graphql.Tracer(tracer),
+	)
+
+	tests := []struct {
+		name    string
+		query   string
+		masked  bool
+		message string
+	}{
+		{name: "resolver_error", query: `{ internal }`, masked: true, message: `pq: relation "users" does not exist`},
+		{name: "panic", query: `{ panic }`, masked: true, message: "panic occurred: secret"},
+		{name: "safe_error", query: `{ safe }`, message: "invalid cursor"},
+		{name: "allowed_error", query: `{ notFound }`, message: "user 1: not found"},
+		{name: "validation_error", query: `{ typed }`, message: `Field "typed" argument "n" of type "Int!" is required, but it was not provided.`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			resp := s.Exec(context.Background(), tt.query, "", nil)
+			if len(resp.Errors) != 1 {
+				t.Fatalf("got errors %v, want one error", resp.Errors)
+			}
+			err := resp.Errors[0]
+			if !tt.masked {
+				if err.Message != tt.message {
+					t.Errorf("got message %q, want %q", err.Message, tt.message)
+				}
+				return
+			}
+
+			if err.Message != graphql.MaskedErrorMessage {
+				t.Errorf("got message %q, want %q", err.Message, graphql.MaskedErrorMessage)
+			}
+			id, _ := err.Extensions["correlationId"].(string)
+			if id == "" || len(err.Extensions) != 1 {
+				t.Fatalf("got extensions %v, want a correlation ID", err.Extensions)
+			}
+			logger.mu.Lock()
+			logged := logger.logged[id]
+			logger.mu.Unlock()
+			var qErr *qerrors.QueryError
+			if !errors.As(logged, &qErr) || qErr.Message != tt.message {
+				t.Errorf("got logged error %v, want %q", logged, tt.message)
+			}
+		})
+	}
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	for _, q := range tracer.queries {
+		for _, err := range q.errors {
+			if err.Message == graphql.MaskedErrorMessage {
+				t.Errorf("got masked error %v in the tracer, want the original error", err)
+			}
+		}
+	}
+}
+
+func TestErrorPresenter(t *testing.T) {
+	presenter := func(ctx context.Context, err *qerrors.QueryError) *qerrors.QueryError {
+		if err.Extensions == nil {
+			err.Extensions = make(map[string]interface{})
+		}
+		err.Extensions["presented"] = true
+		return err
+	}
+	s := graphql.MustParseSchema(maskSchema, &maskQuery{},
+		graphql.ErrorPresenter(presenter),
+		graphql.MaskErrors(),
+		graphql.Logger(&maskLogger{logged: make(map[string]error)}),
+	)
+
+	for _, query := range []string{`{ internal }`, `{ typed }`, `{ unknown }`} {
+		resp := s.Exec(context.Background(), query, "", nil)
+		if len(resp.Errors) != 1 || resp.Errors[0].Extensions["presented"] != true {
+			t.Errorf("got errors %v for %s, want presented errors", resp.Errors, query)
+		}
+	}
+
+	resp := s.Exec(context.Background(), `{ internal }`, "", nil)
+	if err := resp.Errors[0]; err.Message != graphql.MaskedErrorMessage || err.Extensions["correlationId"] == nil {
+		t.Errorf("got error %v with extensions %v, want the masked error to be presented", err, err.Extensions)
+	}
+}
+
+func TestMaskErrors_Subscription(t *testing.T) {
+	s := graphql.MustParseSchema(`
+		type Query { hello: String! }
+		type Subscription { events: String! }
+	`, &maskSubscription{}, graphql.MaskErrors(), graphql.Logger(&maskLogger{logged: make(map[string]error)}))
+
+	c, err := s.Subscribe(context.Background(), `subscription { events }`, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []*qerrors.QueryError
+	for resp := range c {
+		got = append(got, resp.(*graphql.Response).Errors...)
+	}
+	if len(got) != 1 || got[0].Message != graphql.MaskedErrorMessage {
+		t.Errorf("got errors %v, want a masked error", got)
+	}
+}
+
+type maskSubscription struct{}
+
+func (maskSubscription) Hello() string { return "hello" }
+
+func (maskSubscription) Events() (<-chan string, error) {
+	return nil, errors.New("broker unavailable")
+}
@@ -0,0 +1,132 @@
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/lru"
+)
+
+// DefaultPersistedQueryCacheSize is the number of queries held by the default [PersistedQueryStore].
+const DefaultPersistedQueryCacheSize = 1000
+
+// PersistedQueryStore stores the documents of automatic persisted queries by their SHA-256 hash.
+// Implementations must be safe for concurrent use.
+type PersistedQueryStore interface {
+	// Get returns the query stored for the hex encoded SHA-256 hash.
+	Get(ctx context.Context, hash string) (query string, ok bool)
+	// Put stores the query for the hex encoded SHA-256 hash. It is only called for queries which match the hash
+	// and passed validation.
+	Put(ctx context.Context, hash string, query string)
+}
+
+// NewPersistedQueryCache returns an in-memory [PersistedQueryStore] which holds up to size queries and evicts
+// the least recently used ones.
+func NewPersistedQueryCache(size int) PersistedQueryStore {
+	return &persistedQueryCache{cache: lru.New(size)}
+}
+
+type persistedQueryCache struct {
+	cache *lru.Cache
+}
+
+func (c *persistedQueryCache) Get(_ context.Context, hash string) (string, bool) {
+	v, ok := c.cache.Get(hash)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+func (c *persistedQueryCache) Put(_ context.Context, hash string, query string) {
+	c.cache.Add(hash, query)
+}
+
+// UsePersistedQueryStore sets the store of automatic persisted queries. It defaults to an in-memory cache
+// created with [NewPersistedQueryCache] and [DefaultPersistedQueryCacheSize]. Passing nil disables automatic
+// persisted queries.
+func UsePersistedQueryStore(store PersistedQueryStore) SchemaOpt {
+	return func(s *Schema) {
+		s.persistedQueries = store
+	}
+}
+
+// Messages and codes of the errors returned for automatic persisted queries, which are understood by the clients.
+const (
+	PersistedQueryNotFound     = "PersistedQueryNotFound"
+	PersistedQueryNotSupported = "PersistedQueryNotSupported"
+)
+
+func persistedQueryError(message, code string) *errors.QueryError {
+	return &errors.QueryError{Message: message, Extensions: map[string]interface{}{"code": code}}
+}
+
+// PersistedQuery returns the query registered for the hex encoded SHA-256 hash of an automatic persisted query.
+// It allows transports to inspect a persisted query before executing it.
+func (s *Schema) PersistedQuery(ctx context.Context, hash string) (string, bool) {
+	if s.persistedQueries == nil {
+		return "", false
+	}
+	return s.persistedQueries.Get(ctx, strings.ToLower(hash))
+}
+
+// persistedQuery returns the query of the request following the automatic persisted queries protocol.
+// See https://github.com/apollographql/apollo-link-persisted-queries#protocol.
+// If the request registers a new query, the returned persist func has to be called once the query is valid.
+func (s *Schema) persistedQuery(ctx context.Context, req *Request) (string, func(), *errors.QueryError) {
+	ext, ok := req.Extensions["persistedQuery"].(map[string]interface{})
+	if !ok {
+		return req.Query, nil, nil
+	}
+	if s.persistedQueries == nil {
+		return "", nil, persistedQueryError(PersistedQueryNotSupported, errors.CodePersistedQueryNotSupported)
+	}
+	if !isVersion1(ext["version"]) {
+		return "", nil, errors.Errorf("unsupported persisted query version")
+	}
+	hash, _ := ext["sha256Hash"].(string)
+	hash = strings.ToLower(hash)
+	if hash == "" {
+		return "", nil, errors.Errorf("the sha256Hash of the persisted query is missing")
+	}
+
+	if req.Query == "" {
+		query, ok := s.persistedQueries.Get(ctx, hash)
+		if !ok {
+			return "", nil, persistedQueryError(PersistedQueryNotFound, errors.CodePersistedQueryNotFound)
+		}
+		return query, nil, nil
+	}
+
+	if sha256Hash(req.Query) != hash {
+		return "", nil, errors.Errorf("provided sha does not match query")
+	}
+	persist := func() {
+		s.persistedQueries.Put(ctx, hash, req.Query)
+	}
+	return req.Query, persist, nil
+}
+
+// isVersion1 reports whether the version of the persisted query extension is 1, which is the only one defined.
+func isVersion1(v interface{}) bool {
+	switch v := v.(type) {
+	case float64:
+		return v == 1
+	case int:
+		return v == 1
+	case int32:
+		return v == 1
+	case int64:
+		return v == 1
+	}
+	return false
+}
+
+// sha256Hash returns the hex encoded SHA-256 hash of the document.
+func sha256Hash(doc string) string {
+	sum := sha256.Sum256([]byte(doc))
+	return hex.EncodeToString(sum[:])
+}
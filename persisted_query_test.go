@@ -0,0 +1,129 @@
+package graphql_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+const helloSchema = `
+	type Query {
+		hello: String!
+	}
+`
+
+func sha256Hex(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+func persistedQuery(query string) map[string]interface{} {
+	return map[string]interface{}{
+		"persistedQuery": map[string]interface{}{"version": float64(1), "sha256Hash": sha256Hex(query)},
+	}
+}
+
+func marshalResponse(t *testing.T, resp *graphql.Response) string {
+	t.Helper()
+	b, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestExecRequest_PersistedQuery(t *testing.T) {
+	t.Parallel()
+
+	const query = `{ hello }`
+	ctx := context.Background()
+	s := graphql.MustParseSchema(helloSchema, &helloWorldResolver1{})
+	ext := persistedQuery(query)
+
+	steps := []struct {
+		name string
+		req  *graphql.Request
+		want string
+	}{
+		{
+			name: "unknown_hash",
+			req:  &graphql.Request{Extensions: ext},
+			want: `{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`,
+		},
+		{
+			name: "hash_mismatch",
+			req:  &graphql.Request{Query: `{ __typename }`, Extensions: ext},
+			want: `{"errors":[{"message":"provided sha does not match query"}]}`,
+		},
+		{
+			name: "invalid_query_not_registered",
+			req:  &graphql.Request{Query: `{ unknown }`, Extensions: persistedQuery(`{ unknown }`)},
+			want: `{"errors":[{"message":"Cannot query field \"unknown\" on type \"Query\".","locations":[{"line":1,"column":3}]}]}`,
+		},
+		{
+			name: "register",
+			req:  &graphql.Request{Query: query, Extensions: ext},
+			want: `{"data":{"hello":"Hello world!"}}`,
+		},
+		{
+			name: "registered_hash",
+			req:  &graphql.Request{Extensions: ext},
+			want: `{"data":{"hello":"Hello world!"}}`,
+		},
+		{
+			name: "invalid_query_hash",
+			req:  &graphql.Request{Extensions: persistedQuery(`{ unknown }`)},
+			want: `{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`,
+		},
+		{
+			name: "unsupported_version",
+			req:  &graphql.Request{Extensions: map[string]interface{}{"persistedQuery": map[string]interface{}{"version": float64(2), "sha256Hash": "abc"}}},
+			want: `{"errors":[{"message":"unsupported persisted query version"}]}`,
+		},
+	}
+	// The steps depend on each other and must run in order.
+	for _, step := range steps {
+		if got := marshalResponse(t, s.ExecRequest(ctx, step.req)); got != step.want {
+			t.Fatalf("%s: got %s, want %s", step.name, got, step.want)
+		}
+	}
+}
+
+type mapStore map[string]string
+
+func (m mapStore) Get(_ context.Context, hash string) (string, bool) {
+	q, ok := m[hash]
+	return q, ok
+}
+
+func (m mapStore) Put(_ context.Context, hash string, query string) {
+	m[hash] = query
+}
+
+func TestUsePersistedQueryStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := mapStore{"0123abcd": `{ hello }`}
+	s := graphql.MustParseSchema(helloSchema, &helloWorldResolver1{}, graphql.UsePersistedQueryStore(store))
+
+	ext := map[string]interface{}{"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": "0123ABCD"}}
+	if got, want := marshalResponse(t, s.ExecRequest(ctx, &graphql.Request{Extensions: ext})), `{"data":{"hello":"Hello world!"}}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	s.ExecRequest(ctx, &graphql.Request{Query: `{ __typename }`, Extensions: persistedQuery(`{ __typename }`)})
+	if len(store) != 2 {
+		t.Errorf("got %d stored queries, want 2", len(store))
+	}
+
+	disabled := graphql.MustParseSchema(helloSchema, &helloWorldResolver1{}, graphql.UsePersistedQueryStore(nil))
+	want := `{"errors":[{"message":"PersistedQueryNotSupported","extensions":{"code":"PERSISTED_QUERY_NOT_SUPPORTED"}}]}`
+	if got := marshalResponse(t, disabled.ExecRequest(ctx, &graphql.Request{Extensions: ext})); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go/ast"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/exec"
+	"github.com/graph-gophers/graphql-go/internal/exec/selected"
+	"github.com/graph-gophers/graphql-go/internal/query"
+	"github.com/graph-gophers/graphql-go/internal/validation"
+	"github.com/graph-gophers/graphql-go/introspection"
+)
+
+// PreparedOperation is a parsed and validated query or mutation, which can be executed many times with different
+// variables. It is safe for concurrent use.
+type PreparedOperation struct {
+	s        *Schema
+	doc      *ast.ExecutableDefinition
+	op       *ast.OperationDefinition
+	query    string
+	name     string
+	varTypes map[string]*introspection.Type
+
+	// plans holds the selections of an operation without variables, which only depend on whether
+	// introspection is allowed. They are indexed by the AllowIntrospection setting.
+	plans [2][]selected.Selection
+}
+
+// Prepare parses and validates the operation of the query, so that it can be executed repeatedly without paying
+// for parsing and validation again. The operation name may be empty if the query contains a single operation.
+// Subscriptions can not be prepared. Like [Schema.Exec], Prepare only accepts trusted documents if the schema
+// was created with [UseTrustedDocuments]. The errors are presented like the errors of a response.
+func (s *Schema) Prepare(queryString string, operationName string) (*PreparedOperation, []*errors.QueryError) {
+	p, errs := s.prepare(queryString, operationName)
+	if len(errs) != 0 {
+		return nil, s.presentErrors(context.Background(), errs)
+	}
+	return p, nil
+}
+
+func (s *Schema) prepare(queryString string, operationName string) (*PreparedOperation, []*errors.QueryError) {
+	queryString, _, qErr := s.requestQuery(context.Background(), &Request{Query: queryString})
+	if qErr != nil {
+		return nil, []*errors.QueryError{s.coded(errors.CodeBadRequest, qErr)}
+	}
+	if s.maxQueryLength > 0 && len(queryString) > s.maxQueryLength {
+		qErr := errors.Errorf("query length %d exceeds the maximum allowed query length of %d bytes", len(queryString), s.maxQueryLength)
+		return nil, []*errors.QueryError{s.coded(errors.CodeBadRequest, qErr)}
+	}
+	d, qErr := s.parse(queryString)
+	if qErr != nil {
+		return nil, []*errors.QueryError{s.coded(errors.CodeParseFailed, qErr)}
+	}
+	if errs := s.validateDocument(d); len(errs) != 0 {
+		return nil, errs
+	}
+
+	op, err := getOperation(d.doc, operationName)
+	if err != nil {
+		return nil, []*errors.QueryError{s.coded(errors.CodeOperationResolutionFailure, errors.Errorf("%s", err))}
+	}
+	if operationName == "" {
+		operationName = op.Name.Name
+	}
+	if op.Type == query.Subscription {
+		return nil, []*errors.QueryError{s.coded(errors.CodeBadRequest, errors.Errorf("subscriptions can not be prepared, use Schema.Subscribe instead"))}
+	}
+	if qErr := s.checkOperation(op); qErr != nil {
+		return nil, []*errors.QueryError{s.coded(errors.CodeBadRequest, qErr)}
+	}
+	varTypes, qErr := s.variableTypes(op)
+	if qErr != nil {
+		return nil, []*errors.QueryError{s.coded(errors.CodeValidationFailed, qErr)}
+	}
+
+	p := &PreparedOperation{s: s, doc: d.doc, op: op, query: queryString, name: operationName, varTypes: varTypes}
+	if len(op.Vars) == 0 && s.res.QueryResolver.IsValid() {
+		for i, allowIntrospection := range []bool{false, true} {
+			r := &selected.Request{Doc: d.doc, Vars: map[string]interface{}{}, Schema: s.schema, AllowIntrospection: allowIntrospection}
+			sels := selected.ApplyOperation(r, s.res, op)
+			if len(r.Errs) == 0 && sels != nil {
+				p.plans[i] = sels
+			}
+		}
+	}
+	return p, nil
+}
+
+// OperationName returns the name of the prepared operation, which is empty for an anonymous operation.
+func (p *PreparedOperation) OperationName() string {
+	return p.name
+}
+
+// Exec executes the prepared operation with the variables. Only the variable values are validated, the rest of
+// the validation happened in [Schema.Prepare]. It panics if the schema was created without a resolver.
+func (p *PreparedOperation) Exec(ctx context.Context, variables map[string]interface{}) *Response {
+	s := p.s
+	if !s.res.QueryResolver.IsValid() {
+		panic("schema created without resolver, can not exec")
+	}
+
+	ctx = s.requestStart(ctx, &Request{Query: p.query, OperationName: p.name, Variables: variables})
+	s.afterParse(ctx, p.query, p.doc, nil)
+	validationFinish := s.validationTracer.TraceValidation(ctx)
+	errs := validation.ValidateVariables(s.schema, p.doc, variables)
+	validationFinish(errs)
+	errs = s.afterValidation(ctx, p.doc, errs)
+	if len(errs) != 0 {
+		return s.present(ctx, &Response{Errors: errs})
+	}
+
+	r := s.newExecRequest(ctx, p.doc, p.op, variables)
+	extensions, qErr := s.complexity(p.doc, p.op, r.Vars)
+	if qErr != nil {
+		return s.present(ctx, &Response{Errors: []*errors.QueryError{qErr}, Extensions: extensions})
+	}
+	var plan []selected.Selection
+	if r.AllowIntrospection {
+		plan = p.plans[1]
+	} else {
+		plan = p.plans[0]
+	}
+
+	ctx = s.beforeExecution(ctx, p.op, r.Vars)
+	ctx, ext := exec.WithExtensions(ctx)
+	traceCtx, finish := s.tracer.TraceQuery(ctx, p.query, p.name, r.Vars, p.varTypes)
+	data, errs := r.ExecutePlan(traceCtx, s.res, p.op, plan)
+	finish(errs)
+
+	return s.present(ctx, &Response{
+		Data:       data,
+		Errors:     errs,
+		Extensions: ext.MergeInto(extensions),
+	})
+}
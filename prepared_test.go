@@ -0,0 +1,131 @@
+package graphql_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/example/starwars"
+)
+
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	s := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{})
+	p, errs := s.Prepare(`
+		query Hero($episode: Episode = NEWHOPE, $withFriends: Boolean = false) {
+			hero(episode: $episode) {
+				name
+				friends @include(if: $withFriends) { name }
+			}
+		}
+		query Other { __typename }
+	`, "Hero")
+	if len(errs) != 0 {
+		t.Fatalf("got errors %v", errs)
+	}
+	if p.OperationName() != "Hero" {
+		t.Errorf("got operation name %q, want %q", p.OperationName(), "Hero")
+	}
+
+	tests := []struct {
+		name      string
+		variables map[string]interface{}
+		want      string
+	}{
+		{
+			name: "defaults",
+			want: `{"data":{"hero":{"name":"R2-D2"}}}`,
+		},
+		{
+			name:      "variables",
+			variables: map[string]interface{}{"episode": "EMPIRE", "withFriends": true},
+			want:      `{"data":{"hero":{"name":"Luke Skywalker","friends":[{"name":"Han Solo"},{"name":"Leia Organa"},{"name":"C-3PO"},{"name":"R2-D2"}]}}}`,
+		},
+		{
+			name:      "invalid_variables",
+			variables: map[string]interface{}{"episode": "CLONES"},
+			want:      `{"errors":[{"message":"Variable \"episode\" has invalid value CLONES.\nExpected type \"Episode\", found CLONES.","locations":[{"line":2,"column":14}]}]}`,
+		},
+	}
+	for _, tt := range tests {
+		if got := marshalResponse(t, p.Exec(context.Background(), tt.variables)); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPrepare_Errors(t *testing.T) {
+	t.Parallel()
+
+	s := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{})
+	tests := []struct {
+		name          string
+		query         string
+		operationName string
+		want          string
+	}{
+		{
+			name:  "parse_error",
+			query: `{`,
+			want:  `syntax error: unexpected "", expecting Ident`,
+		},
+		{
+			name:  "validation_error",
+			query: `{ unknown }`,
+			want:  `Cannot query field "unknown" on type "Query".`,
+		},
+		{
+			name:          "unknown_operation",
+			query:         `query A { __typename }`,
+			operationName: "B",
+			want:          `no operation with name "B"`,
+		},
+		{
+			name:  "subscription",
+			query: `subscription { __typename }`,
+			want:  `subscriptions can not be prepared, use Schema.Subscribe instead`,
+		},
+	}
+	for _, tt := range tests {
+		p, errs := s.Prepare(tt.query, tt.operationName)
+		if p != nil || len(errs) != 1 || errs[0].Message != tt.want {
+			t.Errorf("%s: got %v, want %q", tt.name, errs, tt.want)
+		}
+	}
+
+	// The variable values are validated when the operation is executed.
+	if _, errs := s.Prepare(`query($id: ID!) { character(id: $id) { name } }`, ""); len(errs) != 0 {
+		t.Errorf("got errors %v for a required variable", errs)
+	}
+}
+
+func TestPrepare_WithoutVariables(t *testing.T) {
+	t.Parallel()
+
+	type ctxKey struct{}
+	s := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.RestrictIntrospection(func(ctx context.Context) bool {
+		return ctx.Value(ctxKey{}) != nil
+	}))
+	p, errs := s.Prepare(`{ hero { name friends @skip(if: true) { name } } __type(name: "Droid") { name } }`, "")
+	if len(errs) != 0 {
+		t.Fatalf("got errors %v", errs)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(introspection bool) {
+			defer wg.Done()
+			ctx, want := context.Background(), `{"data":{"hero":{"name":"R2-D2"}}}`
+			if introspection {
+				ctx, want = context.WithValue(ctx, ctxKey{}, true), `{"data":{"hero":{"name":"R2-D2"},"__type":{"name":"Droid"}}}`
+			}
+			if got := marshalResponse(t, p.Exec(ctx, nil)); got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		}(i%2 == 0)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,139 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// Adapter moves the encoded payloads of a broker through an external message system, e.g. Redis or NATS. The
+// broker created with [NewAdapterBroker] encodes the payloads and handles the filtering, the buffering and the
+// replay, so that an adapter only has to publish and receive messages.
+type Adapter interface {
+	// Publish sends the data to the subscribers of the topic in all processes.
+	Publish(ctx context.Context, topic string, data []byte) error
+	// Subscribe calls handle with the data of every message published to the topic until ctx is done. handle is
+	// not called concurrently.
+	Subscribe(ctx context.Context, topic string, handle func(data []byte)) error
+}
+
+// Codec encodes the payloads of a broker for an [Adapter].
+type Codec interface {
+	Encode(topic string, payload interface{}) ([]byte, error)
+	Decode(topic string, data []byte) (interface{}, error)
+}
+
+// JSONCodec encodes the payloads as JSON.
+type JSONCodec struct {
+	// New returns a pointer to a new value of the payload type of the topic to decode the data into. If New is
+	// nil, the data is decoded into an interface{}.
+	New func(topic string) interface{}
+}
+
+func (c JSONCodec) Encode(topic string, payload interface{}) ([]byte, error) {
+	return json.Marshal(payload)
+}
+
+func (c JSONCodec) Decode(topic string, data []byte) (interface{}, error) {
+	if c.New == nil {
+		var payload interface{}
+		err := json.Unmarshal(data, &payload)
+		return payload, err
+	}
+	payload := c.New(topic)
+	err := json.Unmarshal(data, payload)
+	return payload, err
+}
+
+// adapterBroker subscribes to a topic once through the adapter and fans the payloads out to the subscribers in the
+// process with a memory broker.
+type adapterBroker struct {
+	adapter Adapter
+	codec   Codec
+	local   *MemoryBroker
+
+	mu     sync.Mutex
+	topics map[string]*remoteTopic
+}
+
+type remoteTopic struct {
+	subscribers int
+	cancel      context.CancelFunc
+}
+
+// NewAdapterBroker creates a broker which publishes and receives the payloads through the adapter, encoded with the
+// codec. Each topic is subscribed through the adapter once while it has subscribers in the process, and its
+// payloads are delivered to them like by a [MemoryBroker] created with the options. Payloads which can not be
+// decoded are dropped.
+func NewAdapterBroker(adapter Adapter, codec Codec, opts ...Option) Broker {
+	return &adapterBroker{
+		adapter: adapter,
+		codec:   codec,
+		local:   NewMemoryBroker(opts...),
+		topics:  make(map[string]*remoteTopic),
+	}
+}
+
+func (b *adapterBroker) Publish(ctx context.Context, topic string, payload interface{}) error {
+	data, err := b.codec.Encode(topic, payload)
+	if err != nil {
+		return err
+	}
+	return b.adapter.Publish(ctx, topic, data)
+}
+
+func (b *adapterBroker) Subscribe(ctx context.Context, topic string, filter Filter) (<-chan interface{}, error) {
+	if err := b.acquire(topic); err != nil {
+		return nil, err
+	}
+	c, err := b.local.Subscribe(ctx, topic, filter)
+	if err != nil {
+		b.release(topic)
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		b.release(topic)
+	}()
+	return c, nil
+}
+
+// acquire subscribes to the topic through the adapter unless it is subscribed already.
+func (b *adapterBroker) acquire(topic string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t := b.topics[topic]; t != nil {
+		t.subscribers++
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := b.adapter.Subscribe(ctx, topic, func(data []byte) {
+		payload, decodeErr := b.codec.Decode(topic, data)
+		if decodeErr != nil {
+			return
+		}
+		b.local.Publish(ctx, topic, payload)
+	})
+	if err != nil {
+		cancel()
+		return err
+	}
+	b.topics[topic] = &remoteTopic{subscribers: 1, cancel: cancel}
+	return nil
+}
+
+// release unsubscribes from the topic through the adapter when its last subscriber is gone.
+func (b *adapterBroker) release(topic string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := b.topics[topic]
+	if t == nil {
+		return
+	}
+	t.subscribers--
+	if t.subscribers == 0 {
+		t.cancel()
+		delete(b.topics, topic)
+	}
+}
@@ -0,0 +1,184 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBufferSize is the number of payloads buffered per subscriber unless [BufferSize] is set.
+const DefaultBufferSize = 16
+
+// Option configures a [MemoryBroker].
+type Option func(*MemoryBroker)
+
+// BufferSize sets the number of payloads buffered per subscriber. When the buffer of a subscriber is full, its
+// oldest payload is dropped, so that a slow subscriber neither blocks the publisher nor the other subscribers.
+func BufferSize(n int) Option {
+	return func(b *MemoryBroker) {
+		if n < 1 {
+			n = 1
+		}
+		b.bufferSize = n
+	}
+}
+
+// Replay keeps the last n payloads of every topic and delivers them to new subscribers before the payloads
+// published after they subscribed.
+func Replay(n int) Option {
+	return func(b *MemoryBroker) {
+		b.replay = n
+	}
+}
+
+// MemoryBroker is a [Broker] which delivers the payloads within the process. It is safe for concurrent use.
+type MemoryBroker struct {
+	bufferSize int
+	replay     int
+
+	mu     sync.Mutex
+	topics map[string]*topic
+}
+
+// topic holds the subscribers and the replayed payloads of a topic.
+type topic struct {
+	subscribers map[*subscriber]struct{}
+	history     []interface{}
+}
+
+type subscriber struct {
+	filter Filter
+
+	mu     sync.Mutex
+	c      chan interface{}
+	closed bool
+}
+
+// NewMemoryBroker creates a broker which delivers the payloads within the process.
+func NewMemoryBroker(opts ...Option) *MemoryBroker {
+	b := &MemoryBroker{
+		bufferSize: DefaultBufferSize,
+		topics:     make(map[string]*topic),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Publish delivers the payload to the subscribers of the topic whose filter accepts it. It does not wait for the
+// subscribers to receive the payload. It returns the error of ctx if ctx is done.
+func (b *MemoryBroker) Publish(ctx context.Context, topicName string, payload interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	t := b.topics[topicName]
+	if t == nil {
+		if b.replay <= 0 {
+			b.mu.Unlock()
+			return nil
+		}
+		t = b.topic(topicName)
+	}
+	if b.replay > 0 {
+		t.history = append(t.history, payload)
+		if len(t.history) > b.replay {
+			t.history = t.history[len(t.history)-b.replay:]
+		}
+	}
+	subscribers := make([]*subscriber, 0, len(t.subscribers))
+	for s := range t.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	b.mu.Unlock()
+
+	for _, s := range subscribers {
+		if s.accepts(payload) {
+			s.send(payload)
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel of the payloads published to the topic which are accepted by the filter, starting
+// with the replayed payloads. The subscription is removed and the channel is closed when ctx is done.
+func (b *MemoryBroker) Subscribe(ctx context.Context, topicName string, filter Filter) (<-chan interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s := &subscriber{filter: filter, c: make(chan interface{}, b.bufferSize)}
+
+	b.mu.Lock()
+	t := b.topic(topicName)
+	t.subscribers[s] = struct{}{}
+	// the replayed payloads are sent while the broker is locked, so that they precede the payloads published later
+	s.mu.Lock()
+	b.mu.Unlock()
+	for _, payload := range t.history {
+		if s.accepts(payload) {
+			s.push(payload)
+		}
+	}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.unsubscribe(topicName, s)
+	}()
+	return s.c, nil
+}
+
+// topic returns the topic with the name and creates it if it does not exist. The broker must be locked.
+func (b *MemoryBroker) topic(name string) *topic {
+	t := b.topics[name]
+	if t == nil {
+		t = &topic{subscribers: make(map[*subscriber]struct{})}
+		b.topics[name] = t
+	}
+	return t
+}
+
+func (b *MemoryBroker) unsubscribe(topicName string, s *subscriber) {
+	b.mu.Lock()
+	if t := b.topics[topicName]; t != nil {
+		delete(t.subscribers, s)
+		if len(t.subscribers) == 0 && len(t.history) == 0 {
+			delete(b.topics, topicName)
+		}
+	}
+	b.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	close(s.c)
+}
+
+func (s *subscriber) accepts(payload interface{}) bool {
+	return s.filter == nil || s.filter(payload)
+}
+
+func (s *subscriber) send(payload interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.push(payload)
+	}
+}
+
+// push adds the payload to the buffer and drops the oldest payload if the buffer is full. The subscriber must be
+// locked.
+func (s *subscriber) push(payload interface{}) {
+	for {
+		select {
+		case s.c <- payload:
+			return
+		default:
+		}
+		select {
+		case <-s.c:
+		default:
+		}
+	}
+}
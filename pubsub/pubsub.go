@@ -0,0 +1,70 @@
+// Package pubsub provides brokers which fan out published events to the subscribers of a topic, e.g. to feed the
+// channels returned by subscription resolvers.
+//
+// [MemoryBroker] delivers the events within the process. Brokers backed by external message systems such as Redis
+// or NATS are created with [NewAdapterBroker] from an [Adapter], which only has to move encoded payloads.
+//
+// A subscription resolver subscribes to a topic with its context, so that the subscription is cleaned up when the
+// client unsubscribes, and binds the events to the typed channel it returns:
+//
+//	func (r *resolver) PriceChanged(ctx context.Context, args struct{ Symbol string }) (<-chan *priceResolver, error) {
+//		events, err := r.broker.Subscribe(ctx, "prices", func(payload interface{}) bool {
+//			return payload.(*priceResolver).symbol == args.Symbol
+//		})
+//		if err != nil {
+//			return nil, err
+//		}
+//		var c <-chan *priceResolver
+//		return c, pubsub.Bind(ctx, events, &c)
+//	}
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Filter reports whether a payload is delivered to a subscriber. A nil Filter delivers every payload.
+type Filter func(payload interface{}) bool
+
+// Broker publishes payloads to the subscribers of topics.
+type Broker interface {
+	// Publish delivers the payload to the subscribers of the topic whose filter accepts it.
+	Publish(ctx context.Context, topic string, payload interface{}) error
+	// Subscribe returns a channel of the payloads published to the topic which are accepted by the filter. The
+	// channel is closed when ctx is done.
+	Subscribe(ctx context.Context, topic string, filter Filter) (<-chan interface{}, error)
+}
+
+// Bind forwards the payloads of events to a new channel which is stored in target, a pointer to a channel variable
+// of the type returned by a subscription resolver, e.g. *<-chan *messageResolver. Payloads which are not assignable
+// to the element type of the channel are skipped. The channel is closed when events is closed or ctx is done.
+func Bind(ctx context.Context, events <-chan interface{}, target interface{}) error {
+	ptr := reflect.ValueOf(target)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Chan || ptr.Elem().Type().ChanDir() == reflect.SendDir {
+		return fmt.Errorf("pubsub: target must be a pointer to a receivable channel, got %T", target)
+	}
+	elem := ptr.Elem().Type().Elem()
+	c := reflect.MakeChan(reflect.ChanOf(reflect.BothDir, elem), 0)
+	ptr.Elem().Set(c.Convert(ptr.Elem().Type()))
+
+	go func() {
+		defer c.Close()
+		done := reflect.ValueOf(ctx.Done())
+		for payload := range events {
+			v := reflect.ValueOf(payload)
+			if !v.IsValid() || !v.Type().AssignableTo(elem) {
+				continue
+			}
+			chosen, _, _ := reflect.Select([]reflect.SelectCase{
+				{Dir: reflect.SelectRecv, Chan: done},
+				{Dir: reflect.SelectSend, Chan: c, Send: v},
+			})
+			if chosen == 0 {
+				return
+			}
+		}
+	}()
+	return nil
+}
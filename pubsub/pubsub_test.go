@@ -0,0 +1,282 @@
+package pubsub_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/pubsub"
+)
+
+// receive returns the next n payloads of c.
+func receive(t *testing.T, c <-chan interface{}, n int) []interface{} {
+	t.Helper()
+	var got []interface{}
+	for len(got) < n {
+		select {
+		case p, ok := <-c:
+			if !ok {
+				t.Fatalf("got closed channel after %v, want %d payloads", got, n)
+			}
+			got = append(got, p)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %v, want %d payloads", got, n)
+		}
+	}
+	return got
+}
+
+// waitClosed waits until c is closed.
+func waitClosed(t *testing.T, c <-chan interface{}) {
+	t.Helper()
+	for {
+		select {
+		case _, ok := <-c:
+			if !ok {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("got open channel, want it to be closed")
+		}
+	}
+}
+
+func TestMemoryBroker(t *testing.T) {
+	ctx := context.Background()
+	b := pubsub.NewMemoryBroker()
+
+	all, err := b.Subscribe(ctx, "numbers", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	even, err := b.Subscribe(ctx, "numbers", func(payload interface{}) bool { return payload.(int)%2 == 0 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 4; i++ {
+		if err := b.Publish(ctx, "numbers", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Publish(ctx, "letters", "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := receive(t, all, 4), []interface{}{1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := receive(t, even, 2), []interface{}{2, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMemoryBroker_Buffer(t *testing.T) {
+	ctx := context.Background()
+	b := pubsub.NewMemoryBroker(pubsub.BufferSize(2))
+	c, err := b.Subscribe(ctx, "numbers", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 5; i++ {
+		if err := b.Publish(ctx, "numbers", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := receive(t, c, 2), []interface{}{4, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want the newest payloads %v", got, want)
+	}
+}
+
+func TestMemoryBroker_Replay(t *testing.T) {
+	ctx := context.Background()
+	b := pubsub.NewMemoryBroker(pubsub.Replay(2))
+	for i := 1; i <= 3; i++ {
+		if err := b.Publish(ctx, "numbers", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := b.Subscribe(ctx, "numbers", func(payload interface{}) bool { return payload.(int) != 2 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Publish(ctx, "numbers", 4); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := receive(t, c, 2), []interface{}{3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMemoryBroker_Cancel(t *testing.T) {
+	b := pubsub.NewMemoryBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+	c, err := b.Subscribe(ctx, "numbers", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	waitClosed(t, c)
+
+	if err := b.Publish(context.Background(), "numbers", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Subscribe(ctx, "numbers", nil); err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+	if err := b.Publish(ctx, "numbers", 1); err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
+
+// fakeAdapter delivers the messages within the process and records the subscriptions through it.
+type fakeAdapter struct {
+	mu       sync.Mutex
+	handlers map[string]map[*func([]byte)]struct{}
+}
+
+func (a *fakeAdapter) Publish(ctx context.Context, topic string, data []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for handle := range a.handlers[topic] {
+		(*handle)(data)
+	}
+	return nil
+}
+
+func (a *fakeAdapter) Subscribe(ctx context.Context, topic string, handle func(data []byte)) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.handlers[topic] == nil {
+		a.handlers[topic] = make(map[*func([]byte)]struct{})
+	}
+	a.handlers[topic][&handle] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		delete(a.handlers[topic], &handle)
+	}()
+	return nil
+}
+
+func (a *fakeAdapter) subscriptions(topic string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.handlers[topic])
+}
+
+type price struct {
+	Symbol string  `json:"symbol"`
+	Value  float64 `json:"value"`
+}
+
+func TestAdapterBroker(t *testing.T) {
+	adapter := &fakeAdapter{handlers: make(map[string]map[*func([]byte)]struct{})}
+	b := pubsub.NewAdapterBroker(adapter, pubsub.JSONCodec{New: func(string) interface{} { return &price{} }})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	all, err := b.Subscribe(ctx, "prices", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acme, err := b.Subscribe(ctx, "prices", func(payload interface{}) bool { return payload.(*price).Symbol == "ACME" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := adapter.subscriptions("prices"); n != 1 {
+		t.Errorf("got %d subscriptions through the adapter, want 1", n)
+	}
+
+	for _, p := range []*price{{Symbol: "ACME", Value: 1.5}, {Symbol: "INIT", Value: 2}} {
+		if err := b.Publish(ctx, "prices", p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := receive(t, all, 2); !reflect.DeepEqual(got, []interface{}{&price{"ACME", 1.5}, &price{"INIT", 2}}) {
+		t.Errorf("got %v, want both prices", got)
+	}
+	if got := receive(t, acme, 1); !reflect.DeepEqual(got, []interface{}{&price{"ACME", 1.5}}) {
+		t.Errorf("got %v, want the ACME price", got)
+	}
+
+	cancel()
+	waitClosed(t, all)
+	waitClosed(t, acme)
+	for deadline := time.Now().Add(5 * time.Second); adapter.subscriptions("prices") != 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("got a subscription through the adapter, want it to be cancelled")
+		}
+	}
+}
+
+type subscriptionResolver struct {
+	broker pubsub.Broker
+}
+
+func (r *subscriptionResolver) Hello() string { return "hello" }
+
+func (r *subscriptionResolver) PriceChanged(ctx context.Context, args struct{ Symbol string }) (<-chan *priceResolver, error) {
+	events, err := r.broker.Subscribe(ctx, "prices", func(payload interface{}) bool {
+		return payload.(*priceResolver).symbol == args.Symbol
+	})
+	if err != nil {
+		return nil, err
+	}
+	var c <-chan *priceResolver
+	return c, pubsub.Bind(ctx, events, &c)
+}
+
+type priceResolver struct {
+	symbol string
+	value  float64
+}
+
+func (p *priceResolver) Value() float64 { return p.value }
+
+func TestBind(t *testing.T) {
+	b := pubsub.NewMemoryBroker()
+	s := graphql.MustParseSchema(`
+		type Query { hello: String! }
+		type Subscription { priceChanged(symbol: String!): Price! }
+		type Price { value: Float! }
+	`, &subscriptionResolver{broker: b})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c, err := s.Subscribe(ctx, `subscription { priceChanged(symbol: "ACME") { value } }`, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the subscription is established when the resolver is called, which happens before Subscribe returns
+	for _, p := range []*priceResolver{{"INIT", 1}, {"ACME", 2}} {
+		if err := b.Publish(ctx, "prices", p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case r := <-c:
+		resp := r.(*graphql.Response)
+		if len(resp.Errors) != 0 {
+			t.Fatal(resp.Errors)
+		}
+		var data struct{ PriceChanged struct{ Value float64 } }
+		if err := json.Unmarshal(resp.Data, &data); err != nil {
+			t.Fatal(err)
+		}
+		if data.PriceChanged.Value != 2 {
+			t.Errorf("got %s, want the ACME price", resp.Data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("got no response")
+	}
+
+	var notChan *string
+	if err := pubsub.Bind(ctx, nil, &notChan); err == nil {
+		t.Error("got no error for a target which is not a channel")
+	}
+}
@@ -43,6 +43,8 @@ func UnmarshalSpec(id graphql.ID, v interface{}) error {
 	return json.Unmarshal(s[i+1:], v)
 }
 
+// Handler is a minimal http.Handler which only accepts JSON encoded POST requests.
+// New servers should use the spec compliant Handler of the transport/http package instead.
 type Handler struct {
 	Schema *graphql.Schema
 }
@@ -58,13 +60,9 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := h.Schema.Exec(r.Context(), params.Query, params.OperationName, params.Variables)
-	responseJSON, err := json.Marshal(response)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+	response := h.Schema.ExecBuffered(r.Context(), &graphql.Request{Query: params.Query, OperationName: params.OperationName, Variables: params.Variables})
+	defer response.Release()
 
 	w.Header().Set("Content-Type", "application/json")
-	w.Write(responseJSON)
+	response.WriteTo(w)
 }
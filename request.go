@@ -0,0 +1,41 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+)
+
+// Request is a GraphQL request as sent by a client. It can be decoded from the JSON body of a request directly.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    map[string]interface{} `json:"extensions"`
+	// DocumentID refers to a trusted document instead of sending the query, see [UseTrustedDocuments].
+	DocumentID string `json:"documentId,omitempty"`
+
+	internal bool // internal requests, e.g. the introspection of ToJSON, bypass the trusted documents
+}
+
+// ExecRequest executes the given request with the schema's resolver. In addition to [Schema.Exec] it evaluates the
+// request extensions, e.g. to resolve automatic persisted queries. It panics if the schema was created without a
+// resolver.
+func (s *Schema) ExecRequest(ctx context.Context, req *Request) *Response {
+	if !s.res.QueryResolver.IsValid() {
+		panic("schema created without resolver, can not exec")
+	}
+	return s.exec(ctx, req, s.res)
+}
+
+// SubscribeRequest returns a response channel for the given request with the schema's resolver. In addition to
+// [Schema.Subscribe] it evaluates the request extensions, e.g. to resolve automatic persisted queries. It returns
+// an error if the schema was created without a resolver.
+func (s *Schema) SubscribeRequest(ctx context.Context, req *Request) (<-chan interface{}, error) {
+	if !s.res.SubscriptionResolver.IsValid() {
+		return nil, errors.New("schema created without resolver, can not subscribe")
+	}
+	if _, ok := s.schema.RootOperationTypes["subscription"]; !ok {
+		return nil, errors.New("no subscriptions are offered by the schema")
+	}
+	return s.subscribe(ctx, req, s.res), nil
+}
@@ -0,0 +1,136 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/graph-gophers/graphql-go/internal/exec"
+)
+
+// ExecBuffered executes the request like [Schema.ExecRequest], but the data of the response is held in a buffer
+// from a pool shared by all executions instead of a buffer of its own. The response should be written with
+// [Response.WriteTo] and then released with [Response.Release], after which its data must not be used anymore.
+// It panics if the schema was created without a resolver.
+func (s *Schema) ExecBuffered(ctx context.Context, req *Request) *Response {
+	if !s.res.QueryResolver.IsValid() {
+		panic("schema created without resolver, can not exec")
+	}
+	out := exec.GetBuffer()
+	resp := s.execTo(ctx, req, s.res, out)
+	if resp.Data == nil {
+		exec.PutBuffer(out)
+		return resp
+	}
+	resp.buf = out
+	return resp
+}
+
+// ExecTo executes the request and writes the JSON encoded response to w. The data is written from the pooled
+// buffer it was executed into, see [Schema.ExecBuffered], so it is neither copied nor encoded again. It returns the
+// error of w. It panics if the schema was created without a resolver.
+func (s *Schema) ExecTo(ctx context.Context, w io.Writer, req *Request) error {
+	resp := s.ExecBuffered(ctx, req)
+	defer resp.Release()
+	_, err := resp.WriteTo(w)
+	return err
+}
+
+// Release returns the buffer holding the data of a response of [Schema.ExecBuffered] to the pool and clears the
+// data. It does nothing for other responses.
+func (r *Response) Release() {
+	if r.buf == nil {
+		return
+	}
+	exec.PutBuffer(r.buf)
+	r.buf = nil
+	r.Data = nil
+}
+
+// WriteTo writes the JSON encoding of the response to w. The result is the same as the one of json.Marshal, but
+// the data is written as is instead of being validated and copied first.
+func (r *Response) WriteTo(w io.Writer) (int64, error) {
+	if r.encoded != nil {
+		n, err := w.Write(r.encoded)
+		return int64(n), err
+	}
+	rw := &responseWriter{w: w}
+	if len(r.Errors) != 0 {
+		rw.marshal("errors", r.Errors)
+	}
+	if len(r.Data) != 0 {
+		rw.member("data", r.Data)
+	}
+	if len(r.Incremental) != 0 {
+		rw.marshal("incremental", r.Incremental)
+	}
+	if r.HasNext != nil {
+		rw.marshal("hasNext", *r.HasNext)
+	}
+	if len(r.Extensions) != 0 {
+		rw.marshal("extensions", r.Extensions)
+	}
+	if rw.n == 0 {
+		rw.write([]byte("{"))
+	}
+	rw.write([]byte("}"))
+	return rw.n, rw.err
+}
+
+// MarshalJSON returns the JSON encoding of the response. The response of an event of multiplexed subscriptions,
+// see [MultiplexSubscriptions], is encoded once for all of its subscribers.
+func (r *Response) MarshalJSON() ([]byte, error) {
+	if r.encoded != nil {
+		return r.encoded, nil
+	}
+	return json.Marshal((*response)(r))
+}
+
+// response has the fields of Response without its methods, so that it is encoded with the default encoding.
+type response Response
+
+// encode caches the JSON encoding of the response.
+func (r *Response) encode() {
+	if b, err := json.Marshal((*response)(r)); err == nil {
+		r.encoded = b
+	}
+}
+
+// responseWriter writes the members of a JSON object and keeps the first error.
+type responseWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (rw *responseWriter) write(b []byte) {
+	if rw.err != nil {
+		return
+	}
+	n, err := rw.w.Write(b)
+	rw.n += int64(n)
+	rw.err = err
+}
+
+func (rw *responseWriter) member(name string, value []byte) {
+	if rw.n == 0 {
+		rw.write([]byte(`{"`))
+	} else {
+		rw.write([]byte(`,"`))
+	}
+	rw.write([]byte(name))
+	rw.write([]byte(`":`))
+	rw.write(value)
+}
+
+func (rw *responseWriter) marshal(name string, v interface{}) {
+	if rw.err != nil {
+		return
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		rw.err = err
+		return
+	}
+	rw.member(name, value)
+}
@@ -0,0 +1,127 @@
+package graphql_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+const responseExtensionsSchema = graphql.CostDirective + `
+	type Query {
+		items: [Item!]!
+	}
+
+	type Item {
+		id: Int!
+		name: String!
+	}
+
+	type Subscription {
+		counted: Count!
+	}
+
+	type Count {
+		n: Int!
+	}
+`
+
+type responseExtensionsResolver struct{}
+
+func (responseExtensionsResolver) Items(ctx context.Context) []*responseExtensionsItem {
+	graphql.AddExtension(ctx, "cost", "overridden")
+	graphql.AddWarning(ctx, "items is deprecated")
+	items := make([]*responseExtensionsItem, 10)
+	for i := range items {
+		items[i] = &responseExtensionsItem{id: int32(i)}
+	}
+	return items
+}
+
+type responseExtensionsItem struct {
+	id int32
+}
+
+func (i *responseExtensionsItem) ID(ctx context.Context) int32 {
+	graphql.AddExtension(ctx, fmt.Sprintf("item%d", i.id), i.id)
+	return i.id
+}
+
+// Name is resolved concurrently, as it returns an error.
+func (i *responseExtensionsItem) Name(ctx context.Context) (string, error) {
+	graphql.AddWarning(ctx, "slow name")
+	return "item", nil
+}
+
+func TestAddExtension(t *testing.T) {
+	s := graphql.MustParseSchema(responseExtensionsSchema, &responseExtensionsResolver{}, graphql.MaxComplexity(100))
+
+	resp := s.Exec(context.Background(), `{ items { id name } }`, "", nil)
+	if len(resp.Errors) != 0 {
+		t.Fatal(resp.Errors)
+	}
+	for i := int32(0); i < 10; i++ {
+		if v := resp.Extensions[fmt.Sprintf("item%d", i)]; v != i {
+			t.Errorf("got extension item%d %v, want %d", i, v, i)
+		}
+	}
+	if _, ok := resp.Extensions["cost"].(map[string]interface{}); !ok {
+		t.Errorf("got cost %v, want the cost of the operation", resp.Extensions["cost"])
+	}
+	warnings, _ := resp.Extensions["warnings"].([]string)
+	if len(warnings) != 11 || warnings[0] != "items is deprecated" {
+		t.Errorf("got warnings %q", warnings)
+	}
+
+	if graphql.AddExtension(context.Background(), "key", "value") || graphql.AddWarning(context.Background(), "warning") {
+		t.Error("got extensions added outside of an operation")
+	}
+}
+
+func TestAddExtension_Subscription(t *testing.T) {
+	s := graphql.MustParseSchema(responseExtensionsSchema, &responseExtensionsResolver{})
+
+	c, err := s.Subscribe(context.Background(), `subscription { counted { n } }`, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]interface{}
+	for resp := range c {
+		got = append(got, resp.(*graphql.Response).Extensions)
+	}
+	want := []map[string]interface{}{
+		{"source": true, "event": int32(1)},
+		{"event": int32(2)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got extensions %v, want %v", got, want)
+	}
+}
+
+// Counted adds an extension when the subscription starts and for each event.
+func (responseExtensionsResolver) Counted(ctx context.Context) <-chan *countedEvent {
+	graphql.AddExtension(ctx, "source", true)
+	c := make(chan *countedEvent)
+	go func() {
+		defer close(c)
+		for i := int32(1); i <= 2; i++ {
+			select {
+			case c <- &countedEvent{i}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c
+}
+
+type countedEvent struct {
+	n int32
+}
+
+func (e *countedEvent) N(ctx context.Context) int32 {
+	graphql.AddExtension(ctx, "event", e.n)
+	return e.n
+}
@@ -0,0 +1,188 @@
+package graphql_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+func TestResponse_WriteTo(t *testing.T) {
+	hasNext := false
+	tests := []struct {
+		name string
+		resp *graphql.Response
+	}{
+		{name: "empty", resp: &graphql.Response{}},
+		{name: "data", resp: &graphql.Response{Data: json.RawMessage(`{"hello":"world"}`)}},
+		{
+			name: "all",
+			resp: &graphql.Response{
+				Errors:      []*qerrors.QueryError{{Message: "<failed>", Path: []interface{}{"hello"}}},
+				Data:        json.RawMessage(`{"hello":null}`),
+				Incremental: []*graphql.IncrementalResult{{Data: json.RawMessage(`{"a":1}`), Path: []interface{}{}}},
+				HasNext:     &hasNext,
+				Extensions:  map[string]interface{}{"cost": 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := json.Marshal(tt.resp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			n, err := tt.resp.WriteTo(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != string(want) || n != int64(len(want)) {
+				t.Errorf("got %s (%d bytes), want %s", buf.String(), n, want)
+			}
+		})
+	}
+}
+
+const nullPropagationSchema = `
+	type Query {
+		items: [Item!]!
+		optionalItems: [Item!]
+		item: Item
+	}
+
+	type Item {
+		id: Int!
+		name: String!
+		label: Label!
+	}
+
+	type Label {
+		text: String!
+	}
+`
+
+type nullPropagationQuery struct{}
+
+func (nullPropagationQuery) Items() []*nullPropagationItem {
+	items := make([]*nullPropagationItem, 5)
+	for i := range items {
+		items[i] = &nullPropagationItem{id: int32(i)}
+	}
+	return items
+}
+
+func (q nullPropagationQuery) OptionalItems() *[]*nullPropagationItem {
+	items := q.Items()
+	return &items
+}
+
+func (nullPropagationQuery) Item() *nullPropagationItem {
+	return &nullPropagationItem{id: 3}
+}
+
+// nullPropagationItem fails to resolve the item 3. Name is resolved concurrently, as it returns an error, while
+// Label is resolved synchronously.
+type nullPropagationItem struct {
+	id int32
+}
+
+func (i *nullPropagationItem) ID() int32 { return i.id }
+
+func (i *nullPropagationItem) Name() (string, error) {
+	if i.id == 3 {
+		return "", errors.New("no name")
+	}
+	return fmt.Sprintf("item %d", i.id), nil
+}
+
+func (i *nullPropagationItem) Label() *nullPropagationLabel {
+	if i.id == 3 {
+		return nil
+	}
+	return &nullPropagationLabel{fmt.Sprintf("label %d", i.id)}
+}
+
+type nullPropagationLabel struct {
+	text string
+}
+
+func (l *nullPropagationLabel) Text() string { return l.text }
+
+func TestExecTo(t *testing.T) {
+	s := graphql.MustParseSchema(nullPropagationSchema, &nullPropagationQuery{})
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "complete", query: `{ items { id } }`},
+		{name: "null_list_item", query: `{ items { id label { text } } }`},
+		{name: "null_list_item_async", query: `{ items { id name } }`},
+		{name: "nullable_list", query: `{ optionalItems { id label { text } } item { id } }`},
+		{name: "nullable_list_async", query: `{ optionalItems { name } item { id } }`},
+		{name: "nullable_object", query: `{ a: item { id label { text } } b: item { id name } c: optionalItems { id } }`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := json.Marshal(s.Exec(context.Background(), tt.query, "", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := s.ExecTo(context.Background(), &buf, &graphql.Request{Query: tt.query}); err != nil {
+				t.Fatal(err)
+			}
+			if !json.Valid(buf.Bytes()) {
+				t.Fatalf("got invalid JSON %s", buf.String())
+			}
+			if got := sortErrors(t, buf.Bytes()); got != sortErrors(t, want) {
+				t.Errorf("got %s, want %s", buf.String(), want)
+			}
+		})
+	}
+
+	t.Run("null_propagation", func(t *testing.T) {
+		resp := s.ExecBuffered(context.Background(), &graphql.Request{Query: `{ a: item { id label { text } } b: optionalItems { id } c: optionalItems { name } }`})
+		want := `{"a":null,"b":[{"id":0},{"id":1},{"id":2},{"id":3},{"id":4}],"c":null}`
+		if string(resp.Data) != want {
+			t.Errorf("got data %s, want %s", resp.Data, want)
+		}
+		resp.Release()
+		if resp.Data != nil {
+			t.Errorf("got data %s after release", resp.Data)
+		}
+	})
+}
+
+// sortErrors returns the response with its errors sorted by message, since concurrent resolvers may fail in any
+// order.
+func sortErrors(t *testing.T, resp []byte) string {
+	t.Helper()
+	var r struct {
+		Errors []struct {
+			Message string        `json:"message"`
+			Path    []interface{} `json:"path"`
+		} `json:"errors"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &r); err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(r.Errors, func(i, j int) bool { return r.Errors[i].Message < r.Errors[j].Message })
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
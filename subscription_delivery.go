@@ -0,0 +1,165 @@
+package graphql
+
+import (
+	"context"
+	"time"
+
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/trace/tracer"
+)
+
+// DeliveryPolicy decides what happens to the events of a subscription which its consumer does not receive in time,
+// e.g. because the client is slow.
+type DeliveryPolicy int
+
+const (
+	// DropNewest drops an event which the consumer does not receive within the delivery timeout, while the buffer
+	// of the subscription is full. It is the default policy.
+	DropNewest DeliveryPolicy = iota
+	// Block waits until the consumer receives an event or the subscription ends. No events are dropped, but the
+	// following events are not resolved while the consumer does not receive them.
+	Block
+	// DropOldest drops the oldest buffered event when the buffer of the subscription is full. The buffer holds at
+	// least one event.
+	DropOldest
+	// CloseWithError ends the subscription with an error response if the consumer does not receive an event
+	// within the delivery timeout. The error response is given up as well if the consumer does not receive it
+	// within another delivery timeout.
+	CloseWithError
+)
+
+// SubscribeDeliveryPolicy sets the policy and the size of the buffer of the response channels of subscriptions. The
+// default is [DropNewest] without a buffer. It can be overridden per subscription with [WithDeliveryPolicy].
+// Dropped events are reported to tracers which implement [tracer.DeliveryTracer].
+func SubscribeDeliveryPolicy(policy DeliveryPolicy, bufferSize int) SchemaOpt {
+	return func(s *Schema) {
+		s.deliveryPolicy = policy
+		s.deliveryBufferSize = bufferSize
+	}
+}
+
+// SubscribeDeliveryTimeout sets how long the consumer of a subscription has to receive an event before the
+// [DeliveryPolicy] drops it or closes the subscription. It defaults to the [SubscribeResolverTimeout], which only
+// limits the resolution of an event.
+func SubscribeDeliveryTimeout(timeout time.Duration) SchemaOpt {
+	return func(s *Schema) {
+		s.deliveryTimeout = timeout
+	}
+}
+
+type deliveryPolicyKey struct{}
+
+type deliveryPolicyValue struct {
+	policy     DeliveryPolicy
+	bufferSize int
+}
+
+// WithDeliveryPolicy returns a context which sets the policy and the size of the buffer of the response channel of
+// the subscription started with it, overriding the [SubscribeDeliveryPolicy] of the schema.
+func WithDeliveryPolicy(ctx context.Context, policy DeliveryPolicy, bufferSize int) context.Context {
+	return context.WithValue(ctx, deliveryPolicyKey{}, deliveryPolicyValue{policy: policy, bufferSize: bufferSize})
+}
+
+// delivery delivers the responses of a subscription to its consumer according to the delivery policy.
+type delivery struct {
+	policy     DeliveryPolicy
+	bufferSize int
+	timeout    time.Duration
+	tracer     tracer.DeliveryTracer
+	dropped    int
+}
+
+func (s *Schema) delivery(ctx context.Context) *delivery {
+	d := &delivery{
+		policy:     s.deliveryPolicy,
+		bufferSize: s.deliveryBufferSize,
+		timeout:    s.deliveryTimeout,
+	}
+	if v, ok := ctx.Value(deliveryPolicyKey{}).(deliveryPolicyValue); ok {
+		d.policy, d.bufferSize = v.policy, v.bufferSize
+	}
+	if d.policy == DropOldest && d.bufferSize < 1 {
+		d.bufferSize = 1
+	}
+	if d.bufferSize < 0 {
+		d.bufferSize = 0
+	}
+	if d.timeout == 0 {
+		d.timeout = s.subscribeResolverTimeout
+	}
+	if d.timeout == 0 {
+		d.timeout = time.Second
+	}
+	d.tracer, _ = s.tracer.(tracer.DeliveryTracer)
+	return d
+}
+
+// deliver sends the response to c. It reports false if the subscription has ended, either because the context is
+// done or because the subscription was closed with an error. Dropped events are traced with ctx, which carries the
+// trace of the subscription.
+func (d *delivery) deliver(ctx context.Context, c chan interface{}, resp *Response) bool {
+	select {
+	case c <- resp:
+		return true
+	case <-ctx.Done():
+		return false
+	default:
+	}
+
+	switch d.policy {
+	case Block:
+		select {
+		case c <- resp:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+
+	case DropOldest:
+		for {
+			select {
+			case c <- resp:
+				return true
+			case <-ctx.Done():
+				return false
+			default:
+			}
+			select {
+			case <-c:
+				d.drop(ctx)
+			default:
+			}
+		}
+
+	default:
+		timer := time.NewTimer(d.timeout)
+		defer timer.Stop()
+		select {
+		case c <- resp:
+			return true
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+		}
+		d.drop(ctx)
+		if d.policy != CloseWithError {
+			return true
+		}
+		// the consumer is too slow, so the final error is given up after another timeout
+		err := qerrors.Timeout("subscription closed: the event was not received within %s", d.timeout)
+		timer.Reset(d.timeout)
+		select {
+		case c <- &Response{Errors: []*qerrors.QueryError{err}}:
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		return false
+	}
+}
+
+func (d *delivery) drop(ctx context.Context) {
+	d.dropped++
+	if d.tracer != nil {
+		d.tracer.TraceDroppedEvent(ctx, d.dropped)
+	}
+}
@@ -0,0 +1,285 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/introspection"
+	"github.com/graph-gophers/graphql-go/trace/tracer"
+)
+
+const deliverySchema = `
+	type Query { hello: String! }
+	type Subscription { ticks: Tick! }
+	type Tick { n: Int! }
+`
+
+type deliveryResolver struct {
+	events int
+}
+
+func (r *deliveryResolver) Hello() string { return "hello" }
+
+func (r *deliveryResolver) Ticks() <-chan *tick {
+	c := make(chan *tick, r.events)
+	for i := 1; i <= r.events; i++ {
+		c <- &tick{n: int32(i)}
+	}
+	close(c)
+	return c
+}
+
+type tick struct {
+	n int32
+}
+
+func (t *tick) N() int32 { return t.n }
+
+type deliveryTracer struct {
+	mu      sync.Mutex
+	dropped int
+}
+
+func (t *deliveryTracer) TraceQuery(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, varTypes map[string]*introspection.Type) (context.Context, func([]*qerrors.QueryError)) {
+	return ctx, func([]*qerrors.QueryError) {}
+}
+
+func (t *deliveryTracer) TraceField(ctx context.Context, label, typeName, fieldName string, trivial bool, args map[string]interface{}) (context.Context, func(*qerrors.QueryError)) {
+	return ctx, func(*qerrors.QueryError) {}
+}
+
+func (t *deliveryTracer) TraceDroppedEvent(ctx context.Context, dropped int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dropped = dropped
+}
+
+// waitDropped waits until n events were dropped.
+func (t *deliveryTracer) waitDropped(tb testing.TB, n int) {
+	tb.Helper()
+	var dropped int
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		t.mu.Lock()
+		dropped = t.dropped
+		t.mu.Unlock()
+		if dropped == n {
+			return
+		}
+	}
+	tb.Fatalf("got %d dropped events, want %d", dropped, n)
+}
+
+var _ tracer.DeliveryTracer = (*deliveryTracer)(nil)
+
+func TestSubscribeDeliveryPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []graphql.SchemaOpt
+		ctx     func(context.Context) context.Context
+		dropped int
+		want    []int32
+		wantErr bool
+	}{
+		{
+			name:    "drop_newest",
+			opts:    []graphql.SchemaOpt{graphql.SubscribeDeliveryPolicy(graphql.DropNewest, 1), graphql.SubscribeDeliveryTimeout(10 * time.Millisecond)},
+			dropped: 4,
+			want:    []int32{1},
+		},
+		{
+			name:    "drop_oldest",
+			opts:    []graphql.SchemaOpt{graphql.SubscribeDeliveryPolicy(graphql.DropOldest, 2)},
+			dropped: 3,
+			want:    []int32{4, 5},
+		},
+		{
+			name:    "close_with_error",
+			opts:    []graphql.SchemaOpt{graphql.SubscribeDeliveryPolicy(graphql.CloseWithError, 1), graphql.SubscribeDeliveryTimeout(10 * time.Millisecond)},
+			dropped: 1,
+			want:    []int32{1},
+			wantErr: true,
+		},
+		{
+			name: "block_per_subscription",
+			opts: []graphql.SchemaOpt{graphql.SubscribeDeliveryTimeout(time.Nanosecond)},
+			ctx: func(ctx context.Context) context.Context {
+				return graphql.WithDeliveryPolicy(ctx, graphql.Block, 0)
+			},
+			want: []int32{1, 2, 3, 4, 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &deliveryTracer{}
+			s := graphql.MustParseSchema(deliverySchema, &deliveryResolver{events: 5}, append(tt.opts, graphql.Tracer(tr))...)
+			ctx := context.Background()
+			if tt.ctx != nil {
+				ctx = tt.ctx(ctx)
+			}
+			c, err := s.Subscribe(ctx, `subscription { ticks { n } }`, "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.dropped > 0 {
+				tr.waitDropped(t, tt.dropped)
+			} else {
+				time.Sleep(20 * time.Millisecond)
+			}
+
+			var got []int32
+			var errs []*qerrors.QueryError
+			for r := range c {
+				resp := r.(*graphql.Response)
+				errs = append(errs, resp.Errors...)
+				if resp.Data == nil {
+					continue
+				}
+				var data struct {
+					Ticks struct{ N int32 }
+				}
+				if err := json.Unmarshal(resp.Data, &data); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, data.Ticks.N)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got events %v, want %v", got, tt.want)
+			}
+			if tt.wantErr != (len(errs) == 1 && errs[0].Code() == qerrors.CodeTimeout) {
+				t.Errorf("got errors %v, want error %v", errs, tt.wantErr)
+			}
+			tr.mu.Lock()
+			defer tr.mu.Unlock()
+			if tr.dropped != tt.dropped {
+				t.Errorf("got %d dropped events, want %d", tr.dropped, tt.dropped)
+			}
+		})
+	}
+}
+
+type endlessResolver struct {
+	stopped chan struct{}
+}
+
+func (r *endlessResolver) Hello() string { return "hello" }
+
+func (r *endlessResolver) Ticks(ctx context.Context) <-chan *tick {
+	c := make(chan *tick)
+	go func() {
+		defer close(r.stopped)
+		for i := int32(1); ; i++ {
+			select {
+			case c <- &tick{n: i}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c
+}
+
+func TestSubscribeDeliveryPolicy_CancelsResolver(t *testing.T) {
+	tr := &deliveryTracer{}
+	r := &endlessResolver{stopped: make(chan struct{})}
+	s := graphql.MustParseSchema(deliverySchema, r,
+		graphql.SubscribeDeliveryPolicy(graphql.CloseWithError, 1),
+		graphql.SubscribeDeliveryTimeout(10*time.Millisecond),
+		graphql.Tracer(tr),
+	)
+	c, err := s.Subscribe(context.Background(), `subscription { ticks { n } }`, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.waitDropped(t, 1)
+	waitClosed(t, c)
+
+	select {
+	case <-r.stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("got a running resolver, want its context to be cancelled when the subscription is closed")
+	}
+}
+
+func TestSubscribeDeliveryPolicy_CloseWithErrorUnreceived(t *testing.T) {
+	r := &endlessResolver{stopped: make(chan struct{})}
+	s := graphql.MustParseSchema(deliverySchema, r,
+		graphql.SubscribeDeliveryPolicy(graphql.CloseWithError, 0),
+		graphql.SubscribeDeliveryTimeout(10*time.Millisecond),
+	)
+	// the consumer never receives anything and the context is never cancelled
+	if _, err := s.Subscribe(context.Background(), `subscription { ticks { n } }`, "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-r.stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("got a running subscription, want it to be closed without the error response being received")
+	}
+}
+
+type subscriptionSpanKey struct{}
+
+// spanDeliveryTracer counts the dropped events which are traced outside of the trace of their subscription.
+type spanDeliveryTracer struct {
+	deliveryTracer
+	untraced int
+}
+
+func (t *spanDeliveryTracer) TraceSubscription(ctx context.Context, operationName string, variables map[string]interface{}) (context.Context, func(tracer.SubscriptionCloseReason, *qerrors.QueryError)) {
+	return context.WithValue(ctx, subscriptionSpanKey{}, operationName), func(tracer.SubscriptionCloseReason, *qerrors.QueryError) {}
+}
+
+func (t *spanDeliveryTracer) TraceSubscriptionEvent(ctx context.Context, fieldName string) (context.Context, func([]*qerrors.QueryError)) {
+	return ctx, func([]*qerrors.QueryError) {}
+}
+
+func (t *spanDeliveryTracer) TraceDroppedEvent(ctx context.Context, dropped int) {
+	if ctx.Value(subscriptionSpanKey{}) == nil {
+		t.mu.Lock()
+		t.untraced++
+		t.mu.Unlock()
+	}
+	t.deliveryTracer.TraceDroppedEvent(ctx, dropped)
+}
+
+func TestSubscribeDeliveryPolicy_TraceContext(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []graphql.SchemaOpt
+	}{
+		{
+			name: "single",
+		},
+		{
+			name: "multiplexed",
+			opts: []graphql.SchemaOpt{graphql.MultiplexSubscriptions(func(context.Context) (string, bool) { return "", true })},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &spanDeliveryTracer{}
+			s := graphql.MustParseSchema(deliverySchema, &deliveryResolver{events: 2}, append(tt.opts, graphql.SubscribeDeliveryTimeout(10*time.Millisecond), graphql.Tracer(tr))...)
+			c, err := s.Subscribe(context.Background(), `subscription Ticks { ticks { n } }`, "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tr.waitDropped(t, 2)
+			waitClosed(t, c)
+
+			tr.mu.Lock()
+			defer tr.mu.Unlock()
+			if tr.untraced != 0 {
+				t.Errorf("got %d dropped events outside of the trace of the subscription, want 0", tr.untraced)
+			}
+		})
+	}
+}
+
+var _ tracer.SubscriptionTracer = (*spanDeliveryTracer)(nil)
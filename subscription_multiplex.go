@@ -0,0 +1,297 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/ast"
+)
+
+// MultiplexSubscriptions shares the execution of identical subscriptions. Subscriptions with the same normalized
+// document, the same variables and the same key share one call of the subscription resolver and one execution per
+// event, and the response of each event is encoded once and delivered to all of them. key returns the key of the
+// subscription started with ctx, which must identify everything the resolvers read from the context, e.g. the
+// user. Subscriptions for which key reports false are not shared.
+//
+// The shared execution runs with the values of the context of the subscription which started it, but it is only
+// cancelled when all of its subscriptions are. Each subscription receives the responses with its own
+// [DeliveryPolicy], and a subscription which does not receive them holds back the others until its delivery
+// policy drops the event. The shared responses must not be modified.
+func MultiplexSubscriptions(key func(ctx context.Context) (string, bool)) SchemaOpt {
+	return func(s *Schema) {
+		s.multiplexer = &multiplexer{key: key, streams: make(map[string]*sharedStream)}
+	}
+}
+
+// multiplexer holds the shared executions of the multiplexed subscriptions of a schema.
+type multiplexer struct {
+	key func(ctx context.Context) (string, bool)
+
+	mu      sync.Mutex
+	streams map[string]*sharedStream
+}
+
+// sharedStream is an execution of a subscription shared by its subscribers.
+type sharedStream struct {
+	key         string
+	cancel      context.CancelFunc
+	subscribers map[*streamSubscriber]struct{} // guarded by multiplexer.mu
+}
+
+// streamSubscriber receives the responses of a shared stream and delivers them to the consumer of its subscription.
+type streamSubscriber struct {
+	in   chan sharedResponse // closed when the stream ends
+	left chan struct{}       // closed when the subscriber stops receiving
+}
+
+// sharedResponse is a response of a shared stream with the context of its traced execution.
+type sharedResponse struct {
+	ctx  context.Context
+	resp *Response
+}
+
+// join subscribes to the shared stream of the operation and starts the stream with exec if it is not running yet.
+// It reports false if the subscription is not shared.
+func (m *multiplexer) join(ctx context.Context, dl *delivery, doc *ast.ExecutableDefinition, op *ast.OperationDefinition, variables map[string]interface{}, exec func(ctx context.Context) func(deliver func(context.Context, *Response) bool)) (<-chan interface{}, bool) {
+	key, ok := m.key(ctx)
+	if !ok {
+		return nil, false
+	}
+	vars, err := json.Marshal(variables)
+	if err != nil {
+		return nil, false
+	}
+	key = strings.Join([]string{key, normalizeOperation(doc, op), string(vars)}, "\x00")
+
+	sub := &streamSubscriber{in: make(chan sharedResponse), left: make(chan struct{})}
+	m.mu.Lock()
+	st := m.streams[key]
+	var streamCtx context.Context
+	if st == nil {
+		var cancel context.CancelFunc
+		streamCtx, cancel = context.WithCancel(detachedContext{ctx})
+		st = &sharedStream{key: key, cancel: cancel, subscribers: make(map[*streamSubscriber]struct{})}
+		m.streams[key] = st
+	}
+	st.subscribers[sub] = struct{}{}
+	m.mu.Unlock()
+
+	// the stream is executed after its first subscriber is added, so that the subscriber receives the first event
+	if streamCtx != nil {
+		run := exec(streamCtx)
+		go func() {
+			run(func(ctx context.Context, resp *Response) bool {
+				m.fanOut(st, sharedResponse{ctx: ctx, resp: resp})
+				return true
+			})
+			m.end(st)
+		}()
+	}
+
+	c := make(chan interface{}, dl.bufferSize)
+	go func() {
+		defer close(c)
+		defer m.leave(st, sub)
+		for {
+			select {
+			case shared, ok := <-sub.in:
+				// the subscriber is cancelled with its own context, but dropped events are traced in the stream
+				if !ok || !dl.deliver(tracedContext{Context: ctx, trace: shared.ctx}, c, shared.resp) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c, true
+}
+
+// fanOut encodes the response once and passes it to every subscriber of the stream.
+func (m *multiplexer) fanOut(st *sharedStream, resp sharedResponse) {
+	resp.resp.encode()
+	m.mu.Lock()
+	subscribers := make([]*streamSubscriber, 0, len(st.subscribers))
+	for sub := range st.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	m.mu.Unlock()
+
+	for _, sub := range subscribers {
+		select {
+		case sub.in <- resp:
+		case <-sub.left:
+		}
+	}
+}
+
+// end removes the stream after its execution has ended and closes its subscriptions.
+func (m *multiplexer) end(st *sharedStream) {
+	m.mu.Lock()
+	if m.streams[st.key] == st {
+		delete(m.streams, st.key)
+	}
+	subscribers := st.subscribers
+	st.subscribers = nil
+	m.mu.Unlock()
+
+	st.cancel()
+	for sub := range subscribers {
+		close(sub.in)
+	}
+}
+
+// leave removes the subscriber from the stream and cancels the stream when its last subscriber has left.
+func (m *multiplexer) leave(st *sharedStream, sub *streamSubscriber) {
+	close(sub.left)
+	m.mu.Lock()
+	if _, ok := st.subscribers[sub]; !ok {
+		m.mu.Unlock()
+		return
+	}
+	delete(st.subscribers, sub)
+	last := len(st.subscribers) == 0
+	if last && m.streams[st.key] == st {
+		delete(m.streams, st.key)
+	}
+	m.mu.Unlock()
+
+	if last {
+		st.cancel()
+	}
+}
+
+// detachedContext carries the values of its parent, but neither its deadline nor its cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// tracedContext is the context of a subscriber of a shared stream, which carries the values of the traced execution
+// of the stream before its own.
+type tracedContext struct {
+	context.Context
+	trace context.Context
+}
+
+func (c tracedContext) Value(key interface{}) interface{} {
+	if v := c.trace.Value(key); v != nil {
+		return v
+	}
+	return c.Context.Value(key)
+}
+
+// normalizeOperation prints the operation and the fragments of the document in a canonical form, which ignores
+// the operation name, whitespace, comments and the order of arguments.
+func normalizeOperation(doc *ast.ExecutableDefinition, op *ast.OperationDefinition) string {
+	var b strings.Builder
+	b.WriteString(string(op.Type))
+	if len(op.Vars) != 0 {
+		b.WriteByte('(')
+		for i, v := range op.Vars {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString("$" + v.Name.Name + ":")
+			writeType(&b, v.Type)
+			if v.Default != nil {
+				b.WriteString("=" + v.Default.String())
+			}
+			writeDirectives(&b, v.Directives)
+		}
+		b.WriteByte(')')
+	}
+	writeDirectives(&b, op.Directives)
+	writeSelections(&b, op.Selections)
+
+	fragments := make([]*ast.FragmentDefinition, len(doc.Fragments))
+	copy(fragments, doc.Fragments)
+	sort.Slice(fragments, func(i, j int) bool { return fragments[i].Name.Name < fragments[j].Name.Name })
+	for _, f := range fragments {
+		b.WriteString(" fragment " + f.Name.Name + " on " + f.On.Name)
+		writeDirectives(&b, f.Directives)
+		writeSelections(&b, f.Selections)
+	}
+	return b.String()
+}
+
+func writeType(b *strings.Builder, t ast.Type) {
+	switch t := t.(type) {
+	case *ast.NonNull:
+		writeType(b, t.OfType)
+		b.WriteByte('!')
+	case *ast.List:
+		b.WriteByte('[')
+		writeType(b, t.OfType)
+		b.WriteByte(']')
+	case *ast.TypeName:
+		b.WriteString(t.Name)
+	default:
+		b.WriteString(t.String())
+	}
+}
+
+func writeSelections(b *strings.Builder, sels ast.SelectionSet) {
+	if len(sels) == 0 {
+		return
+	}
+	b.WriteByte('{')
+	for i, sel := range sels {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if sel.Alias.Name != "" && sel.Alias.Name != sel.Name.Name {
+				b.WriteString(sel.Alias.Name + ":")
+			}
+			b.WriteString(sel.Name.Name)
+			writeArguments(b, sel.Arguments)
+			writeDirectives(b, sel.Directives)
+			writeSelections(b, sel.SelectionSet)
+		case *ast.InlineFragment:
+			b.WriteString("...")
+			if sel.On.Name != "" {
+				b.WriteString(" on " + sel.On.Name)
+			}
+			writeDirectives(b, sel.Directives)
+			writeSelections(b, sel.Selections)
+		case *ast.FragmentSpread:
+			b.WriteString("..." + sel.Name.Name)
+			writeDirectives(b, sel.Directives)
+		}
+	}
+	b.WriteByte('}')
+}
+
+func writeDirectives(b *strings.Builder, directives ast.DirectiveList) {
+	for _, d := range directives {
+		b.WriteString("@" + d.Name.Name)
+		writeArguments(b, d.Arguments)
+	}
+}
+
+func writeArguments(b *strings.Builder, args ast.ArgumentList) {
+	if len(args) == 0 {
+		return
+	}
+	sorted := make(ast.ArgumentList, len(args))
+	copy(sorted, args)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name.Name < sorted[j].Name.Name })
+	b.WriteByte('(')
+	for i, arg := range sorted {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(arg.Name.Name + ":" + arg.Value.String())
+	}
+	b.WriteByte(')')
+}
@@ -0,0 +1,229 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+const multiplexSchema = `
+	type Query { hello: String! }
+	type Subscription { onPriceChange(symbol: String!, currency: String = "USD"): Price! }
+	type Price { value: Float! }
+`
+
+type multiplexResolver struct {
+	mu       sync.Mutex
+	streams  map[string][]*priceStream
+	resolved int
+}
+
+type priceStream struct {
+	ctx context.Context
+	c   chan *multiplexPrice
+}
+
+func (r *multiplexResolver) Hello() string { return "hello" }
+
+func (r *multiplexResolver) OnPriceChange(ctx context.Context, args struct{ Symbol, Currency string }) <-chan *multiplexPrice {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := &priceStream{ctx: ctx, c: make(chan *multiplexPrice)}
+	r.streams[args.Symbol] = append(r.streams[args.Symbol], s)
+	return s.c
+}
+
+// subscribed returns the streams which the resolver returned for the symbol.
+func (r *multiplexResolver) subscribed(symbol string) []*priceStream {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.streams[symbol]
+}
+
+// publish sends a price to every running stream of the symbol.
+func (r *multiplexResolver) publish(t *testing.T, symbol string, value float64) {
+	t.Helper()
+	for _, s := range r.subscribed(symbol) {
+		select {
+		case s.c <- &multiplexPrice{r: r, value: value}:
+		case <-s.ctx.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the stream of %q did not receive the price", symbol)
+		}
+	}
+}
+
+func (r *multiplexResolver) resolutions() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.resolved
+}
+
+type multiplexPrice struct {
+	r     *multiplexResolver
+	value float64
+}
+
+func (p *multiplexPrice) Value() float64 {
+	p.r.mu.Lock()
+	defer p.r.mu.Unlock()
+	p.r.resolved++
+	return p.value
+}
+
+type multiplexKey struct{}
+
+// nextResponse returns the next response of c.
+func nextResponse(t *testing.T, c <-chan interface{}) *graphql.Response {
+	t.Helper()
+	select {
+	case resp, ok := <-c:
+		if !ok {
+			t.Fatal("got closed channel, want a response")
+		}
+		return resp.(*graphql.Response)
+	case <-time.After(5 * time.Second):
+		t.Fatal("got no response")
+	}
+	return nil
+}
+
+// waitClosed waits until c is closed.
+func waitClosed(t *testing.T, c <-chan interface{}) {
+	t.Helper()
+	for {
+		select {
+		case _, ok := <-c:
+			if !ok {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("got open channel, want it to be closed")
+		}
+	}
+}
+
+func TestMultiplexSubscriptions(t *testing.T) {
+	r := &multiplexResolver{streams: make(map[string][]*priceStream)}
+	s := graphql.MustParseSchema(multiplexSchema, r, graphql.MultiplexSubscriptions(func(ctx context.Context) (string, bool) {
+		key, ok := ctx.Value(multiplexKey{}).(string)
+		return key, ok
+	}))
+
+	subscribe := func(ctx context.Context, key, query string, variables map[string]interface{}) <-chan interface{} {
+		t.Helper()
+		if key != "" {
+			ctx = context.WithValue(ctx, multiplexKey{}, key)
+		}
+		c, err := s.Subscribe(ctx, query, "", variables)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	defer cancel1()
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	c1 := subscribe(ctx1, "public", `subscription First { onPriceChange(symbol: "ACME", currency: "EUR") { value } }`, nil)
+	c2 := subscribe(ctx2, "public", `
+		# the same subscription in another form
+		subscription Second($symbol: String!) {
+			onPriceChange(currency: "EUR", symbol: $symbol) { value }
+		}
+	`, map[string]interface{}{"symbol": "ACME"})
+	if n := len(r.subscribed("ACME")); n != 2 {
+		t.Fatalf("got %d calls of the resolver, want 2 for the different documents", n)
+	}
+	c3 := subscribe(ctx2, "public", `subscription Third($symbol: String!) { onPriceChange(symbol: $symbol, currency: "EUR") { value } }`, map[string]interface{}{"symbol": "ACME"})
+	c4 := subscribe(ctx2, "private", `subscription { onPriceChange(symbol: "ACME", currency: "EUR") { value } }`, nil)
+	c5 := subscribe(ctx2, "", `subscription { onPriceChange(symbol: "ACME", currency: "EUR") { value } }`, nil)
+	c6 := subscribe(ctx2, "public", `subscription Second($symbol: String!) { onPriceChange(currency: "EUR", symbol: $symbol) { value } }`, map[string]interface{}{"symbol": "INIT"})
+	if n := len(r.subscribed("ACME")); n != 4 {
+		t.Errorf("got %d calls of the resolver, want 4 for the documents, keys and unshared subscription", n)
+	}
+
+	r.publish(t, "ACME", 1.5)
+	want := `{"data":{"onPriceChange":{"value":1.5}}}`
+	shared := nextResponse(t, c1)
+	var responses []*graphql.Response
+	for _, c := range []<-chan interface{}{c2, c3, c4, c5} {
+		resp := nextResponse(t, c)
+		responses = append(responses, resp)
+		b, err := json.Marshal(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("got %s, want %s", b, want)
+		}
+	}
+	if responses[0] != responses[1] {
+		t.Error("got different responses for the shared subscriptions, want one response")
+	}
+	if b, err := json.Marshal(shared); err != nil || string(b) != want {
+		t.Errorf("got %s, %v, want %s", b, err, want)
+	}
+	if n := r.resolutions(); n != 4 {
+		t.Errorf("got %d resolutions of the price, want 4", n)
+	}
+
+	// the shared stream continues for the remaining subscribers and ends with the last of them
+	cancel2()
+	r.publish(t, "ACME", 2)
+	if resp := nextResponse(t, c1); string(resp.Data) != `{"onPriceChange":{"value":2}}` {
+		t.Errorf("got %s, want the second price", resp.Data)
+	}
+	for _, c := range []<-chan interface{}{c2, c3, c4, c5, c6} {
+		waitClosed(t, c)
+	}
+	cancel1()
+	waitClosed(t, c1)
+	for _, st := range append(r.subscribed("ACME"), r.subscribed("INIT")...) {
+		select {
+		case <-st.ctx.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("got a running stream, want all streams to be cancelled")
+		}
+	}
+}
+
+func TestMultiplexSubscriptions_End(t *testing.T) {
+	r := &multiplexResolver{streams: make(map[string][]*priceStream)}
+	s := graphql.MustParseSchema(multiplexSchema, r, graphql.MultiplexSubscriptions(func(context.Context) (string, bool) {
+		return "", true
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	query := `subscription { onPriceChange(symbol: "ACME") { value } }`
+	var cs []<-chan interface{}
+	for i := 0; i < 2; i++ {
+		c, err := s.Subscribe(ctx, query, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cs = append(cs, c)
+	}
+	streams := r.subscribed("ACME")
+	if len(streams) != 1 {
+		t.Fatalf("got %d calls of the resolver, want 1", len(streams))
+	}
+	close(streams[0].c)
+	for _, c := range cs {
+		waitClosed(t, c)
+	}
+
+	// a new subscription starts a new stream after the previous one has ended
+	if _, err := s.Subscribe(ctx, query, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(r.subscribed("ACME")); n != 2 {
+		t.Errorf("got %d calls of the resolver, want 2", n)
+	}
+}
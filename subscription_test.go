@@ -4,12 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 
 	graphql "github.com/graph-gophers/graphql-go"
 	qerrors "github.com/graph-gophers/graphql-go/errors"
 	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/graph-gophers/graphql-go/introspection"
+	"github.com/graph-gophers/graphql-go/trace/tracer"
 )
 
 type rootResolver struct {
@@ -570,3 +575,259 @@ func TestSchemaSubscribe_PanicInResolver(t *testing.T) {
 		},
 	})
 }
+
+type subscriptionsPanicInEventResolver struct{}
+
+func (r *subscriptionsPanicInEventResolver) OnPanic() <-chan *panicEventResolver {
+	c := make(chan *panicEventResolver, 1)
+	c <- &panicEventResolver{}
+	close(c)
+	return c
+}
+
+type panicEventResolver struct{}
+
+func (r *panicEventResolver) Msg() string {
+	panic("panicEventResolver")
+}
+
+func TestSchemaSubscribe_PanicInEventResolver(t *testing.T) {
+	r := &struct {
+		*subscriptionsPanicInEventResolver
+	}{
+		subscriptionsPanicInEventResolver: &subscriptionsPanicInEventResolver{},
+	}
+	gqltesting.RunSubscribe(t, &gqltesting.TestSubscription{
+		Schema: graphql.MustParseSchema(`
+			type Query {}
+			type Subscription {
+				onPanic : PanicEvent!
+			}
+			type PanicEvent {
+				msg: String!
+			}
+		`, r),
+		Query: `
+			subscription {
+				onPanic { msg }
+			}
+		`,
+		ExpectedResults: []gqltesting.TestResponse{
+			{Errors: []*qerrors.QueryError{{Message: "panic occurred: panicEventResolver"}}},
+		},
+	})
+}
+
+type subscriptionResultResolver struct {
+	events []*helloSaidResult
+}
+
+func (r *subscriptionResultResolver) Hello() string {
+	return "Hello world!"
+}
+
+func (r *subscriptionResultResolver) HelloSaid() <-chan *helloSaidResult {
+	c := make(chan *helloSaidResult, len(r.events))
+	for _, e := range r.events {
+		c <- e
+	}
+	close(c)
+	return c
+}
+
+type helloSaidResult struct {
+	msg string
+	err error
+}
+
+func (r *helloSaidResult) SubscriptionResult() (*helloSaidEventResolver, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &helloSaidEventResolver{msg: r.msg}, nil
+}
+
+func TestSchemaSubscribe_SubscriptionResult(t *testing.T) {
+	errEvent := errors.New("event failed")
+	errStream := errors.New("stream failed")
+
+	tests := []struct {
+		name   string
+		events []*helloSaidResult
+		want   []string
+	}{
+		{
+			name:   "event_error",
+			events: []*helloSaidResult{{msg: "a"}, {err: errEvent}, {msg: "b"}},
+			want:   []string{`{"helloSaid":{"msg":"a"}}`, `error: event failed`, `{"helloSaid":{"msg":"b"}}`},
+		},
+		{
+			name:   "query_error",
+			events: []*helloSaidResult{{err: qerrors.Errorf("event failed")}, {err: qerrors.Forbidden("event forbidden")}},
+			want:   []string{`error: graphql: event failed`, `error: event forbidden`},
+		},
+		{
+			name:   "end_with_error",
+			events: []*helloSaidResult{{msg: "a"}, {err: graphql.EndSubscription(errStream)}, {msg: "b"}},
+			want:   []string{`{"helloSaid":{"msg":"a"}}`, `error: stream failed`},
+		},
+		{
+			name:   "end",
+			events: []*helloSaidResult{{msg: "a"}, {err: graphql.EndSubscription(nil)}, {msg: "b"}},
+			want:   []string{`{"helloSaid":{"msg":"a"}}`},
+		},
+		{
+			name:   "wrapped_end_with_error",
+			events: []*helloSaidResult{{msg: "a"}, {err: fmt.Errorf("reading messages: %w", graphql.EndSubscription(errStream))}, {msg: "b"}},
+			want:   []string{`{"helloSaid":{"msg":"a"}}`, `error: reading messages: stream failed`},
+		},
+		{
+			name:   "wrapped_end",
+			events: []*helloSaidResult{{msg: "a"}, {err: fmt.Errorf("reading messages: %w", graphql.EndSubscription(nil))}, {msg: "b"}},
+			want:   []string{`{"helloSaid":{"msg":"a"}}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := graphql.MustParseSchema(`
+				type Query { hello: String! }
+				type Subscription { helloSaid: HelloSaidEvent! }
+				type HelloSaidEvent { msg: String! }
+			`, &subscriptionResultResolver{events: tt.events})
+
+			c, err := s.Subscribe(context.Background(), `subscription { helloSaid { msg } }`, "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for r := range c {
+				resp := r.(*graphql.Response)
+				if len(resp.Errors) == 0 {
+					got = append(got, string(resp.Data))
+					continue
+				}
+				for _, err := range resp.Errors {
+					if len(err.Path) != 1 || err.Path[0] != "helloSaid" || resp.Data != nil {
+						t.Errorf("got error %v at %v with data %s, want it at helloSaid without data", err, err.Path, resp.Data)
+					}
+					got = append(got, "error: "+err.Message)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got responses %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type subscriptionTracer struct {
+	mu      sync.Mutex
+	reasons []tracer.SubscriptionCloseReason
+	errs    []*qerrors.QueryError
+	events  int
+}
+
+func (t *subscriptionTracer) TraceQuery(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, varTypes map[string]*introspection.Type) (context.Context, func([]*qerrors.QueryError)) {
+	return ctx, func([]*qerrors.QueryError) {}
+}
+
+func (t *subscriptionTracer) TraceField(ctx context.Context, label, typeName, fieldName string, trivial bool, args map[string]interface{}) (context.Context, func(*qerrors.QueryError)) {
+	return ctx, func(*qerrors.QueryError) {}
+}
+
+func (t *subscriptionTracer) TraceSubscription(ctx context.Context, operationName string, variables map[string]interface{}) (context.Context, func(tracer.SubscriptionCloseReason, *qerrors.QueryError)) {
+	return ctx, func(reason tracer.SubscriptionCloseReason, err *qerrors.QueryError) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.reasons = append(t.reasons, reason)
+		t.errs = append(t.errs, err)
+	}
+}
+
+func (t *subscriptionTracer) TraceSubscriptionEvent(ctx context.Context, fieldName string) (context.Context, func([]*qerrors.QueryError)) {
+	return ctx, func([]*qerrors.QueryError) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.events++
+	}
+}
+
+func TestSchemaSubscribe_SubscriptionTracer(t *testing.T) {
+	const schema = `
+		type Query { hello: String! }
+		type Subscription { helloSaid: HelloSaidEvent! }
+		type HelloSaidEvent { msg: String! }
+	`
+	tests := []struct {
+		name     string
+		resolver interface{}
+		cancel   bool
+		reason   tracer.SubscriptionCloseReason
+		err      string
+		events   int
+	}{
+		{
+			name:     "completed",
+			resolver: &subscriptionResultResolver{events: []*helloSaidResult{{msg: "a"}, {msg: "b"}}},
+			reason:   tracer.SubscriptionCompleted,
+			events:   2,
+		},
+		{
+			name:     "ended_with_error",
+			resolver: &subscriptionResultResolver{events: []*helloSaidResult{{msg: "a"}, {err: graphql.EndSubscription(errors.New("stream failed"))}}},
+			reason:   tracer.SubscriptionFailed,
+			err:      "stream failed",
+			events:   2,
+		},
+		{
+			name:     "resolver_error",
+			resolver: &rootResolver{helloSaidResolver: &helloSaidResolver{err: errResolver}},
+			reason:   tracer.SubscriptionFailed,
+			err:      errResolver.Error(),
+		},
+		{
+			name:     "cancelled",
+			resolver: &rootResolver{helloSaidResolver: &helloSaidResolver{upstream: make(chan *helloSaidEventResolver)}},
+			cancel:   true,
+			reason:   tracer.SubscriptionCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &subscriptionTracer{}
+			s := graphql.MustParseSchema(schema, tt.resolver, graphql.Tracer(tr))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			c, err := s.Subscribe(ctx, `subscription { helloSaid { msg } }`, "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.cancel {
+				cancel()
+			}
+			for range c {
+			}
+
+			// the subscription is finished after its response channel was closed
+			for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+				tr.mu.Lock()
+				finished := len(tr.reasons) > 0
+				tr.mu.Unlock()
+				if finished || time.Now().After(deadline) {
+					break
+				}
+			}
+			tr.mu.Lock()
+			defer tr.mu.Unlock()
+			if len(tr.reasons) != 1 || tr.reasons[0] != tt.reason {
+				t.Fatalf("got close reasons %v, want %q", tr.reasons, tt.reason)
+			}
+			if got := tr.errs[0]; (got == nil) != (tt.err == "") || got != nil && got.Message != tt.err {
+				t.Errorf("got error %v, want %q", got, tt.err)
+			}
+			if tr.events != tt.events {
+				t.Errorf("got %d events, want %d", tr.events, tt.events)
+			}
+		})
+	}
+}
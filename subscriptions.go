@@ -4,13 +4,13 @@ import (
 	"context"
 	"errors"
 
+	"github.com/graph-gophers/graphql-go/ast"
 	qerrors "github.com/graph-gophers/graphql-go/errors"
 	"github.com/graph-gophers/graphql-go/internal/common"
 	"github.com/graph-gophers/graphql-go/internal/exec"
 	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
 	"github.com/graph-gophers/graphql-go/internal/exec/selected"
 	"github.com/graph-gophers/graphql-go/internal/query"
-	"github.com/graph-gophers/graphql-go/internal/validation"
 	"github.com/graph-gophers/graphql-go/introspection"
 )
 
@@ -19,6 +19,13 @@ import (
 // If the context gets cancelled, the response channel will be closed and no
 // further resolvers will be called. The context error will be returned as soon
 // as possible (not immediately).
+//
+// The events of the channel returned by the subscription resolver may carry
+// either a value or an error if their type has a method
+// SubscriptionResult() (T, error), where T is the type of the values which are
+// resolved. An event with an error results in a response with the error, and
+// the subscription continues unless the error was wrapped with
+// [EndSubscription].
 func (s *Schema) Subscribe(ctx context.Context, queryString string, operationName string, variables map[string]interface{}) (<-chan interface{}, error) {
 	if !s.res.SubscriptionResolver.IsValid() {
 		return nil, errors.New("schema created without resolver, can not subscribe")
@@ -26,25 +33,44 @@ func (s *Schema) Subscribe(ctx context.Context, queryString string, operationNam
 	if _, ok := s.schema.RootOperationTypes["subscription"]; !ok {
 		return nil, errors.New("no subscriptions are offered by the schema")
 	}
-	return s.subscribe(ctx, queryString, operationName, variables, s.res), nil
+	return s.subscribe(ctx, &Request{Query: queryString, OperationName: operationName, Variables: variables}, s.res), nil
 }
 
-func (s *Schema) subscribe(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, res *resolvable.Schema) <-chan interface{} {
-	doc, qErr := query.Parse(queryString)
+func (s *Schema) subscribe(ctx context.Context, req *Request, res *resolvable.Schema) <-chan interface{} {
+	ctx = s.requestStart(ctx, req)
+	queryString, persist, qErr := s.requestQuery(ctx, req)
 	if qErr != nil {
-		return sendAndReturnClosed(&Response{Errors: []*qerrors.QueryError{qErr}})
+		return sendAndReturnClosed(s.present(ctx, &Response{Errors: []*qerrors.QueryError{s.coded(qerrors.CodeBadRequest, qErr)}}))
 	}
+	operationName, variables := req.OperationName, req.Variables
+
+	d, qErr := s.parse(queryString)
+	if qErr != nil {
+		s.afterParse(ctx, queryString, nil, qErr)
+		return sendAndReturnClosed(s.present(ctx, &Response{Errors: []*qerrors.QueryError{s.coded(qerrors.CodeParseFailed, qErr)}}))
+	}
+	doc := d.doc
+	s.afterParse(ctx, queryString, doc, nil)
 
 	validationFinish := s.validationTracer.TraceValidation(ctx)
-	errs := validation.Validate(s.schema, doc, variables, s.maxDepth)
+	errs := s.validate(d, variables)
 	validationFinish(errs)
+	errs = s.afterValidation(ctx, doc, errs)
 	if len(errs) != 0 {
-		return sendAndReturnClosed(&Response{Errors: errs})
+		return sendAndReturnClosed(s.present(ctx, &Response{Errors: errs}))
+	}
+	if persist != nil {
+		persist()
 	}
 
 	op, err := getOperation(doc, operationName)
 	if err != nil {
-		return sendAndReturnClosed(&Response{Errors: []*qerrors.QueryError{qerrors.Errorf("%s", err)}})
+		return sendAndReturnClosed(s.present(ctx, &Response{Errors: []*qerrors.QueryError{s.coded(qerrors.CodeOperationResolutionFailure, qerrors.Errorf("%s", err))}}))
+	}
+
+	extensions, qErr := s.complexity(doc, op, variables)
+	if qErr != nil {
+		return sendAndReturnClosed(s.present(ctx, &Response{Errors: []*qerrors.QueryError{qErr}, Extensions: extensions}))
 	}
 
 	r := &exec.Request{
@@ -58,38 +84,88 @@ func (s *Schema) subscribe(ctx context.Context, queryString string, operationNam
 		Logger:                   s.logger,
 		PanicHandler:             s.panicHandler,
 		SubscribeResolverTimeout: s.subscribeResolverTimeout,
+		Loaders:                  batchLookup(s.loaders),
 	}
 	varTypes := make(map[string]*introspection.Type)
 	for _, v := range op.Vars {
 		t, err := common.ResolveType(v.Type, s.schema.Resolve)
 		if err != nil {
-			return sendAndReturnClosed(&Response{Errors: []*qerrors.QueryError{err}})
+			return sendAndReturnClosed(s.present(ctx, &Response{Errors: []*qerrors.QueryError{s.coded(qerrors.CodeValidationFailed, err)}}))
 		}
 		varTypes[v.Name.Name] = introspection.WrapType(t)
 	}
 
 	if op.Type == query.Query || op.Type == query.Mutation {
+		ctx = s.beforeExecution(ctx, op, variables)
+		ctx, ext := exec.WithExtensions(ctx)
 		data, errs := r.Execute(ctx, res, op)
-		return sendAndReturnClosed(&Response{Data: data, Errors: errs})
+		return sendAndReturnClosed(s.present(ctx, &Response{Data: data, Errors: errs, Extensions: ext.MergeInto(extensions)}))
 	}
 
-	responses := r.Subscribe(ctx, res, op)
-	c := make(chan interface{})
+	dl := s.delivery(ctx)
+	start := func(ctx context.Context) func(deliver func(context.Context, *Response) bool) {
+		return s.execSubscription(ctx, r, res, op, variables)
+	}
+	if s.multiplexer != nil {
+		if c, ok := s.multiplexer.join(ctx, dl, doc, op, variables, start); ok {
+			return c
+		}
+	}
+
+	// the execution is cancelled when the delivery ends the subscription, so that the resolvers stop
+	ctx, cancel := context.WithCancel(ctx)
+	run := start(ctx)
+	c := make(chan interface{}, dl.bufferSize)
 	go func() {
-	Loop:
-		for resp := range responses {
-			select {
-			case c <- &Response{Data: resp.Data, Errors: resp.Errors}:
-				continue
+		defer close(c)
+		defer cancel()
+		run(func(ctx context.Context, resp *Response) bool {
+			return dl.deliver(ctx, c, resp)
+		})
+	}()
+
+	return c
+}
 
-			case <-ctx.Done():
-				break Loop
+// execSubscription starts the execution of the subscription and returns a function which passes the response of
+// each event to deliver until the subscription ends or deliver reports false. deliver receives the context of the
+// traced subscription, which is done when the execution is cancelled.
+func (s *Schema) execSubscription(ctx context.Context, r *exec.Request, res *resolvable.Schema, op *ast.OperationDefinition, variables map[string]interface{}) func(deliver func(context.Context, *Response) bool) {
+	ctx = s.beforeExecution(ctx, op, variables)
+	ctx, ext := exec.WithExtensions(ctx)
+	ctx, responses := r.Subscribe(ctx, res, op)
+	return func(deliver func(context.Context, *Response) bool) {
+		for resp := range responses {
+			if !deliver(ctx, s.present(ctx, &Response{Data: resp.Data, Errors: resp.Errors, Extensions: ext.MergeInto(resp.Extensions)})) {
+				return
 			}
 		}
-		close(c)
-	}()
+	}
+}
 
-	return c
+// EndSubscription wraps the error of a subscription event to end the subscription after a final response with the
+// error. If err is nil, the subscription ends without a response. See [Schema.Subscribe].
+func EndSubscription(err error) error {
+	return &subscriptionEnd{err: err}
+}
+
+type subscriptionEnd struct {
+	err error
+}
+
+func (e *subscriptionEnd) Error() string {
+	if e.err == nil {
+		return "subscription ended"
+	}
+	return e.err.Error()
+}
+
+func (e *subscriptionEnd) Unwrap() error {
+	return e.err
+}
+
+func (e *subscriptionEnd) EndsSubscription() bool {
+	return true
 }
 
 func sendAndReturnClosed(resp *Response) chan interface{} {
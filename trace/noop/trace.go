@@ -22,3 +22,7 @@ func (Tracer) TraceField(ctx context.Context, label, typeName, fieldName string,
 func (Tracer) TraceValidation(context.Context) func([]*errors.QueryError) {
 	return func(errs []*errors.QueryError) {}
 }
+
+func (Tracer) TraceBatch(ctx context.Context, typeName string, keys []interface{}) (context.Context, func([]error)) {
+	return ctx, func([]error) {}
+}
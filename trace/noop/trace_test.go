@@ -12,6 +12,7 @@ import (
 func TestInterfaceImplementation(t *testing.T) {
 	var _ tracer.ValidationTracer = &noop.Tracer{}
 	var _ tracer.Tracer = &noop.Tracer{}
+	var _ tracer.BatchTracer = &noop.Tracer{}
 }
 
 func TestTracerOption(t *testing.T) {
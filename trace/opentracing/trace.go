@@ -7,6 +7,7 @@ import (
 
 	"github.com/graph-gophers/graphql-go/errors"
 	"github.com/graph-gophers/graphql-go/introspection"
+	"github.com/graph-gophers/graphql-go/trace/tracer"
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/opentracing/opentracing-go/log"
@@ -77,4 +78,75 @@ func (Tracer) TraceValidation(ctx context.Context) func([]*errors.QueryError) {
 	}
 }
 
+func (Tracer) TraceBatch(ctx context.Context, typeName string, keys []interface{}) (context.Context, func([]error)) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "Batch: "+typeName)
+	span.SetTag("graphql.type", typeName)
+	span.SetTag("graphql.batch.size", len(keys))
+
+	return spanCtx, func(errs []error) {
+		var failed int
+		var msg string
+		for _, err := range errs {
+			if err != nil {
+				if failed == 0 {
+					msg = err.Error()
+				}
+				failed++
+			}
+		}
+		if failed > 0 {
+			if failed > 1 {
+				msg += fmt.Sprintf(" (and %d more errors)", failed-1)
+			}
+			ext.Error.Set(span, true)
+			span.SetTag("graphql.error", msg)
+		}
+		span.Finish()
+	}
+}
+
+func (Tracer) TraceSubscription(ctx context.Context, operationName string, variables map[string]interface{}) (context.Context, func(tracer.SubscriptionCloseReason, *errors.QueryError)) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "GraphQL subscription")
+
+	if operationName != "" {
+		span.SetTag("graphql.operationName", operationName)
+	}
+
+	if len(variables) != 0 {
+		span.LogFields(log.Object("graphql.variables", variables))
+	}
+
+	return spanCtx, func(reason tracer.SubscriptionCloseReason, err *errors.QueryError) {
+		span.SetTag("graphql.subscription.closeReason", string(reason))
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.SetTag("graphql.error", err.Error())
+		}
+		span.Finish()
+	}
+}
+
+func (Tracer) TraceSubscriptionEvent(ctx context.Context, fieldName string) (context.Context, func([]*errors.QueryError)) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "GraphQL subscription event")
+	span.SetTag("graphql.field", fieldName)
+
+	return spanCtx, func(errs []*errors.QueryError) {
+		if len(errs) > 0 {
+			msg := errs[0].Error()
+			if len(errs) > 1 {
+				msg += fmt.Sprintf(" (and %d more errors)", len(errs)-1)
+			}
+			ext.Error.Set(span, true)
+			span.SetTag("graphql.error", msg)
+		}
+		span.Finish()
+	}
+}
+
+func (Tracer) TraceDroppedEvent(ctx context.Context, dropped int) {
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		span.LogFields(log.String("event", "subscription event dropped"), log.Int("graphql.subscription.dropped", dropped))
+	}
+}
+
 func noop(*errors.QueryError) {}
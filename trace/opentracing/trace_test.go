@@ -1,8 +1,12 @@
 package opentracing_test
 
 import (
+	"context"
 	"testing"
 
+	ot "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+
 	"github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/example/starwars"
 	"github.com/graph-gophers/graphql-go/trace/opentracing"
@@ -12,6 +16,9 @@ import (
 func TestInterfaceImplementation(t *testing.T) {
 	var _ tracer.ValidationTracer = &opentracing.Tracer{}
 	var _ tracer.Tracer = &opentracing.Tracer{}
+	var _ tracer.BatchTracer = &opentracing.Tracer{}
+	var _ tracer.DeliveryTracer = &opentracing.Tracer{}
+	var _ tracer.SubscriptionTracer = &opentracing.Tracer{}
 }
 
 func TestTracerOption(t *testing.T) {
@@ -20,3 +27,66 @@ func TestTracerOption(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+type subscriptionResolver struct{}
+
+func (subscriptionResolver) Hello() string { return "hello" }
+
+func (subscriptionResolver) Greetings() <-chan *greeting {
+	c := make(chan *greeting, 2)
+	c <- &greeting{"hello"}
+	c <- &greeting{"hi"}
+	close(c)
+	return c
+}
+
+type greeting struct {
+	text string
+}
+
+func (g *greeting) Text() string { return g.text }
+
+func TestTraceSubscription(t *testing.T) {
+	mt := mocktracer.New()
+	prev := ot.GlobalTracer()
+	ot.SetGlobalTracer(mt)
+	defer ot.SetGlobalTracer(prev)
+
+	s := graphql.MustParseSchema(`
+		type Query { hello: String! }
+		type Subscription { greetings: Greeting! }
+		type Greeting { text: String! }
+	`, &subscriptionResolver{}, graphql.Tracer(opentracing.Tracer{}))
+	c, err := s.Subscribe(context.Background(), `subscription Greet { greetings { text } }`, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range c {
+	}
+
+	var events int
+	var subscription *mocktracer.MockSpan
+	for _, span := range mt.FinishedSpans() {
+		switch span.OperationName {
+		case "GraphQL subscription event":
+			events++
+			if span.Tag("graphql.field") != "greetings" {
+				t.Errorf("got event span with tags %v, want the field", span.Tags())
+			}
+		case "GraphQL subscription":
+			subscription = span
+		}
+	}
+	if events != 2 {
+		t.Errorf("got %d event spans, want 2", events)
+	}
+	if subscription == nil {
+		t.Fatal("got no subscription span")
+	}
+	if reason := subscription.Tag("graphql.subscription.closeReason"); reason != string(tracer.SubscriptionCompleted) {
+		t.Errorf("got close reason %v, want %q", reason, tracer.SubscriptionCompleted)
+	}
+	if name := subscription.Tag("graphql.operationName"); name != "Greet" {
+		t.Errorf("got operation name %v, want Greet", name)
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/graph-gophers/graphql-go/errors"
 	"github.com/graph-gophers/graphql-go/introspection"
+	"github.com/graph-gophers/graphql-go/trace/tracer"
 )
 
 // DefaultTracer creates a tracer using a default name.
@@ -90,3 +91,74 @@ func (t *Tracer) TraceValidation(ctx context.Context) func([]*errors.QueryError)
 		span.End()
 	}
 }
+
+func (t *Tracer) TraceBatch(ctx context.Context, typeName string, keys []interface{}) (context.Context, func([]error)) {
+	spanCtx, span := t.Tracer.Start(ctx, fmt.Sprintf("Batch: %s", typeName))
+	span.SetAttributes(
+		attribute.String("graphql.type", typeName),
+		attribute.Int("graphql.batch.size", len(keys)),
+	)
+
+	return spanCtx, func(errs []error) {
+		var failed int
+		var msg string
+		for _, err := range errs {
+			if err != nil {
+				if failed == 0 {
+					msg = err.Error()
+				}
+				failed++
+			}
+		}
+		if failed > 0 {
+			if failed > 1 {
+				msg += fmt.Sprintf(" (and %d more errors)", failed-1)
+			}
+			span.SetStatus(codes.Error, msg)
+		}
+		span.End()
+	}
+}
+
+func (t *Tracer) TraceDroppedEvent(ctx context.Context, dropped int) {
+	oteltrace.SpanFromContext(ctx).AddEvent("GraphQL subscription event dropped", oteltrace.WithAttributes(
+		attribute.Int("graphql.subscription.dropped", dropped),
+	))
+}
+
+func (t *Tracer) TraceSubscription(ctx context.Context, operationName string, variables map[string]interface{}) (context.Context, func(tracer.SubscriptionCloseReason, *errors.QueryError)) {
+	spanCtx, span := t.Tracer.Start(ctx, "GraphQL Subscription")
+
+	var attributes []attribute.KeyValue
+	if operationName != "" {
+		attributes = append(attributes, attribute.String("graphql.operationName", operationName))
+	}
+	if len(variables) != 0 {
+		attributes = append(attributes, attribute.String("graphql.variables", fmt.Sprintf("%v", variables)))
+	}
+	span.SetAttributes(attributes...)
+
+	return spanCtx, func(reason tracer.SubscriptionCloseReason, err *errors.QueryError) {
+		span.SetAttributes(attribute.String("graphql.subscription.closeReason", string(reason)))
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+func (t *Tracer) TraceSubscriptionEvent(ctx context.Context, fieldName string) (context.Context, func([]*errors.QueryError)) {
+	spanCtx, span := t.Tracer.Start(ctx, "GraphQL Subscription Event")
+	span.SetAttributes(attribute.String("graphql.field", fieldName))
+
+	return spanCtx, func(errs []*errors.QueryError) {
+		if len(errs) > 0 {
+			msg := errs[0].Error()
+			if len(errs) > 1 {
+				msg += fmt.Sprintf(" (and %d more errors)", len(errs)-1)
+			}
+			span.SetStatus(codes.Error, msg)
+		}
+		span.End()
+	}
+}
@@ -1,9 +1,15 @@
 package otel_test
 
 import (
+	"context"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
 
 	"github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/example/starwars"
@@ -14,6 +20,9 @@ import (
 func TestInterfaceImplementation(t *testing.T) {
 	var _ tracer.ValidationTracer = &otelgraphql.Tracer{}
 	var _ tracer.Tracer = &otelgraphql.Tracer{}
+	var _ tracer.BatchTracer = &otelgraphql.Tracer{}
+	var _ tracer.DeliveryTracer = &otelgraphql.Tracer{}
+	var _ tracer.SubscriptionTracer = &otelgraphql.Tracer{}
 }
 
 func TestTracerOption(t *testing.T) {
@@ -27,3 +36,148 @@ func TestTracerOption(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// recordingTracer records the spans started by the tracer.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, spanName string, opts ...oteltrace.SpanStartOption) (context.Context, oteltrace.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parent, _ := oteltrace.SpanFromContext(ctx).(*recordedSpan)
+	span := &recordedSpan{
+		Span:       oteltrace.SpanFromContext(context.Background()),
+		t:          t,
+		name:       spanName,
+		parent:     parent,
+		attributes: make(map[attribute.Key]attribute.Value),
+	}
+	t.spans = append(t.spans, span)
+	return oteltrace.ContextWithSpan(ctx, span), span
+}
+
+// waitEvents waits until n events were added to a span with the name.
+func (t *recordingTracer) waitEvents(tb testing.TB, name string, n int) {
+	tb.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		t.mu.Lock()
+		for _, span := range t.spans {
+			if span.name == name && len(span.events) == n {
+				t.mu.Unlock()
+				return
+			}
+		}
+		t.mu.Unlock()
+	}
+	tb.Fatalf("got no span %q with %d events", name, n)
+}
+
+// recordedSpan records the attributes and events of a span and whether it has ended.
+type recordedSpan struct {
+	oteltrace.Span
+	t          *recordingTracer
+	name       string
+	parent     *recordedSpan
+	attributes map[attribute.Key]attribute.Value
+	events     []string
+	ended      bool
+}
+
+func (s *recordedSpan) SetAttributes(kv ...attribute.KeyValue) {
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+	for _, a := range kv {
+		s.attributes[a.Key] = a.Value
+	}
+}
+
+func (s *recordedSpan) AddEvent(name string, options ...oteltrace.EventOption) {
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+	s.events = append(s.events, name)
+}
+
+func (s *recordedSpan) End(options ...oteltrace.SpanEndOption) {
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+	s.ended = true
+}
+
+type subscriptionResolver struct{}
+
+func (subscriptionResolver) Hello() string { return "hello" }
+
+func (subscriptionResolver) Greetings() <-chan *greeting {
+	c := make(chan *greeting, 2)
+	c <- &greeting{"hello"}
+	c <- &greeting{"hi"}
+	close(c)
+	return c
+}
+
+type greeting struct {
+	text string
+}
+
+func (g *greeting) Text() string { return g.text }
+
+func TestTraceSubscription(t *testing.T) {
+	rt := &recordingTracer{}
+	s := graphql.MustParseSchema(`
+		type Query { hello: String! }
+		type Subscription { greetings: Greeting! }
+		type Greeting { text: String! }
+	`, &subscriptionResolver{},
+		graphql.Tracer(&otelgraphql.Tracer{Tracer: rt}),
+		graphql.SubscribeDeliveryTimeout(10*time.Millisecond),
+	)
+	c, err := s.Subscribe(context.Background(), `subscription Greet { greetings { text } }`, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the greetings are not received until both are dropped
+	rt.waitEvents(t, "GraphQL Subscription", 2)
+	for range c {
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	var subscription *recordedSpan
+	var events []*recordedSpan
+	for _, span := range rt.spans {
+		switch span.name {
+		case "GraphQL Subscription":
+			subscription = span
+		case "GraphQL Subscription Event":
+			events = append(events, span)
+		}
+	}
+	if subscription == nil {
+		t.Fatal("got no subscription span")
+	}
+	if !subscription.ended {
+		t.Error("got a running subscription span, want it to be ended")
+	}
+	if name := subscription.attributes["graphql.operationName"].AsString(); name != "Greet" {
+		t.Errorf("got operation name %q, want Greet", name)
+	}
+	if reason := subscription.attributes["graphql.subscription.closeReason"].AsString(); reason != string(tracer.SubscriptionCompleted) {
+		t.Errorf("got close reason %q, want %q", reason, tracer.SubscriptionCompleted)
+	}
+	if want := []string{"GraphQL subscription event dropped", "GraphQL subscription event dropped"}; !reflect.DeepEqual(subscription.events, want) {
+		t.Errorf("got subscription events %q, want %q", subscription.events, want)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d event spans, want 2", len(events))
+	}
+	for _, span := range events {
+		if span.parent != subscription || !span.ended {
+			t.Errorf("got event span with parent %v and ended %v, want an ended child of the subscription span", span.parent, span.ended)
+		}
+		if field := span.attributes["graphql.field"].AsString(); field != "greetings" {
+			t.Errorf("got event span for field %q, want greetings", field)
+		}
+	}
+}
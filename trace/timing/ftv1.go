@@ -0,0 +1,183 @@
+package timing
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// Field numbers of the Trace message of Apollo Federation (reports.proto).
+const (
+	traceEndTime    = 3
+	traceStartTime  = 4
+	traceDurationNs = 11
+	traceRoot       = 14
+
+	nodeResponseName      = 1
+	nodeIndex             = 2
+	nodeType              = 3
+	nodeStartTime         = 8
+	nodeEndTime           = 9
+	nodeError             = 11
+	nodeChild             = 12
+	nodeParentType        = 13
+	nodeOriginalFieldName = 14
+
+	errorMessage  = 1
+	errorLocation = 2
+	errorJSON     = 4
+
+	locationLine   = 1
+	locationColumn = 2
+
+	timestampSeconds = 1
+	timestampNanos   = 2
+)
+
+// Wire types of protocol buffers.
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// node is a node of the tree of the federated trace. Its children are the fields of an object or the items of a list.
+type node struct {
+	key      interface{}
+	resolver *resolver
+	children []*node
+	byKey    map[interface{}]*node
+}
+
+func (n *node) child(key interface{}) *node {
+	if c, ok := n.byKey[key]; ok {
+		return c
+	}
+	c := &node{key: key}
+	if n.byKey == nil {
+		n.byKey = make(map[interface{}]*node)
+	}
+	n.byKey[key] = c
+	n.children = append(n.children, c)
+	return c
+}
+
+// ftv1 returns the trace encoded as Trace message.
+func (t *trace) ftv1(end time.Time) []byte {
+	root := &node{}
+	for _, r := range t.sortedResolvers() {
+		n := root
+		for _, key := range r.path {
+			n = n.child(key)
+		}
+		n.resolver = r
+	}
+	root.sortItems()
+
+	var b protoBuffer
+	b.message(traceStartTime, timestamp(t.req.start))
+	b.message(traceEndTime, timestamp(end))
+	b.uint(traceDurationNs, uint64(end.Sub(t.req.start)))
+	b.message(traceRoot, root.encode())
+	return b
+}
+
+// sortItems orders the items of lists by their index, since they may be resolved in any order.
+func (n *node) sortItems() {
+	sort.SliceStable(n.children, func(i, j int) bool {
+		a, okA := n.children[i].key.(int)
+		b, okB := n.children[j].key.(int)
+		return okA && okB && a < b
+	})
+	for _, c := range n.children {
+		c.sortItems()
+	}
+}
+
+func (n *node) encode() []byte {
+	var b protoBuffer
+	switch key := n.key.(type) {
+	case string:
+		b.string(nodeResponseName, key)
+	case int:
+		// the index is part of a oneof, so it is encoded even if it is zero
+		b.tag(nodeIndex, wireVarint)
+		b.varint(uint64(key))
+	}
+	if r := n.resolver; r != nil {
+		if name, ok := n.key.(string); ok && name != r.fieldName {
+			b.string(nodeOriginalFieldName, r.fieldName)
+		}
+		b.string(nodeType, r.returnType)
+		b.string(nodeParentType, r.parentType)
+		b.uint(nodeStartTime, uint64(r.start))
+		b.uint(nodeEndTime, uint64(r.end))
+		if r.err != nil {
+			b.message(nodeError, encodeError(r))
+		}
+	}
+	for _, c := range n.children {
+		b.message(nodeChild, c.encode())
+	}
+	return b
+}
+
+func encodeError(r *resolver) []byte {
+	var b protoBuffer
+	b.string(errorMessage, r.err.Message)
+	for _, loc := range r.err.Locations {
+		var l protoBuffer
+		l.uint(locationLine, uint64(loc.Line))
+		l.uint(locationColumn, uint64(loc.Column))
+		b.message(errorLocation, l)
+	}
+	if data, err := json.Marshal(r.err); err == nil {
+		b.string(errorJSON, string(data))
+	}
+	return b
+}
+
+// timestamp encodes t as google.protobuf.Timestamp message.
+func timestamp(t time.Time) []byte {
+	var b protoBuffer
+	b.uint(timestampSeconds, uint64(t.Unix()))
+	b.uint(timestampNanos, uint64(t.Nanosecond()))
+	return b
+}
+
+// protoBuffer encodes the fields of a protocol buffer message. Fields with default values are omitted like in proto3.
+type protoBuffer []byte
+
+func (b *protoBuffer) varint(v uint64) {
+	for v >= 0x80 {
+		*b = append(*b, byte(v)|0x80)
+		v >>= 7
+	}
+	*b = append(*b, byte(v))
+}
+
+func (b *protoBuffer) tag(field int, wireType int) {
+	b.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (b *protoBuffer) uint(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	b.tag(field, wireVarint)
+	b.varint(v)
+}
+
+func (b *protoBuffer) string(field int, s string) {
+	if s == "" {
+		return
+	}
+	b.tag(field, wireBytes)
+	b.varint(uint64(len(s)))
+	*b = append(*b, s...)
+}
+
+func (b *protoBuffer) message(field int, m []byte) {
+	b.tag(field, wireBytes)
+	b.varint(uint64(len(m)))
+	*b = append(*b, m...)
+}
@@ -0,0 +1,235 @@
+// Package timing provides a tracer which reports the start offset and the duration of every resolver in the
+// extensions of the response, either in the Apollo tracing format or as a federated trace (ftv1) for Apollo
+// Federation gateways. Traces are only reported for requests which ask for them with [WithTracing] or with the
+// headers read by [Handler].
+package timing
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/introspection"
+	"github.com/graph-gophers/graphql-go/trace/tracer"
+)
+
+// Format is a set of the formats of the traces reported for a request.
+type Format int
+
+const (
+	// Apollo reports the trace in the "tracing" extension in the Apollo tracing format.
+	Apollo Format = 1 << iota
+	// FTV1 reports the trace in the "ftv1" extension as base64 encoded protocol buffer of the Trace message of
+	// Apollo Federation.
+	FTV1
+)
+
+const (
+	// TracingHeader requests the Apollo tracing format if it is set to any value.
+	TracingHeader = "X-Apollo-Tracing"
+	// FederationHeader requests the federated trace if it is set to "ftv1". It is sent by Apollo Federation
+	// gateways.
+	FederationHeader = "Apollo-Federation-Include-Trace"
+)
+
+type ctxKey string
+
+const (
+	requestKey ctxKey = "timing_request"
+	traceKey   ctxKey = "timing_trace"
+)
+
+// request holds the formats requested for a request and the timing of its validation.
+type request struct {
+	formats Format
+	start   time.Time
+
+	mu         sync.Mutex
+	validation *Span
+}
+
+// WithTracing returns a context which requests the traces of the operations executed with it in the formats. The
+// offsets in the traces are relative to the time WithTracing is called.
+func WithTracing(ctx context.Context, formats Format) context.Context {
+	if formats == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, requestKey, &request{formats: formats, start: time.Now()})
+}
+
+// FormatsFromHeader returns the formats of the traces requested by the headers of an HTTP request.
+func FormatsFromHeader(h http.Header) Format {
+	var formats Format
+	if h.Get(TracingHeader) != "" {
+		formats |= Apollo
+	}
+	if strings.EqualFold(h.Get(FederationHeader), "ftv1") {
+		formats |= FTV1
+	}
+	return formats
+}
+
+// Handler calls next with a request context which requests the traces asked for by the headers of the request.
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if formats := FormatsFromHeader(r.Header); formats != 0 {
+			r = r.WithContext(WithTracing(r.Context(), formats))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Tracer records the timing of the resolvers of the operations which requested traces and adds the traces to the
+// extensions of their responses. Other operations are not traced. Subscriptions are not traced.
+type Tracer struct{}
+
+func (Tracer) TraceQuery(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, varTypes map[string]*introspection.Type) (context.Context, func([]*errors.QueryError)) {
+	req, _ := ctx.Value(requestKey).(*request)
+	if req == nil {
+		return ctx, func([]*errors.QueryError) {}
+	}
+	t := &trace{req: req}
+	traceCtx := context.WithValue(ctx, traceKey, t)
+	return traceCtx, func([]*errors.QueryError) {
+		end := time.Now()
+		if req.formats&Apollo != 0 {
+			graphql.AddExtension(ctx, "tracing", t.apollo(end))
+		}
+		if req.formats&FTV1 != 0 {
+			graphql.AddExtension(ctx, "ftv1", base64.StdEncoding.EncodeToString(t.ftv1(end)))
+		}
+	}
+}
+
+// TraceField does not trace the field, since the fields are traced with TraceFieldPath.
+func (Tracer) TraceField(ctx context.Context, label, typeName, fieldName string, trivial bool, args map[string]interface{}) (context.Context, func(*errors.QueryError)) {
+	return ctx, func(*errors.QueryError) {}
+}
+
+func (Tracer) TraceFieldPath(ctx context.Context, field *tracer.FieldInfo) (context.Context, func(*errors.QueryError)) {
+	t, _ := ctx.Value(traceKey).(*trace)
+	if t == nil {
+		return ctx, func(*errors.QueryError) {}
+	}
+	r := &resolver{
+		path:       field.Path,
+		parentType: field.TypeName,
+		fieldName:  field.FieldName,
+		returnType: field.ReturnType,
+		start:      time.Since(t.req.start),
+	}
+	return ctx, func(err *errors.QueryError) {
+		r.end = time.Since(t.req.start)
+		r.err = err
+		t.mu.Lock()
+		t.resolvers = append(t.resolvers, r)
+		t.mu.Unlock()
+	}
+}
+
+func (Tracer) TraceValidation(ctx context.Context) func([]*errors.QueryError) {
+	req, _ := ctx.Value(requestKey).(*request)
+	if req == nil {
+		return func([]*errors.QueryError) {}
+	}
+	start := time.Since(req.start)
+	return func([]*errors.QueryError) {
+		span := &Span{StartOffset: int64(start), Duration: int64(time.Since(req.start) - start)}
+		req.mu.Lock()
+		req.validation = span
+		req.mu.Unlock()
+	}
+}
+
+// trace holds the timing of the resolvers of an operation.
+type trace struct {
+	req *request
+
+	mu        sync.Mutex
+	resolvers []*resolver
+}
+
+type resolver struct {
+	path       []interface{}
+	parentType string
+	fieldName  string
+	returnType string
+	start      time.Duration
+	end        time.Duration
+	err        *errors.QueryError
+}
+
+// sortedResolvers returns the resolvers ordered by their start.
+func (t *trace) sortedResolvers() []*resolver {
+	t.mu.Lock()
+	resolvers := make([]*resolver, len(t.resolvers))
+	copy(resolvers, t.resolvers)
+	t.mu.Unlock()
+	sort.SliceStable(resolvers, func(i, j int) bool {
+		return resolvers[i].start < resolvers[j].start
+	})
+	return resolvers
+}
+
+// ApolloTrace is the trace of an operation in the Apollo tracing format, which is reported in the "tracing"
+// extension. Durations and offsets are in nanoseconds.
+type ApolloTrace struct {
+	Version    int             `json:"version"`
+	StartTime  time.Time       `json:"startTime"`
+	EndTime    time.Time       `json:"endTime"`
+	Duration   int64           `json:"duration"`
+	Validation *Span           `json:"validation,omitempty"`
+	Execution  ApolloExecution `json:"execution"`
+}
+
+// Span is the start offset and the duration of a phase of a request.
+type Span struct {
+	StartOffset int64 `json:"startOffset"`
+	Duration    int64 `json:"duration"`
+}
+
+// ApolloExecution holds the timing of the resolvers of an operation.
+type ApolloExecution struct {
+	Resolvers []ApolloResolver `json:"resolvers"`
+}
+
+// ApolloResolver is the timing of a resolver.
+type ApolloResolver struct {
+	Path        []interface{} `json:"path"`
+	ParentType  string        `json:"parentType"`
+	FieldName   string        `json:"fieldName"`
+	ReturnType  string        `json:"returnType"`
+	StartOffset int64         `json:"startOffset"`
+	Duration    int64         `json:"duration"`
+}
+
+func (t *trace) apollo(end time.Time) *ApolloTrace {
+	resolvers := t.sortedResolvers()
+	a := &ApolloTrace{
+		Version:   1,
+		StartTime: t.req.start.UTC(),
+		EndTime:   end.UTC(),
+		Duration:  int64(end.Sub(t.req.start)),
+		Execution: ApolloExecution{Resolvers: make([]ApolloResolver, len(resolvers))},
+	}
+	t.req.mu.Lock()
+	a.Validation = t.req.validation
+	t.req.mu.Unlock()
+	for i, r := range resolvers {
+		a.Execution.Resolvers[i] = ApolloResolver{
+			Path:        r.path,
+			ParentType:  r.parentType,
+			FieldName:   r.fieldName,
+			ReturnType:  r.returnType,
+			StartOffset: int64(r.start),
+			Duration:    int64(r.end - r.start),
+		}
+	}
+	return a
+}
@@ -0,0 +1,263 @@
+package timing_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/trace/timing"
+	"github.com/graph-gophers/graphql-go/trace/tracer"
+)
+
+func TestInterfaceImplementation(t *testing.T) {
+	var _ tracer.Tracer = timing.Tracer{}
+	var _ tracer.FieldPathTracer = timing.Tracer{}
+	var _ tracer.ValidationTracer = timing.Tracer{}
+}
+
+const schema = `
+	type Query {
+		users: [User!]!
+	}
+
+	type User {
+		name: String!
+		email: String
+	}
+`
+
+type query struct{}
+
+func (query) Users() []*user {
+	return []*user{{name: "Alice"}, {name: "Bob"}}
+}
+
+type user struct {
+	name string
+}
+
+func (u *user) Name() string {
+	return u.name
+}
+
+func (u *user) Email() (*string, error) {
+	return nil, errors.New("hidden")
+}
+
+func TestTracer(t *testing.T) {
+	s := graphql.MustParseSchema(schema, &query{}, graphql.Tracer(timing.Tracer{}))
+	const q = `{ users { login: name } }`
+
+	resp := s.Exec(context.Background(), q, "", nil)
+	if len(resp.Extensions) != 0 {
+		t.Errorf("got extensions %v, want no traces without a request for them", resp.Extensions)
+	}
+
+	resp = s.Exec(timing.WithTracing(context.Background(), timing.Apollo), q, "", nil)
+	if len(resp.Errors) != 0 {
+		t.Fatal(resp.Errors)
+	}
+	if _, ok := resp.Extensions["ftv1"]; ok {
+		t.Errorf("got an ftv1 trace, want only the tracing extension")
+	}
+	trace, ok := resp.Extensions["tracing"].(*timing.ApolloTrace)
+	if !ok {
+		t.Fatalf("got extensions %v, want a tracing extension", resp.Extensions)
+	}
+	if trace.Version != 1 || trace.Duration <= 0 || trace.Validation == nil || !trace.EndTime.After(trace.StartTime) {
+		t.Errorf("got trace %+v, want the timing of the request", trace)
+	}
+	var paths [][]interface{}
+	for _, r := range trace.Execution.Resolvers {
+		paths = append(paths, r.Path)
+		if r.StartOffset <= 0 || r.Duration < 0 {
+			t.Errorf("got resolver %+v, want a start offset and a duration", r)
+		}
+	}
+	wantPaths := [][]interface{}{{"users"}, {"users", 0, "login"}, {"users", 1, "login"}}
+	if len(paths) != len(wantPaths) {
+		t.Fatalf("got paths %v, want %v", paths, wantPaths)
+	}
+	for _, want := range wantPaths {
+		found := false
+		for _, p := range paths {
+			found = found || reflect.DeepEqual(p, want)
+		}
+		if !found {
+			t.Errorf("got paths %v, want %v", paths, want)
+		}
+	}
+	if r := trace.Execution.Resolvers[0]; r.ParentType != "Query" || r.FieldName != "users" || r.ReturnType != "[User!]!" {
+		t.Errorf("got resolver %+v, want users of Query", r)
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Extensions struct {
+			Tracing struct {
+				StartTime string `json:"startTime"`
+				Execution struct {
+					Resolvers []map[string]interface{} `json:"resolvers"`
+				} `json:"execution"`
+			} `json:"tracing"`
+		} `json:"extensions"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if tr := decoded.Extensions.Tracing; tr.StartTime == "" || len(tr.Execution.Resolvers) != 3 || tr.Execution.Resolvers[0]["startOffset"] == nil {
+		t.Errorf("got %s, want the Apollo tracing format", data)
+	}
+}
+
+func TestTracer_FTV1(t *testing.T) {
+	s := graphql.MustParseSchema(schema, &query{}, graphql.Tracer(timing.Tracer{}))
+
+	resp := s.Exec(timing.WithTracing(context.Background(), timing.FTV1), `{ users { login: name email } }`, "", nil)
+	encoded, ok := resp.Extensions["ftv1"].(string)
+	if !ok {
+		t.Fatalf("got extensions %v, want an ftv1 trace", resp.Extensions)
+	}
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trace := decode(t, b)
+	if trace.uint(11) == 0 || trace.message(t, 4).uint(1) == 0 || trace.message(t, 3).uint(1) == 0 {
+		t.Errorf("got trace %v, want the start, the end and the duration", trace)
+	}
+	root := trace.message(t, 14)
+	users := root.message(t, 12)
+	if users.string(1) != "users" || users.string(3) != "[User!]!" || users.string(13) != "Query" || users.uint(9) == 0 {
+		t.Errorf("got node %v, want the users field", users)
+	}
+	items := users.messages(t, 12)
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want 2", len(items))
+	}
+	if _, ok := items[0][2]; !ok || items[1].uint(2) != 1 {
+		t.Errorf("got items %v, want the indices 0 and 1", items)
+	}
+	fields := make(map[string]message)
+	for _, f := range items[1].messages(t, 12) {
+		fields[f.string(1)] = f
+	}
+	if login := fields["login"]; login.string(14) != "name" || login.string(13) != "User" {
+		t.Errorf("got fields %v, want the aliased name field", fields)
+	}
+	if email := fields["email"]; email.message(t, 11).string(1) != "hidden" {
+		t.Errorf("got fields %v, want the error of the email field", fields)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	s := graphql.MustParseSchema(schema, &query{}, graphql.Tracer(timing.Tracer{}))
+	var resp *graphql.Response
+	h := timing.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp = s.Exec(r.Context(), `{ users { name } }`, "", nil)
+	}))
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   []string
+	}{
+		{name: "none", header: http.Header{}},
+		{name: "apollo", header: http.Header{timing.TracingHeader: {"1"}}, want: []string{"tracing"}},
+		{name: "ftv1", header: http.Header{timing.FederationHeader: {"ftv1"}}, want: []string{"ftv1"}},
+		{name: "unknown_format", header: http.Header{timing.FederationHeader: {"ftv2"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+			r.Header = tt.header
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			if len(resp.Extensions) != len(tt.want) {
+				t.Fatalf("got extensions %v, want %v", resp.Extensions, tt.want)
+			}
+			for _, key := range tt.want {
+				if _, ok := resp.Extensions[key]; !ok {
+					t.Errorf("got extensions %v, want %v", resp.Extensions, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// message holds the fields of a decoded protocol buffer message by field number.
+type message map[int][]interface{}
+
+func decode(t *testing.T, b []byte) message {
+	t.Helper()
+	m := make(message)
+	for len(b) > 0 {
+		key, n := varint(t, b)
+		b = b[n:]
+		field := int(key >> 3)
+		switch key & 7 {
+		case 0:
+			v, n := varint(t, b)
+			b = b[n:]
+			m[field] = append(m[field], v)
+		case 2:
+			l, n := varint(t, b)
+			b = b[n:]
+			m[field] = append(m[field], b[:l])
+			b = b[l:]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+	}
+	return m
+}
+
+func varint(t *testing.T, b []byte) (uint64, int) {
+	var v uint64
+	for i, c := range b {
+		v |= uint64(c&0x7f) << (7 * i)
+		if c < 0x80 {
+			return v, i + 1
+		}
+	}
+	t.Fatal("truncated varint")
+	return 0, 0
+}
+
+func (m message) uint(field int) uint64 {
+	if len(m[field]) == 0 {
+		return 0
+	}
+	return m[field][0].(uint64)
+}
+
+func (m message) string(field int) string {
+	if len(m[field]) == 0 {
+		return ""
+	}
+	return string(m[field][0].([]byte))
+}
+
+func (m message) message(t *testing.T, field int) message {
+	if len(m[field]) == 0 {
+		return message{}
+	}
+	return decode(t, m[field][0].([]byte))
+}
+
+func (m message) messages(t *testing.T, field int) []message {
+	var msgs []message
+	for _, v := range m[field] {
+		msgs = append(msgs, decode(t, v.([]byte)))
+	}
+	return msgs
+}
@@ -11,6 +11,9 @@ import (
 type QueryFinishFunc = func([]*errors.QueryError)
 type FieldFinishFunc = func(*errors.QueryError)
 type ValidationFinishFunc = func([]*errors.QueryError)
+type BatchFinishFunc = func([]error)
+type SubscriptionFinishFunc = func(reason SubscriptionCloseReason, err *errors.QueryError)
+type SubscriptionEventFinishFunc = func([]*errors.QueryError)
 
 type Tracer interface {
 	TraceQuery(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, varTypes map[string]*introspection.Type) (context.Context, QueryFinishFunc)
@@ -21,6 +24,63 @@ type ValidationTracer interface {
 	TraceValidation(ctx context.Context) ValidationFinishFunc
 }
 
+// BatchTracer is an optional interface of a [Tracer], which traces the calls of the batch functions registered
+// with the loader package. The finish function receives the errors of the keys, which contain nil entries for the
+// keys which were loaded successfully. Tracers which don't implement it trace the calls with TraceField.
+type BatchTracer interface {
+	TraceBatch(ctx context.Context, typeName string, keys []interface{}) (context.Context, BatchFinishFunc)
+}
+
+// SubscriptionTracer is an optional interface of a [Tracer], which traces the lifetime of subscriptions and the
+// resolution of each of their events. The finish function of a subscription receives the reason why it ended and
+// the error which ended it, if any. Tracers which don't implement it only trace the fields of the events.
+type SubscriptionTracer interface {
+	TraceSubscription(ctx context.Context, operationName string, variables map[string]interface{}) (context.Context, SubscriptionFinishFunc)
+	TraceSubscriptionEvent(ctx context.Context, fieldName string) (context.Context, SubscriptionEventFinishFunc)
+}
+
+// SubscriptionCloseReason tells why a subscription ended.
+type SubscriptionCloseReason string
+
+const (
+	// SubscriptionCompleted means that the source stream of the subscription was closed or ended without an error.
+	SubscriptionCompleted SubscriptionCloseReason = "completed"
+	// SubscriptionCancelled means that the context of the subscription is done, e.g. because the client
+	// unsubscribed.
+	SubscriptionCancelled SubscriptionCloseReason = "cancelled"
+	// SubscriptionFailed means that the subscription could not be started or that its source stream ended with an
+	// error.
+	SubscriptionFailed SubscriptionCloseReason = "failed"
+)
+
+// DeliveryTracer is an optional interface of a [Tracer], which is notified when the delivery policy of a
+// subscription drops an event because the consumer did not receive it in time. dropped is the number of events
+// dropped from the subscription so far. ctx is the context returned by TraceSubscription if the tracer is a
+// [SubscriptionTracer].
+type DeliveryTracer interface {
+	TraceDroppedEvent(ctx context.Context, dropped int)
+}
+
+// FieldInfo describes a field traced by a [FieldPathTracer].
+type FieldInfo struct {
+	Label      string
+	TypeName   string
+	FieldName  string
+	ReturnType string
+	Trivial    bool
+	Args       map[string]interface{}
+	// Path is the response path of the field, which consists of the response names of the fields and the indices
+	// of the list items.
+	Path []interface{}
+}
+
+// FieldPathTracer is an optional interface of a [Tracer], which traces fields together with their response path and
+// return type, e.g. to report the timing of every resolver. Tracers which implement it trace fields with
+// TraceFieldPath instead of TraceField.
+type FieldPathTracer interface {
+	TraceFieldPath(ctx context.Context, field *FieldInfo) (context.Context, FieldFinishFunc)
+}
+
 // Deprecated: use [ValidationTracer] instead.
 type LegacyValidationTracer interface {
 	TraceValidation() func([]*errors.QueryError)
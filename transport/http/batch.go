@@ -0,0 +1,45 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// DefaultMaxBatchSize is the maximum number of operations in a batched request used when [Handler.MaxBatchSize]
+// is not set.
+const DefaultMaxBatchSize = 10
+
+// isBatch reports whether a JSON request body is an array of operations.
+func isBatch(body []byte) bool {
+	body = bytes.TrimLeft(body, " \t\r\n")
+	return len(body) > 0 && body[0] == '['
+}
+
+// execBatch executes the operations of a batch concurrently, limited by BatchConcurrency. The responses
+// are returned in the order of the operations.
+func (h *Handler) execBatch(ctx context.Context, operations []*graphql.Request) []*graphql.Response {
+	limit := h.BatchConcurrency
+	if limit <= 0 || limit > len(operations) {
+		limit = len(operations)
+	}
+	sem := make(chan struct{}, limit)
+
+	responses := make([]*graphql.Response, len(operations))
+	var wg sync.WaitGroup
+	for i, p := range operations {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, p *graphql.Request) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			responses[i] = h.Schema.ExecRequest(ctx, p)
+		}(i, p)
+	}
+	wg.Wait()
+	return responses
+}
@@ -0,0 +1,146 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	graphqlhttp "github.com/graph-gophers/graphql-go/transport/http"
+)
+
+func TestHandler_Batch(t *testing.T) {
+	const jsonCT = "application/json; charset=utf-8"
+	const graphqlCT = "application/graphql-response+json; charset=utf-8"
+
+	tests := map[string]struct {
+		handler *graphqlhttp.Handler
+		req     request
+		want    response
+	}{
+		"batch": {
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "application/json"},
+				body:   ` [{"query":"{ hero { name } }"},{"query":"query($id: ID!) { human(id: $id) { name } }","variables":{"id":"1000"}}]`,
+			},
+			want: response{status: 200, contentType: jsonCT, body: `[{"data":{"hero":{"name":"R2-D2"}}},{"data":{"human":{"name":"Luke Skywalker"}}}]`},
+		},
+		"batch_with_errors": {
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "application/json", "Accept": "application/graphql-response+json"},
+				body:   `[{"query":"{ unknown }"},{"query":"{ hero { name } }"}]`,
+			},
+			want: response{status: 200, contentType: graphqlCT, body: `[{"errors":[{"message":"Cannot query field \"unknown\" on type \"Query\".","locations":[{"line":1,"column":3}]}]},{"data":{"hero":{"name":"R2-D2"}}}]`},
+		},
+		"empty_batch": {
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "application/json"},
+				body:   `[]`,
+			},
+			want: response{status: 400, contentType: jsonCT, body: `{"errors":[{"message":"the batch must contain at least one operation"}]}`},
+		},
+		"missing_query": {
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "application/json"},
+				body:   `[{"query":"{ hero { name } }"},{}]`,
+			},
+			want: response{status: 400, contentType: jsonCT, body: `{"errors":[{"message":"the query parameter of operation 1 is missing"}]}`},
+		},
+		"batch_too_large": {
+			handler: &graphqlhttp.Handler{Schema: starwarsSchema, MaxBatchSize: 1},
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "application/json"},
+				body:   `[{"query":"{ hero { name } }"},{"query":"{ hero { name } }"}]`,
+			},
+			want: response{status: 413, contentType: jsonCT, body: `{"errors":[{"message":"the batch contains 2 operations, the maximum is 1"}]}`},
+		},
+		"batching_disabled": {
+			handler: &graphqlhttp.Handler{Schema: starwarsSchema, MaxBatchSize: -1},
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "application/json"},
+				body:   `[{"query":"{ hero { name } }"}]`,
+			},
+			want: response{status: 400, contentType: jsonCT, body: `{"errors":[{"message":"batched operations are not supported"}]}`},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			h := tt.handler
+			if h == nil {
+				h = &graphqlhttp.Handler{Schema: starwarsSchema}
+			}
+			tt.req.target = "/"
+			w := serve(h, tt.req)
+			if w.Code != tt.want.status {
+				t.Errorf("got status %d, want %d", w.Code, tt.want.status)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.want.contentType {
+				t.Errorf("got content type %q, want %q", ct, tt.want.contentType)
+			}
+			if body := w.Body.String(); body != tt.want.body {
+				t.Errorf("got body %s, want %s", body, tt.want.body)
+			}
+		})
+	}
+}
+
+type ctxKey string
+
+type batchResolver struct {
+	mu       sync.Mutex
+	contexts map[interface{}]bool
+	running  int32
+	max      int32
+}
+
+func (r *batchResolver) Slow(ctx context.Context) int32 {
+	n := atomic.AddInt32(&r.running, 1)
+	defer atomic.AddInt32(&r.running, -1)
+	r.mu.Lock()
+	if n > r.max {
+		r.max = n
+	}
+	r.contexts[ctx.Value(ctxKey("request"))] = true
+	r.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	return n
+}
+
+func TestHandler_BatchConcurrency(t *testing.T) {
+	res := &batchResolver{contexts: make(map[interface{}]bool)}
+	h := &graphqlhttp.Handler{
+		Schema:           graphql.MustParseSchema(`type Query { slow: Int! }`, res),
+		BatchConcurrency: 2,
+	}
+	// Every request gets its own context value, all operations of a batch must observe the same one.
+	withValue := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey("request"), new(int))))
+	})
+
+	w := serve(withValue, request{
+		method: http.MethodPost,
+		target: "/",
+		header: map[string]string{"Content-Type": "application/json"},
+		body:   `[{"query":"{ slow }"},{"query":"{ slow }"},{"query":"{ slow }"},{"query":"{ slow }"},{"query":"{ slow }"}]`,
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if res.max != 2 {
+		t.Errorf("got %d concurrent operations, want 2", res.max)
+	}
+	if len(res.contexts) != 1 {
+		t.Errorf("got %d different request contexts, want 1", len(res.contexts))
+	}
+}
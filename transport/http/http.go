@@ -0,0 +1,431 @@
+// Package http implements the GraphQL over HTTP specification.
+// See https://graphql.github.io/graphql-over-http/draft/.
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/query"
+	"github.com/graph-gophers/graphql-go/transport/sse"
+)
+
+// Media types supported by the handler.
+const (
+	MediaTypeGraphQLResponse = "application/graphql-response+json"
+	MediaTypeJSON            = "application/json"
+	MediaTypeGraphQL         = "application/graphql"
+	MediaTypeEventStream     = "text/event-stream"
+)
+
+// DefaultMaxBodySize is the maximum size of a request body used when [Handler.MaxBodySize] is not set.
+const DefaultMaxBodySize = 1 << 20
+
+// PreflightHeaders are the headers which mark a request as non-simple for the CSRF prevention. Browsers never send
+// custom headers in simple cross-origin requests, so any of these headers proves that a CORS preflight took place.
+var PreflightHeaders = []string{"GraphQL-Require-Preflight", "Apollo-Require-Preflight", "X-Apollo-Operation-Name"}
+
+// Handler is an http.Handler which executes GraphQL operations following the GraphQL over HTTP specification.
+//
+// Queries can be sent with GET and POST requests, mutations only with POST requests. POST request bodies are
+// accepted as application/json or application/graphql. The response media type is negotiated using the Accept
+// header: application/graphql-response+json responses use 4xx status codes for requests which fail before
+// execution, while application/json responses use 200 for every well-formed request. Requests accepting
+// text/event-stream are served with Server-Sent Events by [sse.Handler], which also supports subscriptions. The
+// requests of its single connection mode, i.e. PUT requests and requests carrying a stream token, are passed to it
+// as well.
+//
+// File uploads are supported with multipart/form-data requests following the GraphQL multipart request
+// specification. The uploaded files are passed to the resolvers as [graphql.Upload] values.
+//
+// Automatic persisted queries are resolved by [graphql.Schema.ExecRequest], requests may omit the query if they
+// carry the hash of a registered one in extensions.persistedQuery.
+//
+// POST requests may contain a JSON array of operations, which are executed concurrently and answered with a JSON
+// array of responses in the same order. All operations of a batch share the context of the request.
+//
+// Requests accepting multipart/mixed are executed with [graphql.Schema.ExecIncremental], which delivers the
+// payloads of fragments marked with @defer and of lists marked with @stream as separate parts of the response.
+// Batched operations are always answered with a single JSON response.
+type Handler struct {
+	Schema *graphql.Schema
+	// MaxBodySize is the maximum allowed size of a request body in bytes. It defaults to [DefaultMaxBodySize].
+	// A negative value disables the limit.
+	MaxBodySize int64
+	// DisableCSRFPrevention disables the rejection of requests which a browser could send cross-origin without a
+	// CORS preflight, i.e. requests without a non-simple content type and without one of the [PreflightHeaders].
+	// Note that multipart/form-data is a simple content type, so file upload clients must send a preflight header.
+	DisableCSRFPrevention bool
+	// MaxUploadSize is the maximum allowed size of a multipart request body in bytes. It defaults to
+	// [DefaultMaxUploadSize]. A negative value disables the limit.
+	MaxUploadSize int64
+	// MaxFileSize is the maximum allowed size of a single uploaded file in bytes. The default is 0 which
+	// limits files by MaxUploadSize only.
+	MaxFileSize int64
+	// UploadMemory is the number of bytes of a multipart request kept in memory. Larger uploads are spilled over
+	// to temporary files, which are removed once the operation completes. It defaults to [DefaultUploadMemory].
+	UploadMemory int64
+	// MaxBatchSize is the maximum number of operations in a batched request. It defaults to [DefaultMaxBatchSize].
+	// A negative value disables batching.
+	MaxBatchSize int
+	// BatchConcurrency is the maximum number of operations of a batch which are executed at the same time.
+	// The default is 0 which executes all operations of a batch concurrently.
+	BatchConcurrency int
+
+	sseOnce sync.Once
+	sse     *sse.Handler // serves the event streams, it holds the reserved streams of the single connection mode
+}
+
+// requestError is an error which is reported to the client before the operation is executed.
+type requestError struct {
+	status int
+	msg    string
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
+func newRequestError(status int, format string, a ...interface{}) *requestError {
+	return &requestError{status: status, msg: fmt.Sprintf(format, a...)}
+}
+
+var errMutationOverGet = newRequestError(nethttp.StatusMethodNotAllowed, "mutations can only be executed with POST requests")
+
+// request holds the operations of an HTTP request, which is a single one unless the client sent a batch.
+type request struct {
+	operations []*graphql.Request
+	batch      bool
+	closers    []io.Closer
+}
+
+func single(p *graphql.Request) *request {
+	return &request{operations: []*graphql.Request{p}}
+}
+
+func (req *request) close() {
+	for _, c := range req.closers {
+		c.Close()
+	}
+}
+
+func (h *Handler) ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request) {
+	mediaType, ok := negotiate(r.Header.Get("Accept"))
+	if !ok && !acceptsEventStream(r) && !acceptsMultipartMixed(r) {
+		writeError(w, MediaTypeJSON, newRequestError(nethttp.StatusNotAcceptable, "none of the accepted media types are supported, use %s or %s", MediaTypeGraphQLResponse, MediaTypeJSON))
+		return
+	}
+	if !ok {
+		mediaType = MediaTypeJSON
+	}
+
+	if !h.DisableCSRFPrevention && !preflighted(r) {
+		writeError(w, mediaType, newRequestError(nethttp.StatusBadRequest, "this request has been blocked as a potential Cross-Site Request Forgery (CSRF), either specify a Content-Type header other than %s or set one of the headers %s", "application/x-www-form-urlencoded, multipart/form-data or text/plain", strings.Join(PreflightHeaders, ", ")))
+		return
+	}
+
+	if acceptsEventStream(r) || singleConnection(r) {
+		if r.Method == nethttp.MethodGet {
+			if p, err := readQueryParams(r); err == nil && h.Schema.OperationType(r.Context(), p) == query.Mutation {
+				w.Header().Set("Allow", nethttp.MethodPost)
+				writeError(w, mediaType, errMutationOverGet)
+				return
+			}
+		}
+		h.eventStream().ServeHTTP(w, r)
+		return
+	}
+
+	req, reqErr := h.readRequest(w, r)
+	if r.MultipartForm != nil {
+		defer r.MultipartForm.RemoveAll()
+	}
+	if reqErr != nil {
+		writeError(w, mediaType, reqErr)
+		return
+	}
+	defer req.close()
+
+	if req.batch {
+		writeJSON(w, mediaType, nethttp.StatusOK, h.execBatch(r.Context(), req.operations))
+		return
+	}
+	p := req.operations[0]
+	if acceptsMultipartMixed(r) {
+		h.writeIncremental(w, r, p)
+		return
+	}
+	resp := h.Schema.ExecBuffered(r.Context(), p)
+	defer resp.Release()
+	writeResponse(w, mediaType, resp)
+}
+
+// eventStream returns the handler of the event streams, which is created with the first one.
+func (h *Handler) eventStream() *sse.Handler {
+	h.sseOnce.Do(func() {
+		limit := h.MaxBodySize
+		if limit == 0 {
+			limit = DefaultMaxBodySize
+		}
+		h.sse = &sse.Handler{Schema: h.Schema, MaxBodySize: limit}
+	})
+	return h.sse
+}
+
+func (h *Handler) readRequest(w nethttp.ResponseWriter, r *nethttp.Request) (*request, *requestError) {
+	var req *request
+	var err *requestError
+	switch r.Method {
+	case nethttp.MethodGet:
+		var p *graphql.Request
+		p, err = readQueryParams(r)
+		if err == nil && h.Schema.OperationType(r.Context(), p) == query.Mutation {
+			w.Header().Set("Allow", nethttp.MethodPost)
+			err = errMutationOverGet
+		}
+		if err == nil {
+			req = single(p)
+		}
+	case nethttp.MethodPost:
+		contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		limit, def := h.MaxBodySize, int64(DefaultMaxBodySize)
+		if contentType == "multipart/form-data" {
+			limit, def = h.MaxUploadSize, DefaultMaxUploadSize
+		}
+		if limit == 0 {
+			limit = def
+		}
+		if limit > 0 {
+			r.Body = nethttp.MaxBytesReader(w, r.Body, limit)
+		}
+		if contentType == "multipart/form-data" {
+			req, err = h.readMultipart(r)
+		} else {
+			req, err = readBody(r)
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		err = newRequestError(nethttp.StatusMethodNotAllowed, "method %s is not allowed, use GET or POST", r.Method)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.checkRequest(req); err != nil {
+		req.close()
+		return nil, err
+	}
+	return req, nil
+}
+
+func (h *Handler) checkRequest(req *request) *requestError {
+	if !req.batch {
+		if !hasQuery(req.operations[0]) {
+			return newRequestError(nethttp.StatusBadRequest, "the query parameter is missing")
+		}
+		return nil
+	}
+
+	max := h.MaxBatchSize
+	if max == 0 {
+		max = DefaultMaxBatchSize
+	}
+	switch {
+	case max < 0:
+		return newRequestError(nethttp.StatusBadRequest, "batched operations are not supported")
+	case len(req.operations) == 0:
+		return newRequestError(nethttp.StatusBadRequest, "the batch must contain at least one operation")
+	case len(req.operations) > max:
+		return newRequestError(nethttp.StatusRequestEntityTooLarge, "the batch contains %d operations, the maximum is %d", len(req.operations), max)
+	}
+	for i, p := range req.operations {
+		if p == nil || !hasQuery(p) {
+			return newRequestError(nethttp.StatusBadRequest, "the query parameter of operation %d is missing", i)
+		}
+	}
+	return nil
+}
+
+func readQueryParams(r *nethttp.Request) (*graphql.Request, *requestError) {
+	q := r.URL.Query()
+	p := &graphql.Request{
+		Query:         q.Get("query"),
+		OperationName: q.Get("operationName"),
+		DocumentID:    q.Get("documentId"),
+	}
+	if v := q.Get("variables"); v != "" {
+		if err := json.Unmarshal([]byte(v), &p.Variables); err != nil {
+			return nil, newRequestError(nethttp.StatusBadRequest, "the variables parameter must be a JSON object: %s", err)
+		}
+	}
+	if v := q.Get("extensions"); v != "" {
+		if err := json.Unmarshal([]byte(v), &p.Extensions); err != nil {
+			return nil, newRequestError(nethttp.StatusBadRequest, "the extensions parameter must be a JSON object: %s", err)
+		}
+	}
+	return p, nil
+}
+
+func readBody(r *nethttp.Request) (*request, *requestError) {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch contentType {
+	case MediaTypeJSON:
+		var raw json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			return nil, bodyError(err)
+		}
+		if isBatch(raw) {
+			req := &request{batch: true}
+			if err := json.Unmarshal(raw, &req.operations); err != nil {
+				return nil, bodyError(err)
+			}
+			return req, nil
+		}
+		var p graphql.Request
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, bodyError(err)
+		}
+		return single(&p), nil
+
+	case MediaTypeGraphQL:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, bodyError(err)
+		}
+		p, reqErr := readQueryParams(r)
+		if reqErr != nil {
+			return nil, reqErr
+		}
+		p.Query = string(body)
+		return single(p), nil
+
+	default:
+		return nil, newRequestError(nethttp.StatusUnsupportedMediaType, "unsupported content type %q, use %s, %s or multipart/form-data", contentType, MediaTypeJSON, MediaTypeGraphQL)
+	}
+}
+
+func bodyError(err error) *requestError {
+	// MaxBytesReader doesn't expose a typed error in all supported Go versions.
+	if strings.Contains(err.Error(), "request body too large") {
+		return newRequestError(nethttp.StatusRequestEntityTooLarge, "the request body is too large")
+	}
+	return newRequestError(nethttp.StatusBadRequest, "the request body is not valid JSON: %s", err)
+}
+
+// hasQuery reports whether the request contains a query or refers to a trusted or persisted one.
+func hasQuery(p *graphql.Request) bool {
+	_, persisted := p.Extensions["persistedQuery"]
+	return p.Query != "" || p.DocumentID != "" || persisted
+}
+
+// preflighted reports whether a browser must have sent a CORS preflight request before this request.
+func preflighted(r *nethttp.Request) bool {
+	switch r.Method {
+	case nethttp.MethodGet, nethttp.MethodHead, nethttp.MethodPost:
+	default:
+		// Browsers send a preflight for all other methods.
+		return true
+	}
+	for _, h := range PreflightHeaders {
+		if r.Header.Get(h) != "" {
+			return true
+		}
+	}
+
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		// Browsers only send a valid simple content type without a preflight.
+		return r.Header.Get("Content-Type") != ""
+	}
+	switch contentType {
+	case "application/x-www-form-urlencoded", "multipart/form-data", "text/plain":
+		return false
+	}
+	return true
+}
+
+// negotiate returns the response media type with the highest preference in the Accept header. A missing
+// Accept header or a wildcard are treated as application/json for compatibility with legacy clients.
+func negotiate(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return MediaTypeJSON, true
+	}
+
+	var best string
+	bestQ := 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, mediaParams, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := mediaParams["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		var candidate string
+		switch mediaType {
+		case MediaTypeGraphQLResponse, MediaTypeJSON:
+			candidate = mediaType
+		case "application/*", "*/*":
+			candidate = MediaTypeJSON
+		default:
+			continue
+		}
+		if q > bestQ || (q == bestQ && candidate == MediaTypeGraphQLResponse) {
+			best, bestQ = candidate, q
+		}
+	}
+	return best, best != ""
+}
+
+// singleConnection reports whether the request belongs to the single connection mode of [sse.Handler], i.e. it
+// reserves a stream or carries the token of a reserved one.
+func singleConnection(r *nethttp.Request) bool {
+	return r.Method == nethttp.MethodPut || r.Header.Get(sse.TokenHeader) != "" || r.URL.Query().Get("token") != ""
+}
+
+func acceptsEventStream(r *nethttp.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mediaType == MediaTypeEventStream {
+			return true
+		}
+	}
+	return false
+}
+
+func writeError(w nethttp.ResponseWriter, mediaType string, err *requestError) {
+	writeJSON(w, mediaType, err.status, &graphql.Response{Errors: []*errors.QueryError{errors.Errorf("%s", err.msg)}})
+}
+
+func writeResponse(w nethttp.ResponseWriter, mediaType string, resp *graphql.Response) {
+	status := nethttp.StatusOK
+	// A response without data means the request failed before execution, e.g. because of parse or validation errors.
+	if mediaType == MediaTypeGraphQLResponse && resp.Data == nil {
+		status = nethttp.StatusBadRequest
+	}
+	w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+	w.WriteHeader(status)
+	resp.WriteTo(w)
+}
+
+func writeJSON(w nethttp.ResponseWriter, mediaType string, status int, resp interface{}) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		nethttp.Error(w, err.Error(), nethttp.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(data)
+}
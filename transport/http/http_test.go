@@ -0,0 +1,451 @@
+package http_test
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/example/starwars"
+	graphqlhttp "github.com/graph-gophers/graphql-go/transport/http"
+	"github.com/graph-gophers/graphql-go/transport/sse"
+)
+
+var starwarsSchema = graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{})
+
+type request struct {
+	method string
+	target string
+	header map[string]string
+	body   string
+}
+
+type response struct {
+	status      int
+	contentType string
+	body        string
+}
+
+func serve(h http.Handler, req request) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(req.method, req.target, strings.NewReader(req.body))
+	for k, v := range req.header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	const heroQuery = `{"query":"{ hero { name } }"}`
+	const heroData = `{"data":{"hero":{"name":"R2-D2"}}}`
+	const jsonCT = "application/json; charset=utf-8"
+	const graphqlCT = "application/graphql-response+json; charset=utf-8"
+
+	tests := map[string]struct {
+		handler *graphqlhttp.Handler
+		req     request
+		want    response
+	}{
+		"post_json": {
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "application/json"},
+				body:   heroQuery,
+			},
+			want: response{status: 200, contentType: jsonCT, body: heroData},
+		},
+		"post_graphql": {
+			req: request{
+				method: http.MethodPost,
+				target: "/?operationName=Hero",
+				header: map[string]string{"Content-Type": "application/graphql", "Accept": "application/graphql-response+json"},
+				body:   `query Hero { hero { name } } query Other { __typename }`,
+			},
+			want: response{status: 200, contentType: graphqlCT, body: heroData},
+		},
+		"get_query": {
+			req: request{
+				method: http.MethodGet,
+				target: "/?query=" + url.QueryEscape(`query($episode: Episode) { hero(episode: $episode) { name } }`) + "&variables=" + url.QueryEscape(`{"episode":"EMPIRE"}`),
+				header: map[string]string{"GraphQL-Require-Preflight": "1"},
+			},
+			want: response{status: 200, contentType: jsonCT, body: `{"data":{"hero":{"name":"Luke Skywalker"}}}`},
+		},
+		"get_mutation": {
+			req: request{
+				method: http.MethodGet,
+				target: "/?query=" + url.QueryEscape(`mutation { createReview(episode: JEDI, review: {stars: 5}) { stars } }`),
+				header: map[string]string{"GraphQL-Require-Preflight": "1"},
+			},
+			want: response{status: 405, contentType: jsonCT, body: `{"errors":[{"message":"mutations can only be executed with POST requests"}]}`},
+		},
+		"parse_error_graphql_response": {
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "application/json", "Accept": "application/graphql-response+json"},
+				body:   `{"query":"{"}`,
+			},
+			want: response{status: 400, contentType: graphqlCT, body: `{"errors":[{"message":"syntax error: unexpected \"\", expecting Ident","locations":[{"line":1,"column":2}]}]}`},
+		},
+		"validation_error_graphql_response": {
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "application/json", "Accept": "application/graphql-response+json, application/json;q=0.9"},
+				body:   `{"query":"{ unknown }"}`,
+			},
+			want: response{status: 400, contentType: graphqlCT, body: `{"errors":[{"message":"Cannot query field \"unknown\" on type \"Query\".","locations":[{"line":1,"column":3}]}]}`},
+		},
+		"validation_error_json": {
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "application/json", "Accept": "application/json"},
+				body:   `{"query":"{ unknown }"}`,
+			},
+			want: response{status: 200, contentType: jsonCT, body: `{"errors":[{"message":"Cannot query field \"unknown\" on type \"Query\".","locations":[{"line":1,"column":3}]}]}`},
+		},
+		"accept_preference": {
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "application/json", "Accept": "application/graphql-response+json;q=0.5, application/json"},
+				body:   heroQuery,
+			},
+			want: response{status: 200, contentType: jsonCT, body: heroData},
+		},
+		"not_acceptable": {
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "application/json", "Accept": "text/html"},
+				body:   heroQuery,
+			},
+			want: response{status: 406, contentType: jsonCT, body: `{"errors":[{"message":"none of the accepted media types are supported, use application/graphql-response+json or application/json"}]}`},
+		},
+		"unsupported_media_type": {
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "application/xml", "GraphQL-Require-Preflight": "1"},
+				body:   `<query/>`,
+			},
+			want: response{status: 415, contentType: jsonCT, body: `{"errors":[{"message":"unsupported content type \"application/xml\", use application/json, application/graphql or multipart/form-data"}]}`},
+		},
+		"malformed_json": {
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "application/json"},
+				body:   `{"query":`,
+			},
+			want: response{status: 400, contentType: jsonCT, body: `{"errors":[{"message":"the request body is not valid JSON: unexpected EOF"}]}`},
+		},
+		"missing_query": {
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "application/json"},
+				body:   `{}`,
+			},
+			want: response{status: 400, contentType: jsonCT, body: `{"errors":[{"message":"the query parameter is missing"}]}`},
+		},
+		"body_too_large": {
+			handler: &graphqlhttp.Handler{Schema: starwarsSchema, MaxBodySize: 10},
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "application/json"},
+				body:   heroQuery,
+			},
+			want: response{status: 413, contentType: jsonCT, body: `{"errors":[{"message":"the request body is too large"}]}`},
+		},
+		"method_not_allowed": {
+			req: request{
+				method: http.MethodPatch,
+				header: map[string]string{"Content-Type": "application/json"},
+				body:   heroQuery,
+			},
+			want: response{status: 405, contentType: jsonCT, body: `{"errors":[{"message":"method PATCH is not allowed, use GET or POST"}]}`},
+		},
+		"csrf_simple_get": {
+			req: request{
+				method: http.MethodGet,
+				target: "/?query=" + url.QueryEscape(`{ hero { name } }`),
+			},
+			want: response{status: 400, contentType: jsonCT, body: `{"errors":[{"message":"this request has been blocked as a potential Cross-Site Request Forgery (CSRF), either specify a Content-Type header other than application/x-www-form-urlencoded, multipart/form-data or text/plain or set one of the headers GraphQL-Require-Preflight, Apollo-Require-Preflight, X-Apollo-Operation-Name"}]}`},
+		},
+		"csrf_simple_post": {
+			req: request{
+				method: http.MethodPost,
+				header: map[string]string{"Content-Type": "text/plain"},
+				body:   heroQuery,
+			},
+			want: response{status: 400, contentType: jsonCT, body: `{"errors":[{"message":"this request has been blocked as a potential Cross-Site Request Forgery (CSRF), either specify a Content-Type header other than application/x-www-form-urlencoded, multipart/form-data or text/plain or set one of the headers GraphQL-Require-Preflight, Apollo-Require-Preflight, X-Apollo-Operation-Name"}]}`},
+		},
+		"csrf_disabled": {
+			handler: &graphqlhttp.Handler{Schema: starwarsSchema, DisableCSRFPrevention: true},
+			req: request{
+				method: http.MethodGet,
+				target: "/?query=" + url.QueryEscape(`{ hero { name } }`),
+			},
+			want: response{status: 200, contentType: jsonCT, body: heroData},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			h := tt.handler
+			if h == nil {
+				h = &graphqlhttp.Handler{Schema: starwarsSchema}
+			}
+			if tt.req.target == "" {
+				tt.req.target = "/"
+			}
+			w := serve(h, tt.req)
+			if w.Code != tt.want.status {
+				t.Errorf("got status %d, want %d", w.Code, tt.want.status)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.want.contentType {
+				t.Errorf("got content type %q, want %q", ct, tt.want.contentType)
+			}
+			if body := w.Body.String(); body != tt.want.body {
+				t.Errorf("got body %s, want %s", body, tt.want.body)
+			}
+		})
+	}
+}
+
+func TestHandler_EventStream(t *testing.T) {
+	srv := httptest.NewServer(&graphqlhttp.Handler{Schema: starwarsSchema})
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(`{"query":"{ hero { name } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var lines []string
+	s := bufio.NewScanner(resp.Body)
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	got := strings.Join(lines, "\n")
+	want := "event: next\ndata: {\"data\":{\"hero\":{\"name\":\"R2-D2\"}}}\n\nevent: complete\ndata: \n"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandler_EventStreamSingleConnection(t *testing.T) {
+	srv := httptest.NewServer(&graphqlhttp.Handler{Schema: starwarsSchema})
+	defer srv.Close()
+
+	do := func(method, body string, header map[string]string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL, strings.NewReader(body))
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := do(http.MethodPut, "", nil)
+	token, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("got status %d reserving a stream: %s", resp.StatusCode, token)
+	}
+
+	stream := do(http.MethodGet, "", map[string]string{sse.TokenHeader: string(token), "Accept": "text/event-stream", "GraphQL-Require-Preflight": "1"})
+	defer stream.Body.Close()
+	if stream.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d opening the stream", stream.StatusCode)
+	}
+
+	resp = do(http.MethodPost, `{"query":"{ hero { name } }","extensions":{"operationId":"1"}}`, map[string]string{sse.TokenHeader: string(token), "Content-Type": "application/json"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("got status %d starting the operation", resp.StatusCode)
+	}
+
+	r := bufio.NewReader(stream.Body)
+	var lines []string
+	for len(lines) < 2 {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line = strings.TrimSuffix(line, "\n"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	want := []string{"event: next", `data: {"id":"1","payload":{"data":{"hero":{"name":"R2-D2"}}}}`}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got %q, want %q", lines, want)
+	}
+}
+
+func TestHandler_EventStreamBodyTooLarge(t *testing.T) {
+	h := &graphqlhttp.Handler{Schema: starwarsSchema, MaxBodySize: 10}
+	reserve := httptest.NewRecorder()
+	h.ServeHTTP(reserve, httptest.NewRequest(http.MethodPut, "/", nil))
+	if reserve.Code != http.StatusCreated {
+		t.Fatalf("got status %d reserving a stream", reserve.Code)
+	}
+
+	tests := map[string]map[string]string{
+		"distinct_connections": {"Accept": "text/event-stream"},
+		"single_connection":    {sse.TokenHeader: reserve.Body.String()},
+	}
+	for name, header := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"query":"{ hero { name } }","extensions":{"operationId":"1"}}`))
+			r.Header.Set("Content-Type", "application/json")
+			for k, v := range header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("got status %d: %s, want %d", w.Code, w.Body, http.StatusRequestEntityTooLarge)
+			}
+		})
+	}
+}
+
+func TestHandler_PersistedQuery(t *testing.T) {
+	h := &graphqlhttp.Handler{Schema: graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{})}
+	get := func(query, hash string) *httptest.ResponseRecorder {
+		ext := `{"persistedQuery":{"version":1,"sha256Hash":"` + hash + `"}}`
+		target := "/?extensions=" + url.QueryEscape(ext)
+		if query != "" {
+			target += "&query=" + url.QueryEscape(query)
+		}
+		return serve(h, request{method: http.MethodGet, target: target, header: map[string]string{"GraphQL-Require-Preflight": "1"}})
+	}
+
+	const heroQuery = `{ hero { name } }`
+	sum := sha256.Sum256([]byte(heroQuery))
+	hash := hex.EncodeToString(sum[:])
+
+	w := get("", hash)
+	if want := `{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`; w.Body.String() != want {
+		t.Fatalf("got %s, want %s", w.Body, want)
+	}
+	if w := get(heroQuery, hash); w.Code != http.StatusOK {
+		t.Fatalf("got status %d registering the query: %s", w.Code, w.Body)
+	}
+	if w := get("", hash); w.Body.String() != `{"data":{"hero":{"name":"R2-D2"}}}` {
+		t.Fatalf("got %s for the persisted query", w.Body)
+	}
+
+	// A registered mutation must not be executable with a GET request.
+	const mutation = `mutation { createReview(episode: JEDI, review: {stars: 5}) { stars } }`
+	sum = sha256.Sum256([]byte(mutation))
+	mutationHash := hex.EncodeToString(sum[:])
+	ext := `{"persistedQuery":{"version":1,"sha256Hash":"` + mutationHash + `"}}`
+	w = serve(h, request{
+		method: http.MethodPost,
+		target: "/",
+		header: map[string]string{"Content-Type": "application/json"},
+		body:   `{"query":` + strconv.Quote(mutation) + `,"extensions":` + ext + `}`,
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d registering the mutation: %s", w.Code, w.Body)
+	}
+	if w := get("", mutationHash); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("got status %d for a persisted mutation over GET, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandler_GetParsedBySchema(t *testing.T) {
+	cache := graphql.NewDocumentCache(10)
+	h := &graphqlhttp.Handler{Schema: graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.MaxQueryLength(40), graphql.UseDocumentCache(cache))}
+	get := func(query string) *httptest.ResponseRecorder {
+		return serve(h, request{method: http.MethodGet, target: "/?query=" + url.QueryEscape(query), header: map[string]string{"GraphQL-Require-Preflight": "1"}})
+	}
+
+	// an oversized document is rejected without being parsed
+	w := get(`mutation { createReview(episode: JEDI, review: {stars: 5}) { stars } }`)
+	if want := `{"errors":[{"message":"query length 70 exceeds the maximum allowed query length of 40 bytes"}]}`; w.Body.String() != want {
+		t.Fatalf("got %s, want %s", w.Body, want)
+	}
+	if stats := cache.Stats(); stats.Misses != 0 {
+		t.Fatalf("got %d parsed documents, want 0", stats.Misses)
+	}
+
+	// the document parsed for the operation type is executed from the cache
+	if w := get(`{ hero { name } }`); w.Body.String() != `{"data":{"hero":{"name":"R2-D2"}}}` {
+		t.Fatalf("got %s", w.Body)
+	}
+	if stats := cache.Stats(); stats.Misses != 1 || stats.Hits != 1 {
+		t.Fatalf("got %d misses and %d hits, want the document to be parsed once", stats.Misses, stats.Hits)
+	}
+	if w := get(`mutation { createReview }`); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("got status %d for a mutation over GET, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandler_GetTrustedDocuments(t *testing.T) {
+	const mutation = `mutation { createReview(episode: JEDI, review: {stars: 5}) { stars } }`
+	cache := graphql.NewDocumentCache(10)
+	trusted := graphql.NewTrustedDocuments(map[string]string{"review": mutation})
+	h := &graphqlhttp.Handler{Schema: graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.UseTrustedDocuments(trusted), graphql.UseDocumentCache(cache))}
+	get := func(params string) *httptest.ResponseRecorder {
+		return serve(h, request{method: http.MethodGet, target: "/?" + params, header: map[string]string{"GraphQL-Require-Preflight": "1"}})
+	}
+
+	// an ad-hoc document is rejected before it is parsed
+	w := get("query=" + url.QueryEscape(`mutation { createReview(episode: EMPIRE, review: {stars: 1}) { stars } }`))
+	if want := `{"errors":[{"message":"only trusted documents may be executed"}]}`; w.Body.String() != want {
+		t.Fatalf("got %s, want %s", w.Body, want)
+	}
+	if stats := cache.Stats(); stats.Misses != 0 {
+		t.Fatalf("got %d parsed documents, want 0", stats.Misses)
+	}
+
+	if w := get("documentId=review"); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("got status %d for a trusted mutation over GET, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandler_Incremental(t *testing.T) {
+	s := graphql.MustParseSchema(graphql.IncrementalDeliveryDirectives+starwars.Schema, &starwars.Resolver{})
+	srv := httptest.NewServer(&graphqlhttp.Handler{Schema: s})
+	defer srv.Close()
+
+	body := `{"query":"{ hero { name ... @defer(label: \"friends\") { friends { name } } } }"}`
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "multipart/mixed; deferSpec=20220824, application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct, want := resp.Header.Get("Content-Type"), `multipart/mixed; boundary="-"; deferSpec=20220824`; ct != want {
+		t.Fatalf("got content type %q, want %q", ct, want)
+	}
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const part = "\r\n---\r\nContent-Type: application/json; charset=utf-8\r\n\r\n"
+	want := part + `{"data":{"hero":{"name":"R2-D2"}},"hasNext":true}` +
+		part + `{"incremental":[{"data":{"friends":[{"name":"Luke Skywalker"},{"name":"Han Solo"},{"name":"Leia Organa"}]},"path":["hero"],"label":"friends"}],"hasNext":false}` +
+		"\r\n-----\r\n"
+	if string(got) != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
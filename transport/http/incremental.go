@@ -0,0 +1,47 @@
+package http
+
+import (
+	"encoding/json"
+	"mime"
+	nethttp "net/http"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// MediaTypeMultipartMixed is the media type of responses with incremental delivery of @defer and @stream payloads.
+const MediaTypeMultipartMixed = "multipart/mixed"
+
+// multipartBoundary is the boundary used by the incremental delivery specification, which clients expect.
+const multipartBoundary = "-"
+
+// acceptsMultipartMixed reports whether the client accepts incremental delivery with multipart/mixed responses.
+func acceptsMultipartMixed(r *nethttp.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mediaType == MediaTypeMultipartMixed {
+			return true
+		}
+	}
+	return false
+}
+
+// writeIncremental executes the request with [graphql.Schema.ExecIncremental] and writes every payload as a part
+// of a multipart/mixed response, which is flushed as soon as the payload is available.
+func (h *Handler) writeIncremental(w nethttp.ResponseWriter, r *nethttp.Request, p *graphql.Request) {
+	flusher, _ := w.(nethttp.Flusher)
+	w.Header().Set("Content-Type", MediaTypeMultipartMixed+`; boundary="`+multipartBoundary+`"; deferSpec=20220824`)
+	w.WriteHeader(nethttp.StatusOK)
+
+	for resp := range h.Schema.ExecIncremental(r.Context(), p) {
+		data, err := json.Marshal(resp)
+		if err != nil {
+			continue
+		}
+		w.Write([]byte("\r\n--" + multipartBoundary + "\r\nContent-Type: application/json; charset=utf-8\r\n\r\n"))
+		w.Write(data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	w.Write([]byte("\r\n--" + multipartBoundary + "--\r\n"))
+}
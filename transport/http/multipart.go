@@ -0,0 +1,186 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+const (
+	// DefaultMaxUploadSize is the maximum size of a multipart request body used when [Handler.MaxUploadSize] is not set.
+	DefaultMaxUploadSize = 32 << 20
+	// DefaultUploadMemory is the number of bytes of a multipart request kept in memory when [Handler.UploadMemory]
+	// is not set. The remainder is spilled over to temporary files.
+	DefaultUploadMemory = 1 << 20
+)
+
+// readMultipart reads a request following the GraphQL multipart request specification.
+// See https://github.com/jaydenseric/graphql-multipart-request-spec.
+func (h *Handler) readMultipart(r *nethttp.Request) (*request, *requestError) {
+	memory := h.UploadMemory
+	if memory <= 0 {
+		memory = DefaultUploadMemory
+	}
+	if err := r.ParseMultipartForm(memory); err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			return nil, newRequestError(nethttp.StatusRequestEntityTooLarge, "the request body is too large")
+		}
+		return nil, newRequestError(nethttp.StatusBadRequest, "invalid multipart request: %s", err)
+	}
+	form := r.MultipartForm
+
+	if len(form.Value["operations"]) == 0 {
+		return nil, newRequestError(nethttp.StatusBadRequest, "the operations field of the multipart request is missing")
+	}
+	var operations interface{}
+	if err := json.Unmarshal([]byte(form.Value["operations"][0]), &operations); err != nil {
+		return nil, newRequestError(nethttp.StatusBadRequest, "the operations field is not valid JSON: %s", err)
+	}
+
+	var fileMap map[string][]string
+	if len(form.Value["map"]) != 0 {
+		if err := json.Unmarshal([]byte(form.Value["map"][0]), &fileMap); err != nil {
+			return nil, newRequestError(nethttp.StatusBadRequest, "the map field is not valid JSON: %s", err)
+		}
+	}
+
+	var closers []io.Closer
+	closeAll := func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	}
+	for key, paths := range fileMap {
+		files := form.File[key]
+		if len(files) == 0 {
+			closeAll()
+			return nil, newRequestError(nethttp.StatusBadRequest, "the file %q of the map field is missing", key)
+		}
+		fh := files[0]
+		if h.MaxFileSize > 0 && fh.Size > h.MaxFileSize {
+			closeAll()
+			return nil, newRequestError(nethttp.StatusRequestEntityTooLarge, "the file %q exceeds the maximum file size of %d bytes", key, h.MaxFileSize)
+		}
+
+		for _, path := range paths {
+			// Every path gets its own handle, so that reading one doesn't move the offset of the others.
+			f, err := fh.Open()
+			if err != nil {
+				closeAll()
+				return nil, newRequestError(nethttp.StatusInternalServerError, "could not open the file %q: %s", key, err)
+			}
+			closers = append(closers, f)
+
+			upload := &graphql.Upload{
+				File:        f,
+				Filename:    fh.Filename,
+				ContentType: fh.Header.Get("Content-Type"),
+				Size:        fh.Size,
+			}
+			if err := setPath(operations, path, upload); err != nil {
+				closeAll()
+				return nil, newRequestError(nethttp.StatusBadRequest, "%s", err)
+			}
+		}
+	}
+
+	req := &request{closers: closers}
+	switch operations := operations.(type) {
+	case map[string]interface{}:
+		p, err := requestFromMap(operations)
+		if err != nil {
+			closeAll()
+			return nil, newRequestError(nethttp.StatusBadRequest, "%s", err)
+		}
+		req.operations = []*graphql.Request{p}
+
+	case []interface{}:
+		req.batch = true
+		for _, op := range operations {
+			m, ok := op.(map[string]interface{})
+			if !ok {
+				closeAll()
+				return nil, newRequestError(nethttp.StatusBadRequest, "the operations of a batch must be JSON objects")
+			}
+			p, err := requestFromMap(m)
+			if err != nil {
+				closeAll()
+				return nil, newRequestError(nethttp.StatusBadRequest, "%s", err)
+			}
+			req.operations = append(req.operations, p)
+		}
+
+	default:
+		closeAll()
+		return nil, newRequestError(nethttp.StatusBadRequest, "the operations field must be a JSON object or array")
+	}
+	return req, nil
+}
+
+// setPath replaces the value at the dot separated path of the operations, e.g. "variables.files.0".
+func setPath(operations interface{}, path string, v interface{}) error {
+	segments := strings.Split(path, ".")
+	cur := operations
+	for i, seg := range segments {
+		last := i == len(segments)-1
+		switch c := cur.(type) {
+		case map[string]interface{}:
+			if last {
+				c[seg] = v
+				return nil
+			}
+			cur = c[seg]
+
+		case []interface{}:
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 || idx >= len(c) {
+				return fmt.Errorf("invalid file path %q: %q is not a valid list index", path, seg)
+			}
+			if last {
+				c[idx] = v
+				return nil
+			}
+			cur = c[idx]
+
+		default:
+			return fmt.Errorf("invalid file path %q", path)
+		}
+	}
+	return fmt.Errorf("invalid file path %q", path)
+}
+
+func requestFromMap(m map[string]interface{}) (*graphql.Request, error) {
+	p := &graphql.Request{}
+	var ok bool
+	if v := m["query"]; v != nil {
+		if p.Query, ok = v.(string); !ok {
+			return nil, fmt.Errorf("the query must be a string")
+		}
+	}
+	if v := m["documentId"]; v != nil {
+		if p.DocumentID, ok = v.(string); !ok {
+			return nil, fmt.Errorf("the documentId must be a string")
+		}
+	}
+	if v := m["operationName"]; v != nil {
+		if p.OperationName, ok = v.(string); !ok {
+			return nil, fmt.Errorf("the operationName must be a string")
+		}
+	}
+	if v := m["variables"]; v != nil {
+		if p.Variables, ok = v.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("the variables must be a JSON object")
+		}
+	}
+	if v := m["extensions"]; v != nil {
+		if p.Extensions, ok = v.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("the extensions must be a JSON object")
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,226 @@
+package http_test
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	graphqlhttp "github.com/graph-gophers/graphql-go/transport/http"
+)
+
+const uploadSchema = `
+	scalar Upload
+
+	type Query {
+		hello: String!
+	}
+
+	type Mutation {
+		upload(file: Upload!): File!
+		uploadMany(files: [Upload!]!): [File!]!
+	}
+
+	type File {
+		name: String!
+		contentType: String!
+		size: Int!
+		content: String!
+	}
+`
+
+type uploadResolver struct{}
+
+func (*uploadResolver) Hello() string { return "Hello world!" }
+
+func (*uploadResolver) Upload(args struct{ File graphql.Upload }) (*fileResolver, error) {
+	return newFileResolver(args.File)
+}
+
+func (*uploadResolver) UploadMany(args struct{ Files []graphql.Upload }) ([]*fileResolver, error) {
+	var files []*fileResolver
+	for _, u := range args.Files {
+		f, err := newFileResolver(u)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+type fileResolver struct {
+	upload  graphql.Upload
+	content string
+}
+
+func newFileResolver(u graphql.Upload) (*fileResolver, error) {
+	b, err := io.ReadAll(u.File)
+	if err != nil {
+		return nil, err
+	}
+	return &fileResolver{upload: u, content: string(b)}, nil
+}
+
+func (f *fileResolver) Name() string        { return f.upload.Filename }
+func (f *fileResolver) ContentType() string { return f.upload.ContentType }
+func (f *fileResolver) Size() int32         { return int32(f.upload.Size) }
+func (f *fileResolver) Content() string     { return f.content }
+
+type part struct {
+	name     string
+	filename string
+	content  string
+}
+
+func multipartRequest(t *testing.T, parts ...part) (string, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, p := range parts {
+		var w io.Writer
+		var err error
+		if p.filename != "" {
+			w, err = mw.CreateFormFile(p.name, p.filename)
+		} else {
+			w, err = mw.CreateFormField(p.name)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, p.content)
+	}
+	mw.Close()
+	return mw.FormDataContentType(), buf.String()
+}
+
+func TestHandler_Multipart(t *testing.T) {
+	const jsonCT = "application/json; charset=utf-8"
+	s := graphql.MustParseSchema(uploadSchema, &uploadResolver{})
+
+	tests := map[string]struct {
+		handler *graphqlhttp.Handler
+		parts   []part
+		header  map[string]string
+		want    response
+	}{
+		"single_file": {
+			parts: []part{
+				{name: "operations", content: `{"query":"mutation($file: Upload!) { upload(file: $file) { name contentType size content } }","variables":{"file":null}}`},
+				{name: "map", content: `{"0":["variables.file"]}`},
+				{name: "0", filename: "a.txt", content: "Alpha"},
+			},
+			want: response{status: 200, contentType: jsonCT, body: `{"data":{"upload":{"name":"a.txt","contentType":"application/octet-stream","size":5,"content":"Alpha"}}}`},
+		},
+		"file_list": {
+			parts: []part{
+				{name: "operations", content: `{"query":"mutation($files: [Upload!]!) { uploadMany(files: $files) { name content } }","variables":{"files":[null,null,null]}}`},
+				{name: "map", content: `{"0":["variables.files.0","variables.files.2"],"1":["variables.files.1"]}`},
+				{name: "0", filename: "a.txt", content: "Alpha"},
+				{name: "1", filename: "b.txt", content: "Bravo"},
+			},
+			want: response{status: 200, contentType: jsonCT, body: `{"data":{"uploadMany":[{"name":"a.txt","content":"Alpha"},{"name":"b.txt","content":"Bravo"},{"name":"a.txt","content":"Alpha"}]}}`},
+		},
+		"batch": {
+			parts: []part{
+				{name: "operations", content: `[{"query":"mutation($file: Upload!) { upload(file: $file) { name } }","variables":{"file":null}},{"query":"mutation($file: Upload!) { upload(file: $file) { content } }","variables":{"file":null}}]`},
+				{name: "map", content: `{"0":["0.variables.file","1.variables.file"]}`},
+				{name: "0", filename: "a.txt", content: "Alpha"},
+			},
+			want: response{status: 200, contentType: jsonCT, body: `[{"data":{"upload":{"name":"a.txt"}}},{"data":{"upload":{"content":"Alpha"}}}]`},
+		},
+		"spill_over_to_disk": {
+			handler: &graphqlhttp.Handler{Schema: s, UploadMemory: 1},
+			parts: []part{
+				{name: "operations", content: `{"query":"mutation($file: Upload!) { upload(file: $file) { content } }","variables":{"file":null}}`},
+				{name: "map", content: `{"0":["variables.file"]}`},
+				{name: "0", filename: "a.txt", content: string(bytes.Repeat([]byte("a"), 1024))},
+			},
+			want: response{status: 200, contentType: jsonCT, body: `{"data":{"upload":{"content":"` + string(bytes.Repeat([]byte("a"), 1024)) + `"}}}`},
+		},
+		"missing_operations": {
+			parts: []part{
+				{name: "map", content: `{}`},
+			},
+			want: response{status: 400, contentType: jsonCT, body: `{"errors":[{"message":"the operations field of the multipart request is missing"}]}`},
+		},
+		"missing_file": {
+			parts: []part{
+				{name: "operations", content: `{"query":"mutation($file: Upload!) { upload(file: $file) { name } }","variables":{"file":null}}`},
+				{name: "map", content: `{"0":["variables.file"]}`},
+			},
+			want: response{status: 400, contentType: jsonCT, body: `{"errors":[{"message":"the file \"0\" of the map field is missing"}]}`},
+		},
+		"invalid_path": {
+			parts: []part{
+				{name: "operations", content: `{"query":"mutation($file: Upload!) { upload(file: $file) { name } }","variables":{"file":null}}`},
+				{name: "map", content: `{"0":["variables.file.0"]}`},
+				{name: "0", filename: "a.txt", content: "Alpha"},
+			},
+			want: response{status: 400, contentType: jsonCT, body: `{"errors":[{"message":"invalid file path \"variables.file.0\""}]}`},
+		},
+		"file_too_large": {
+			handler: &graphqlhttp.Handler{Schema: s, MaxFileSize: 4},
+			parts: []part{
+				{name: "operations", content: `{"query":"mutation($file: Upload!) { upload(file: $file) { name } }","variables":{"file":null}}`},
+				{name: "map", content: `{"0":["variables.file"]}`},
+				{name: "0", filename: "a.txt", content: "Alpha"},
+			},
+			want: response{status: 413, contentType: jsonCT, body: `{"errors":[{"message":"the file \"0\" exceeds the maximum file size of 4 bytes"}]}`},
+		},
+		"request_too_large": {
+			handler: &graphqlhttp.Handler{Schema: s, MaxUploadSize: 64},
+			parts: []part{
+				{name: "operations", content: `{"query":"mutation($file: Upload!) { upload(file: $file) { name } }","variables":{"file":null}}`},
+				{name: "map", content: `{"0":["variables.file"]}`},
+				{name: "0", filename: "a.txt", content: "Alpha"},
+			},
+			want: response{status: 413, contentType: jsonCT, body: `{"errors":[{"message":"the request body is too large"}]}`},
+		},
+		"variable_without_file": {
+			parts: []part{
+				{name: "operations", content: `{"query":"mutation($file: Upload!) { upload(file: $file) { name } }","variables":{"file":"a.txt"}}`},
+			},
+			want: response{status: 200, contentType: jsonCT, body: `{"errors":[{"message":"wrong type for Upload: string, files must be sent with a multipart request"}],"data":{}}`},
+		},
+		"csrf": {
+			header: map[string]string{"GraphQL-Require-Preflight": ""},
+			parts: []part{
+				{name: "operations", content: `{"query":"{ hello }"}`},
+			},
+			want: response{status: 400, contentType: jsonCT, body: `{"errors":[{"message":"this request has been blocked as a potential Cross-Site Request Forgery (CSRF), either specify a Content-Type header other than application/x-www-form-urlencoded, multipart/form-data or text/plain or set one of the headers GraphQL-Require-Preflight, Apollo-Require-Preflight, X-Apollo-Operation-Name"}]}`},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			h := tt.handler
+			if h == nil {
+				h = &graphqlhttp.Handler{Schema: s}
+			}
+			contentType, body := multipartRequest(t, tt.parts...)
+			header := map[string]string{"Content-Type": contentType, "GraphQL-Require-Preflight": "1"}
+			for k, v := range tt.header {
+				if v == "" {
+					delete(header, k)
+					continue
+				}
+				header[k] = v
+			}
+
+			w := serve(h, request{method: http.MethodPost, target: "/", header: header, body: body})
+			if w.Code != tt.want.status {
+				t.Errorf("got status %d, want %d", w.Code, tt.want.status)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.want.contentType {
+				t.Errorf("got content type %q, want %q", ct, tt.want.contentType)
+			}
+			if body := w.Body.String(); body != tt.want.body {
+				t.Errorf("got body %s, want %s", body, tt.want.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,513 @@
+// Package sse implements the graphql-sse protocol which streams GraphQL operation results as
+// Server-Sent Events. Both the "distinct connections" and the "single connection" modes are supported.
+// See https://github.com/enisdenjo/graphql-sse/blob/master/PROTOCOL.md.
+package sse
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// TokenHeader is the header carrying the stream token in the single connection mode.
+const TokenHeader = "X-GraphQL-Event-Stream-Token"
+
+const (
+	eventNext     = "next"
+	eventComplete = "complete"
+)
+
+const (
+	// DefaultReservationTimeout is the time within which a reserved stream must be opened unless
+	// Handler.ReservationTimeout is set.
+	DefaultReservationTimeout = time.Minute
+	// DefaultMaxReservations is the number of reserved streams which are not open yet unless
+	// Handler.MaxReservations is set.
+	DefaultMaxReservations = 1000
+	// DefaultMaxBodySize is the maximum size of a request body unless Handler.MaxBodySize is set.
+	DefaultMaxBodySize = 1 << 20
+)
+
+// Handler is an http.Handler which executes GraphQL operations and streams their results as Server-Sent Events.
+//
+// In the distinct connections mode every GET or POST request accepting text/event-stream executes one operation.
+// In the single connection mode a PUT request reserves a stream and returns its token. The stream is then opened with
+// a GET request and operations are started with POST and stopped with DELETE requests carrying the token. The
+// operations run with the values of the context of their POST request until they are stopped or the stream is
+// closed.
+type Handler struct {
+	Schema *graphql.Schema
+	// KeepAlive is the interval of the comments sent to keep idle streams open through proxies.
+	// The default is 0 which disables keep-alive comments.
+	KeepAlive time.Duration
+	// ReservationTimeout is the time within which a reserved stream must be opened before its reservation is
+	// removed. The default is DefaultReservationTimeout.
+	ReservationTimeout time.Duration
+	// MaxReservations is the number of reserved streams which are not open yet. Further reservations are refused
+	// with 503 Service Unavailable. The default is DefaultMaxReservations.
+	MaxReservations int
+	// MaxBodySize is the maximum allowed size of a request body in bytes. Larger requests are refused with
+	// 413 Request Entity Too Large. It defaults to DefaultMaxBodySize, a negative value disables the limit.
+	MaxBodySize int64
+
+	mu       sync.Mutex
+	streams  map[string]*stream
+	reserved int // the number of streams which are not open yet
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		h.reserve(w)
+		return
+	}
+
+	if token := streamToken(r); token != "" {
+		switch r.Method {
+		case http.MethodGet:
+			h.serveStream(w, r, token)
+		case http.MethodPost:
+			h.startOperation(w, r, token)
+		case http.MethodDelete:
+			h.stopOperation(w, r, token)
+		default:
+			w.Header().Set("Allow", "GET, POST, PUT, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "only text/event-stream responses are supported", http.StatusNotAcceptable)
+		return
+	}
+	h.serveDistinct(w, r)
+}
+
+// serveDistinct executes a single operation and streams its results in the response of the request.
+func (h *Handler) serveDistinct(w http.ResponseWriter, r *http.Request) {
+	p, status, err := h.readParams(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	es, err := newEventStream(w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// The request context is cancelled when the client disconnects, which stops the subscription.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	responses, err := h.subscribe(ctx, p)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	es.open()
+
+	var keepAlive <-chan time.Time
+	if h.KeepAlive > 0 {
+		ticker := time.NewTicker(h.KeepAlive)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+
+	for {
+		select {
+		case resp, ok := <-responses:
+			if !ok {
+				es.send(eventComplete, nil)
+				return
+			}
+			if err := es.send(eventNext, resp); err != nil {
+				return
+			}
+		case <-keepAlive:
+			if err := es.comment(); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *Handler) subscribe(ctx context.Context, p *graphql.Request) (<-chan interface{}, error) {
+	if _, ok := h.Schema.AST().RootOperationTypes["subscription"]; !ok {
+		// Subscribe refuses to run without a subscription root type, but queries
+		// and mutations must still be served over the stream.
+		out := make(chan interface{}, 1)
+		out <- h.Schema.ExecRequest(ctx, p)
+		close(out)
+		return out, nil
+	}
+	return h.Schema.SubscribeRequest(ctx, p)
+}
+
+// stream is a reserved event stream of the single connection mode.
+type stream struct {
+	ctx       context.Context // the context of the GET request of the open stream
+	events    chan event
+	expiry    *time.Timer
+	mu        sync.Mutex
+	connected bool
+	ops       map[string]context.CancelFunc
+}
+
+type event struct {
+	name string
+	data interface{}
+}
+
+type operationEvent struct {
+	ID      string      `json:"id"`
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+func (h *Handler) reserve(w http.ResponseWriter) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	token := hex.EncodeToString(b)
+
+	maxReservations := h.MaxReservations
+	if maxReservations <= 0 {
+		maxReservations = DefaultMaxReservations
+	}
+	timeout := h.ReservationTimeout
+	if timeout <= 0 {
+		timeout = DefaultReservationTimeout
+	}
+
+	h.mu.Lock()
+	if h.reserved >= maxReservations {
+		h.mu.Unlock()
+		http.Error(w, "too many reserved streams", http.StatusServiceUnavailable)
+		return
+	}
+	if h.streams == nil {
+		h.streams = make(map[string]*stream)
+	}
+	s := &stream{
+		events: make(chan event),
+		ops:    make(map[string]context.CancelFunc),
+	}
+	s.expiry = time.AfterFunc(timeout, func() { h.expire(token, s) })
+	h.streams[token] = s
+	h.reserved++
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(token))
+}
+
+// expire removes the reserved stream if it was not opened.
+func (h *Handler) expire(token string, s *stream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.streams[token] != s {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.connected {
+		delete(h.streams, token)
+		h.reserved--
+	}
+}
+
+// connect opens the reserved stream with the context of the GET request. It returns the HTTP status of the error if
+// the stream can not be opened.
+func (h *Handler) connect(ctx context.Context, token string) (*stream, int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.streams[token]
+	if s == nil {
+		return nil, http.StatusNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.connected {
+		return nil, http.StatusConflict
+	}
+	s.expiry.Stop()
+	s.ctx = ctx
+	s.connected = true
+	h.reserved--
+	return s, 0
+}
+
+func (h *Handler) lookup(token string) *stream {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.streams[token]
+}
+
+// serveStream delivers the events of all operations started with the token until the client disconnects.
+func (h *Handler) serveStream(w http.ResponseWriter, r *http.Request, token string) {
+	// The operations of the stream are stopped when the client disconnects or the stream fails.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	s, status := h.connect(ctx, token)
+	switch status {
+	case http.StatusNotFound:
+		http.Error(w, "stream not found", status)
+		return
+	case http.StatusConflict:
+		http.Error(w, "stream already open", status)
+		return
+	}
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.streams, token)
+		h.mu.Unlock()
+	}()
+
+	es, err := newEventStream(w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	es.open()
+
+	var keepAlive <-chan time.Time
+	if h.KeepAlive > 0 {
+		ticker := time.NewTicker(h.KeepAlive)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+
+	for {
+		select {
+		case e := <-s.events:
+			if err := es.send(e.name, e.data); err != nil {
+				return
+			}
+		case <-keepAlive:
+			if err := es.comment(); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *Handler) startOperation(w http.ResponseWriter, r *http.Request, token string) {
+	s := h.lookup(token)
+	if s == nil {
+		http.Error(w, "stream not found", http.StatusNotFound)
+		return
+	}
+
+	p, status, err := h.readParams(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	id, _ := p.Extensions["operationId"].(string)
+	if id == "" {
+		http.Error(w, "operation ID is missing", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	if !s.connected {
+		s.mu.Unlock()
+		http.Error(w, "stream is not open", http.StatusConflict)
+		return
+	}
+	ctx, cancel := context.WithCancel(operationContext{Context: s.ctx, values: r.Context()})
+	if _, ok := s.ops[id]; ok {
+		s.mu.Unlock()
+		cancel()
+		http.Error(w, fmt.Sprintf("operation with ID %q already exists", id), http.StatusConflict)
+		return
+	}
+	s.ops[id] = cancel
+	s.mu.Unlock()
+
+	responses, err := h.subscribe(ctx, p)
+	if err != nil {
+		s.stop(id)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	go func() {
+		for resp := range responses {
+			if !s.emit(ctx, event{eventNext, &operationEvent{ID: id, Payload: resp}}) {
+				break
+			}
+		}
+		if s.stop(id) {
+			s.emit(s.ctx, event{eventComplete, &operationEvent{ID: id}})
+		}
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) stopOperation(w http.ResponseWriter, r *http.Request, token string) {
+	s := h.lookup(token)
+	if s == nil {
+		http.Error(w, "stream not found", http.StatusNotFound)
+		return
+	}
+
+	id := r.URL.Query().Get("operationId")
+	if id == "" {
+		http.Error(w, "operation ID is missing", http.StatusBadRequest)
+		return
+	}
+	s.stop(id)
+	w.WriteHeader(http.StatusOK)
+}
+
+// stop cancels the operation. It returns false if the operation was already stopped.
+func (s *stream) stop(id string) bool {
+	s.mu.Lock()
+	cancel, ok := s.ops[id]
+	delete(s.ops, id)
+	s.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+func (s *stream) emit(ctx context.Context, e event) bool {
+	select {
+	case s.events <- e:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// operationContext ends with the stream, but carries the values of the POST request which started the operation,
+// e.g. its authentication, before the values of the stream.
+type operationContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c operationContext) Value(key interface{}) interface{} {
+	if v := c.values.Value(key); v != nil {
+		return v
+	}
+	return c.Context.Value(key)
+}
+
+func streamToken(r *http.Request) string {
+	if token := r.Header.Get(TokenHeader); token != "" {
+		return token
+	}
+	return r.URL.Query().Get("token")
+}
+
+// readParams reads the operation from the body of a POST request or the query parameters of a GET request. It
+// returns the status of the response if the request is invalid.
+func (h *Handler) readParams(w http.ResponseWriter, r *http.Request) (*graphql.Request, int, error) {
+	var p graphql.Request
+	if r.Method == http.MethodPost {
+		limit := h.MaxBodySize
+		if limit == 0 {
+			limit = DefaultMaxBodySize
+		}
+		if limit > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			if strings.Contains(err.Error(), "request body too large") {
+				return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("the request body is too large")
+			}
+			return nil, http.StatusBadRequest, err
+		}
+		return &p, 0, nil
+	}
+
+	q := r.URL.Query()
+	p.Query = q.Get("query")
+	p.OperationName = q.Get("operationName")
+	p.DocumentID = q.Get("documentId")
+	if v := q.Get("variables"); v != "" {
+		if err := json.Unmarshal([]byte(v), &p.Variables); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid variables: %s", err)
+		}
+	}
+	if v := q.Get("extensions"); v != "" {
+		if err := json.Unmarshal([]byte(v), &p.Extensions); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid extensions: %s", err)
+		}
+	}
+	return &p, 0, nil
+}
+
+type eventStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func newEventStream(w http.ResponseWriter) (*eventStream, error) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("streaming is not supported by the response writer")
+	}
+	return &eventStream{w: w, flusher: f}, nil
+}
+
+func (es *eventStream) open() {
+	h := es.w.Header()
+	h.Set("Content-Type", "text/event-stream; charset=utf-8")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	es.w.WriteHeader(http.StatusOK)
+	es.flusher.Flush()
+}
+
+func (es *eventStream) send(name string, data interface{}) error {
+	var payload []byte
+	if data != nil {
+		var err error
+		payload, err = json.Marshal(data)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(es.w, "event: %s\ndata: %s\n\n", name, payload); err != nil {
+		return err
+	}
+	es.flusher.Flush()
+	return nil
+}
+
+func (es *eventStream) comment() error {
+	if _, err := es.w.Write([]byte(":\n\n")); err != nil {
+		return err
+	}
+	es.flusher.Flush()
+	return nil
+}
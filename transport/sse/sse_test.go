@@ -0,0 +1,306 @@
+package sse_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/transport/sse"
+)
+
+const schema = `
+	type Query {
+		hello: String!
+		user: String!
+	}
+
+	type Subscription {
+		count(to: Int!): Int!
+		forever: Int!
+	}
+`
+
+type resolver struct {
+	stopped chan struct{}
+}
+
+func (*resolver) Hello() string {
+	return "Hello world!"
+}
+
+type userKey struct{}
+
+func (*resolver) User(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
+
+// withUser sets the user of the X-User header in the request context.
+func withUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, r.Header.Get("X-User"))))
+	})
+}
+
+func (*resolver) Count(ctx context.Context, args struct{ To int32 }) <-chan int32 {
+	c := make(chan int32)
+	go func() {
+		defer close(c)
+		for i := int32(1); i <= args.To; i++ {
+			select {
+			case c <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c
+}
+
+func (r *resolver) Forever(ctx context.Context) <-chan int32 {
+	c := make(chan int32)
+	go func() {
+		defer close(r.stopped)
+		for i := int32(1); ; i++ {
+			select {
+			case c <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c
+}
+
+type event struct {
+	name string
+	data string
+}
+
+func readEvent(t *testing.T, r *bufio.Reader) event {
+	t.Helper()
+	var e event
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			if e.name != "" {
+				return e
+			}
+		case strings.HasPrefix(line, "event: "):
+			e.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func expect(t *testing.T, r *bufio.Reader, name, data string) {
+	t.Helper()
+	if e := readEvent(t, r); e.name != name || e.data != data {
+		t.Fatalf("got event %q with data %s, want event %q with data %s", e.name, e.data, name, data)
+	}
+}
+
+func do(t *testing.T, method, u, body string, header map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, u, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestDistinctConnections(t *testing.T) {
+	srv := httptest.NewServer(&sse.Handler{Schema: graphql.MustParseSchema(schema, &resolver{})})
+	defer srv.Close()
+
+	t.Run("post", func(t *testing.T) {
+		resp := do(t, http.MethodPost, srv.URL, `{"query":"subscription { count(to: 2) }"}`, map[string]string{"Accept": "text/event-stream"})
+		defer resp.Body.Close()
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream; charset=utf-8" {
+			t.Fatalf("got content type %q", ct)
+		}
+		r := bufio.NewReader(resp.Body)
+		expect(t, r, "next", `{"data":{"count":1}}`)
+		expect(t, r, "next", `{"data":{"count":2}}`)
+		expect(t, r, "complete", "")
+	})
+
+	t.Run("get", func(t *testing.T) {
+		u := srv.URL + "?query=" + url.QueryEscape("query Q { hello }") + "&operationName=Q"
+		resp := do(t, http.MethodGet, u, "", map[string]string{"Accept": "text/event-stream"})
+		defer resp.Body.Close()
+		r := bufio.NewReader(resp.Body)
+		expect(t, r, "next", `{"data":{"hello":"Hello world!"}}`)
+		expect(t, r, "complete", "")
+	})
+
+	t.Run("not_acceptable", func(t *testing.T) {
+		resp := do(t, http.MethodPost, srv.URL, `{"query":"{ hello }"}`, map[string]string{"Accept": "application/json"})
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotAcceptable {
+			t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusNotAcceptable)
+		}
+	})
+}
+
+func TestDisconnectStopsSubscription(t *testing.T) {
+	res := &resolver{stopped: make(chan struct{})}
+	srv := httptest.NewServer(&sse.Handler{Schema: graphql.MustParseSchema(schema, res)})
+	defer srv.Close()
+
+	resp := do(t, http.MethodPost, srv.URL, `{"query":"subscription { forever }"}`, map[string]string{"Accept": "text/event-stream"})
+	r := bufio.NewReader(resp.Body)
+	expect(t, r, "next", `{"data":{"forever":1}}`)
+	resp.Body.Close()
+
+	select {
+	case <-res.stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the subscription was not cancelled after the client disconnected")
+	}
+}
+
+func TestSingleConnection(t *testing.T) {
+	srv := httptest.NewServer(&sse.Handler{Schema: graphql.MustParseSchema(schema, &resolver{})})
+	defer srv.Close()
+
+	resp := do(t, http.MethodPut, srv.URL, "", nil)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	token := map[string]string{sse.TokenHeader: string(b)}
+
+	resp = do(t, http.MethodPost, srv.URL, `{"query":"{ hello }","extensions":{"operationId":"1"}}`, token)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("got status %d for an operation before the stream is open, want %d", resp.StatusCode, http.StatusConflict)
+	}
+
+	stream := do(t, http.MethodGet, srv.URL, "", map[string]string{sse.TokenHeader: string(b), "Accept": "text/event-stream"})
+	defer stream.Body.Close()
+	r := bufio.NewReader(stream.Body)
+
+	second := do(t, http.MethodGet, srv.URL, "", map[string]string{sse.TokenHeader: string(b), "Accept": "text/event-stream"})
+	second.Body.Close()
+	if second.StatusCode != http.StatusConflict {
+		t.Fatalf("got status %d for a second stream, want %d", second.StatusCode, http.StatusConflict)
+	}
+
+	resp = do(t, http.MethodPost, srv.URL, `{"query":"subscription { count(to: 2) }","extensions":{"operationId":"1"}}`, token)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	expect(t, r, "next", `{"id":"1","payload":{"data":{"count":1}}}`)
+	expect(t, r, "next", `{"id":"1","payload":{"data":{"count":2}}}`)
+	expect(t, r, "complete", `{"id":"1"}`)
+
+	resp = do(t, http.MethodPost, srv.URL, `{"query":"subscription { count(to: 1000000) }","extensions":{"operationId":"2"}}`, token)
+	resp.Body.Close()
+	expect(t, r, "next", `{"id":"2","payload":{"data":{"count":1}}}`)
+
+	resp = do(t, http.MethodDelete, srv.URL+"?operationId=2", "", token)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	resp = do(t, http.MethodPost, srv.URL, `{"query":"{ hello }","extensions":{"operationId":"3"}}`, token)
+	resp.Body.Close()
+	for {
+		e := readEvent(t, r)
+		if e.data == `{"id":"2"}` {
+			t.Fatal("an operation stopped by the client must not be completed")
+		}
+		if e.name == "complete" && e.data == `{"id":"3"}` {
+			return
+		}
+	}
+}
+
+// reserve reserves a stream and returns its token.
+func reserve(t *testing.T, u string) string {
+	t.Helper()
+	resp := do(t, http.MethodPut, u, "", nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	return string(b)
+}
+
+func TestReservations(t *testing.T) {
+	srv := httptest.NewServer(&sse.Handler{
+		Schema:             graphql.MustParseSchema(schema, &resolver{}),
+		ReservationTimeout: 50 * time.Millisecond,
+		MaxReservations:    2,
+	})
+	defer srv.Close()
+
+	expired := reserve(t, srv.URL)
+	reserve(t, srv.URL)
+	resp := do(t, http.MethodPut, srv.URL, "", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("got status %d for too many reservations, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	resp = do(t, http.MethodGet, srv.URL, "", map[string]string{sse.TokenHeader: expired, "Accept": "text/event-stream"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("got status %d for an expired reservation, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	// an open stream neither expires nor counts as a reservation
+	token := reserve(t, srv.URL)
+	stream := do(t, http.MethodGet, srv.URL, "", map[string]string{sse.TokenHeader: token, "Accept": "text/event-stream"})
+	defer stream.Body.Close()
+	reserve(t, srv.URL)
+	reserve(t, srv.URL)
+	time.Sleep(200 * time.Millisecond)
+	resp = do(t, http.MethodPost, srv.URL, `{"query":"{ hello }","extensions":{"operationId":"1"}}`, map[string]string{sse.TokenHeader: token})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	r := bufio.NewReader(stream.Body)
+	expect(t, r, "next", `{"id":"1","payload":{"data":{"hello":"Hello world!"}}}`)
+}
+
+func TestSingleConnectionContext(t *testing.T) {
+	srv := httptest.NewServer(withUser(&sse.Handler{Schema: graphql.MustParseSchema(schema, &resolver{})}))
+	defer srv.Close()
+
+	token := reserve(t, srv.URL)
+	stream := do(t, http.MethodGet, srv.URL, "", map[string]string{sse.TokenHeader: token, "Accept": "text/event-stream", "X-User": "stream"})
+	defer stream.Body.Close()
+
+	resp := do(t, http.MethodPost, srv.URL, `{"query":"{ user }","extensions":{"operationId":"1"}}`, map[string]string{sse.TokenHeader: token, "X-User": "alice"})
+	resp.Body.Close()
+	r := bufio.NewReader(stream.Body)
+	expect(t, r, "next", `{"id":"1","payload":{"data":{"user":"alice"}}}`)
+}
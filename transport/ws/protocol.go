@@ -0,0 +1,87 @@
+package ws
+
+import (
+	"encoding/json"
+)
+
+const (
+	// ProtocolGraphQLTransportWS is the sub-protocol implemented by the graphql-ws library.
+	// See https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md.
+	ProtocolGraphQLTransportWS = "graphql-transport-ws"
+	// ProtocolGraphQLWS is the legacy sub-protocol implemented by the subscriptions-transport-ws library.
+	// See https://github.com/apollographql/subscriptions-transport-ws/blob/master/PROTOCOL.md.
+	ProtocolGraphQLWS = "graphql-ws"
+)
+
+// Message types shared by both protocols.
+const (
+	msgConnectionInit = "connection_init"
+	msgConnectionAck  = "connection_ack"
+	msgError          = "error"
+	msgComplete       = "complete"
+)
+
+// Message types of the graphql-transport-ws protocol.
+const (
+	msgPing      = "ping"
+	msgPong      = "pong"
+	msgSubscribe = "subscribe"
+	msgNext      = "next"
+)
+
+// Message types of the legacy graphql-ws protocol.
+const (
+	msgConnectionError     = "connection_error"
+	msgConnectionKeepAlive = "ka"
+	msgConnectionTerminate = "connection_terminate"
+	msgStart               = "start"
+	msgStop                = "stop"
+	msgData                = "data"
+)
+
+// Close codes of the graphql-transport-ws protocol.
+const (
+	closeBadRequest               = 4400
+	closeUnauthorized             = 4401
+	closeForbidden                = 4403
+	closeSubprotocolNotAcceptable = 4406
+	closeConnectionInitTimeout    = 4408
+	closeSubscriberAlreadyExists  = 4409
+	closeTooManyInitRequests      = 4429
+)
+
+type message struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// protocol maps the generic operation life cycle onto the message types of a sub-protocol.
+type protocol struct {
+	name      string
+	subscribe string
+	stop      string
+	next      string
+	keepAlive string
+}
+
+var protocols = map[string]*protocol{
+	ProtocolGraphQLTransportWS: {
+		name:      ProtocolGraphQLTransportWS,
+		subscribe: msgSubscribe,
+		stop:      msgComplete,
+		next:      msgNext,
+		keepAlive: msgPing,
+	},
+	ProtocolGraphQLWS: {
+		name:      ProtocolGraphQLWS,
+		subscribe: msgStart,
+		stop:      msgStop,
+		next:      msgData,
+		keepAlive: msgConnectionKeepAlive,
+	},
+}
+
+func (p *protocol) legacy() bool {
+	return p.name == ProtocolGraphQLWS
+}
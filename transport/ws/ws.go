@@ -0,0 +1,397 @@
+// Package ws implements a WebSocket transport for GraphQL operations. It speaks both the
+// graphql-transport-ws protocol and the legacy graphql-ws protocol of subscriptions-transport-ws.
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+type ctxKey string
+
+const initPayloadKey ctxKey = "initPayload"
+
+// defaultInitTimeout is the time a client has to send connection_init when [Handler.InitTimeout] is not set.
+const defaultInitTimeout = 10 * time.Second
+
+// InitFunc is called with the payload of the connection_init message. The returned context is used as the
+// parent context of every operation started on the connection. Returning an error rejects the connection.
+type InitFunc func(ctx context.Context, payload map[string]interface{}) (context.Context, error)
+
+// InitPayloadFromContext returns the connection_init payload sent by the client of the connection
+// which started the operation. It is typically used to read authentication tokens in resolvers.
+func InitPayloadFromContext(ctx context.Context) map[string]interface{} {
+	p, _ := ctx.Value(initPayloadKey).(map[string]interface{})
+	return p
+}
+
+// Handler is an http.Handler which upgrades requests to WebSocket connections and executes GraphQL operations
+// sent over them. Subscriptions are driven by [graphql.Schema.Subscribe], queries and mutations are answered
+// with a single result followed by a complete message. Clients must negotiate one of the sub-protocols,
+// connections without a supported sub-protocol are closed with the code 4406.
+type Handler struct {
+	Schema *graphql.Schema
+	// InitFunc optionally validates the connection_init payload, e.g. to authenticate the client.
+	InitFunc InitFunc
+	// CheckOrigin returns true if the request Origin header is acceptable. If it is nil, requests
+	// with an Origin header which doesn't match the Host header are rejected.
+	CheckOrigin func(r *http.Request) bool
+	// InitTimeout is the time a client has to send connection_init after connecting. It defaults to 10 seconds.
+	InitTimeout time.Duration
+	// KeepAlive is the interval of the keep-alive messages sent to the client: ping for graphql-transport-ws
+	// and ka for graphql-ws. The default is 0 which disables keep-alive messages.
+	KeepAlive time.Duration
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{
+		CheckOrigin:  h.CheckOrigin,
+		Subprotocols: []string{ProtocolGraphQLTransportWS, ProtocolGraphQLWS},
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has already replied with an HTTP error
+		return
+	}
+
+	c := &connection{
+		handler: h,
+		conn:    conn,
+		ops:     make(map[string]context.CancelFunc),
+	}
+	proto, ok := protocols[conn.Subprotocol()]
+	if !ok {
+		c.close(closeSubprotocolNotAcceptable, "Subprotocol not acceptable")
+		return
+	}
+	c.proto = proto
+	c.serve(r.Context())
+}
+
+type connection struct {
+	handler *Handler
+	conn    *websocket.Conn
+	proto   *protocol
+	ctx     context.Context
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	ops     map[string]context.CancelFunc
+	acked   bool
+	wg      sync.WaitGroup
+}
+
+func (c *connection) serve(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		c.wg.Wait()
+		c.conn.Close()
+	}()
+
+	timeout := c.handler.InitTimeout
+	if timeout == 0 {
+		timeout = defaultInitTimeout
+	}
+	initTimer := time.AfterFunc(timeout, func() {
+		if !c.isAcked() {
+			c.close(closeConnectionInitTimeout, "Connection initialisation timeout")
+		}
+	})
+	defer initTimer.Stop()
+
+	if c.handler.KeepAlive > 0 {
+		c.wg.Add(1)
+		go c.keepAlive(ctx)
+	}
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.reject("", closeBadRequest, "Invalid message received")
+			if !c.proto.legacy() {
+				return
+			}
+			continue
+		}
+
+		if !c.handle(ctx, &msg) {
+			return
+		}
+	}
+}
+
+// handle processes a single client message. It returns false if the connection must be closed.
+func (c *connection) handle(ctx context.Context, msg *message) bool {
+	switch msg.Type {
+	case msgConnectionInit:
+		return c.init(ctx, msg)
+
+	case msgPing:
+		if !c.proto.legacy() {
+			c.send("", msgPong, msg.Payload)
+		}
+		return true
+
+	case msgPong:
+		return true
+
+	case c.proto.subscribe:
+		return c.start(msg)
+
+	case c.proto.stop:
+		c.stop(msg.ID)
+		return true
+
+	case msgConnectionTerminate:
+		if c.proto.legacy() {
+			return false
+		}
+		fallthrough
+
+	default:
+		c.reject(msg.ID, closeBadRequest, fmt.Sprintf("Unexpected message of type %q received", msg.Type))
+		return c.proto.legacy()
+	}
+}
+
+func (c *connection) init(ctx context.Context, msg *message) bool {
+	if c.isAcked() {
+		if c.proto.legacy() {
+			return true
+		}
+		c.close(closeTooManyInitRequests, "Too many initialisation requests")
+		return false
+	}
+
+	var payload map[string]interface{}
+	if len(msg.Payload) != 0 {
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			c.reject("", closeBadRequest, "Invalid connection_init payload")
+			return false
+		}
+	}
+
+	ctx = context.WithValue(ctx, initPayloadKey, payload)
+	if c.handler.InitFunc != nil {
+		var err error
+		ctx, err = c.handler.InitFunc(ctx, payload)
+		if err != nil {
+			if c.proto.legacy() {
+				c.send("", msgConnectionError, map[string]interface{}{"message": err.Error()})
+				c.close(websocket.CloseNormalClosure, "")
+			} else {
+				c.close(closeForbidden, "Forbidden")
+			}
+			return false
+		}
+	}
+
+	c.ctx = ctx
+	c.mu.Lock()
+	c.acked = true
+	c.mu.Unlock()
+
+	c.send("", msgConnectionAck, nil)
+	if c.proto.legacy() && c.handler.KeepAlive > 0 {
+		c.send("", msgConnectionKeepAlive, nil)
+	}
+	return true
+}
+
+func (c *connection) start(msg *message) bool {
+	if !c.isAcked() {
+		c.reject(msg.ID, closeUnauthorized, "Unauthorized")
+		return c.proto.legacy()
+	}
+
+	var payload graphql.Request
+	if msg.ID == "" || json.Unmarshal(msg.Payload, &payload) != nil {
+		c.reject(msg.ID, closeBadRequest, "Invalid subscribe message")
+		return c.proto.legacy()
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.mu.Lock()
+	if _, ok := c.ops[msg.ID]; ok {
+		c.mu.Unlock()
+		cancel()
+		c.reject(msg.ID, closeSubscriberAlreadyExists, fmt.Sprintf("Subscriber for %s already exists", msg.ID))
+		return c.proto.legacy()
+	}
+	c.ops[msg.ID] = cancel
+	c.mu.Unlock()
+
+	c.wg.Add(1)
+	go c.run(ctx, msg.ID, &payload)
+	return true
+}
+
+func (c *connection) run(ctx context.Context, id string, payload *graphql.Request) {
+	defer c.wg.Done()
+
+	responses, err := c.subscribe(ctx, payload)
+	if err != nil {
+		c.finish(id, false)
+		c.sendError(id, errors.Errorf("%s", err))
+		return
+	}
+
+	// A single response without data means that the operation failed before its execution, e.g. because of parse
+	// or validation errors, which graphql-transport-ws reports with an error message instead of a result. Such a
+	// response is sent on a channel which is already closed.
+	var received []interface{}
+	if resp, ok := <-responses; ok {
+		received = append(received, resp)
+		if errs := requestErrors(resp); errs != nil && !c.proto.legacy() {
+			select {
+			case next, ok := <-responses:
+				if !ok {
+					c.finish(id, false)
+					c.send(id, msgError, errs)
+					return
+				}
+				received = append(received, next)
+			default:
+			}
+		}
+	}
+	for _, resp := range received {
+		c.send(id, c.proto.next, resp)
+	}
+	for resp := range responses {
+		c.send(id, c.proto.next, resp)
+	}
+	c.finish(id, true)
+}
+
+// requestErrors returns the errors of a response without data.
+func requestErrors(resp interface{}) []*errors.QueryError {
+	r, ok := resp.(*graphql.Response)
+	if !ok || r.Data != nil {
+		return nil
+	}
+	return r.Errors
+}
+
+func (c *connection) subscribe(ctx context.Context, payload *graphql.Request) (<-chan interface{}, error) {
+	s := c.handler.Schema
+	if _, ok := s.AST().RootOperationTypes["subscription"]; !ok {
+		// Subscribe refuses to run without a subscription root type, but queries
+		// and mutations must still be served over the socket.
+		out := make(chan interface{}, 1)
+		out <- s.ExecRequest(ctx, payload)
+		close(out)
+		return out, nil
+	}
+	return s.SubscribeRequest(ctx, payload)
+}
+
+// finish unregisters the operation and notifies the client unless the client stopped it.
+func (c *connection) finish(id string, notify bool) {
+	c.mu.Lock()
+	cancel, ok := c.ops[id]
+	delete(c.ops, id)
+	c.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	cancel()
+	if notify {
+		c.send(id, msgComplete, nil)
+	}
+}
+
+func (c *connection) stop(id string) {
+	c.mu.Lock()
+	cancel, ok := c.ops[id]
+	delete(c.ops, id)
+	c.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+}
+
+func (c *connection) keepAlive(ctx context.Context) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.handler.KeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !c.isAcked() {
+				continue
+			}
+			if err := c.send("", c.proto.keepAlive, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *connection) isAcked() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.acked
+}
+
+// reject reports a protocol violation. The graphql-transport-ws protocol closes the socket with the
+// given code while the legacy protocol only reports an error to the client.
+func (c *connection) reject(id string, code int, reason string) {
+	if c.proto.legacy() {
+		c.sendError(id, errors.Errorf("%s", reason))
+		return
+	}
+	c.close(code, reason)
+}
+
+func (c *connection) sendError(id string, err *errors.QueryError) {
+	if c.proto.legacy() {
+		c.send(id, msgError, err)
+		return
+	}
+	c.send(id, msgError, []*errors.QueryError{err})
+}
+
+func (c *connection) send(id, typ string, payload interface{}) error {
+	msg := message{ID: id, Type: typ}
+	switch p := payload.(type) {
+	case nil:
+	case json.RawMessage:
+		msg.Payload = p
+	default:
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		msg.Payload = data
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteJSON(&msg)
+}
+
+func (c *connection) close(code int, reason string) {
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	c.conn.Close()
+}
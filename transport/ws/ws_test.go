@@ -0,0 +1,293 @@
+package ws_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/transport/ws"
+)
+
+const schema = `
+	type Query {
+		hello: String!
+	}
+
+	type Subscription {
+		count(to: Int!): Int!
+		whoami: String!
+	}
+`
+
+type resolver struct{}
+
+func (resolver) Hello() string {
+	return "Hello world!"
+}
+
+func (resolver) Count(ctx context.Context, args struct{ To int32 }) <-chan int32 {
+	c := make(chan int32)
+	go func() {
+		defer close(c)
+		for i := int32(1); i <= args.To; i++ {
+			select {
+			case c <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c
+}
+
+func (resolver) Whoami(ctx context.Context) <-chan string {
+	c := make(chan string, 1)
+	token, _ := ws.InitPayloadFromContext(ctx)["token"].(string)
+	c <- token
+	close(c)
+	return c
+}
+
+type message struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+func dial(t *testing.T, h *ws.Handler, protocol string) *websocket.Conn {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	d := websocket.Dialer{Subprotocols: []string{protocol}}
+	conn, _, err := d.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if conn.Subprotocol() != protocol {
+		t.Fatalf("got sub-protocol %q, want %q", conn.Subprotocol(), protocol)
+	}
+	return conn
+}
+
+func write(t *testing.T, conn *websocket.Conn, msg string) {
+	t.Helper()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func read(t *testing.T, conn *websocket.Conn) *message {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg message
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	return &msg
+}
+
+func expect(t *testing.T, conn *websocket.Conn, id, typ, payload string) {
+	t.Helper()
+	msg := read(t, conn)
+	if msg.ID != id || msg.Type != typ || string(msg.Payload) != payload {
+		t.Fatalf("got message {id: %q, type: %q, payload: %s}, want {id: %q, type: %q, payload: %s}", msg.ID, msg.Type, msg.Payload, id, typ, payload)
+	}
+}
+
+func expectClose(t *testing.T, conn *websocket.Conn, code int) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, code) {
+		t.Fatalf("got %v, want close error with code %d", err, code)
+	}
+}
+
+func TestGraphQLTransportWS(t *testing.T) {
+	s := graphql.MustParseSchema(schema, &resolver{})
+
+	t.Run("subscription", func(t *testing.T) {
+		conn := dial(t, &ws.Handler{Schema: s}, ws.ProtocolGraphQLTransportWS)
+		write(t, conn, `{"type":"connection_init"}`)
+		expect(t, conn, "", "connection_ack", "")
+		write(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"subscription { count(to: 3) }"}}`)
+		expect(t, conn, "1", "next", `{"data":{"count":1}}`)
+		expect(t, conn, "1", "next", `{"data":{"count":2}}`)
+		expect(t, conn, "1", "next", `{"data":{"count":3}}`)
+		expect(t, conn, "1", "complete", "")
+	})
+
+	t.Run("query", func(t *testing.T) {
+		conn := dial(t, &ws.Handler{Schema: s}, ws.ProtocolGraphQLTransportWS)
+		write(t, conn, `{"type":"connection_init"}`)
+		expect(t, conn, "", "connection_ack", "")
+		write(t, conn, `{"id":"q","type":"subscribe","payload":{"query":"{ hello }"}}`)
+		expect(t, conn, "q", "next", `{"data":{"hello":"Hello world!"}}`)
+		expect(t, conn, "q", "complete", "")
+	})
+
+	t.Run("validation_error", func(t *testing.T) {
+		conn := dial(t, &ws.Handler{Schema: s}, ws.ProtocolGraphQLTransportWS)
+		write(t, conn, `{"type":"connection_init"}`)
+		expect(t, conn, "", "connection_ack", "")
+		write(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"subscription { unknown }"}}`)
+		expect(t, conn, "1", "error", `[{"message":"Cannot query field \"unknown\" on type \"Subscription\".","locations":[{"line":1,"column":16}]}]`)
+		write(t, conn, `{"id":"2","type":"subscribe","payload":{"query":"{ hello"}}`)
+		expect(t, conn, "2", "error", `[{"message":"syntax error: unexpected \"\", expecting Ident","locations":[{"line":1,"column":8}]}]`)
+	})
+
+	t.Run("ping", func(t *testing.T) {
+		conn := dial(t, &ws.Handler{Schema: s}, ws.ProtocolGraphQLTransportWS)
+		write(t, conn, `{"type":"ping","payload":{"n":1}}`)
+		expect(t, conn, "", "pong", `{"n":1}`)
+	})
+
+	t.Run("client_complete", func(t *testing.T) {
+		conn := dial(t, &ws.Handler{Schema: s}, ws.ProtocolGraphQLTransportWS)
+		write(t, conn, `{"type":"connection_init"}`)
+		expect(t, conn, "", "connection_ack", "")
+		write(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"subscription { count(to: 1000000) }"}}`)
+		expect(t, conn, "1", "next", `{"data":{"count":1}}`)
+		write(t, conn, `{"id":"1","type":"complete"}`)
+		write(t, conn, `{"id":"2","type":"subscribe","payload":{"query":"{ hello }"}}`)
+		for {
+			msg := read(t, conn)
+			if msg.ID == "1" && msg.Type == "complete" {
+				t.Fatal("server must not complete an operation stopped by the client")
+			}
+			if msg.ID == "2" && msg.Type == "complete" {
+				return
+			}
+		}
+	})
+
+	t.Run("init_payload", func(t *testing.T) {
+		conn := dial(t, &ws.Handler{Schema: s}, ws.ProtocolGraphQLTransportWS)
+		write(t, conn, `{"type":"connection_init","payload":{"token":"secret"}}`)
+		expect(t, conn, "", "connection_ack", "")
+		write(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"subscription { whoami }"}}`)
+		expect(t, conn, "1", "next", `{"data":{"whoami":"secret"}}`)
+		expect(t, conn, "1", "complete", "")
+	})
+
+	t.Run("init_rejected", func(t *testing.T) {
+		h := &ws.Handler{
+			Schema: s,
+			InitFunc: func(ctx context.Context, payload map[string]interface{}) (context.Context, error) {
+				return nil, errors.New("invalid token")
+			},
+		}
+		conn := dial(t, h, ws.ProtocolGraphQLTransportWS)
+		write(t, conn, `{"type":"connection_init"}`)
+		expectClose(t, conn, 4403)
+	})
+
+	t.Run("subscribe_before_init", func(t *testing.T) {
+		conn := dial(t, &ws.Handler{Schema: s}, ws.ProtocolGraphQLTransportWS)
+		write(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"{ hello }"}}`)
+		expectClose(t, conn, 4401)
+	})
+
+	t.Run("init_timeout", func(t *testing.T) {
+		conn := dial(t, &ws.Handler{Schema: s, InitTimeout: 10 * time.Millisecond}, ws.ProtocolGraphQLTransportWS)
+		expectClose(t, conn, 4408)
+	})
+
+	t.Run("duplicate_id", func(t *testing.T) {
+		conn := dial(t, &ws.Handler{Schema: s}, ws.ProtocolGraphQLTransportWS)
+		write(t, conn, `{"type":"connection_init"}`)
+		expect(t, conn, "", "connection_ack", "")
+		write(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"subscription { count(to: 1000000) }"}}`)
+		write(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"subscription { count(to: 1000000) }"}}`)
+		for {
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			_, _, err := conn.ReadMessage()
+			if err != nil {
+				if !websocket.IsCloseError(err, 4409) {
+					t.Fatalf("got %v, want close error with code 4409", err)
+				}
+				return
+			}
+		}
+	})
+
+	t.Run("keep_alive", func(t *testing.T) {
+		conn := dial(t, &ws.Handler{Schema: s, KeepAlive: 10 * time.Millisecond}, ws.ProtocolGraphQLTransportWS)
+		write(t, conn, `{"type":"connection_init"}`)
+		expect(t, conn, "", "connection_ack", "")
+		expect(t, conn, "", "ping", "")
+	})
+}
+
+func TestGraphQLWS(t *testing.T) {
+	s := graphql.MustParseSchema(schema, &resolver{})
+
+	t.Run("subscription", func(t *testing.T) {
+		conn := dial(t, &ws.Handler{Schema: s}, ws.ProtocolGraphQLWS)
+		write(t, conn, `{"type":"connection_init","payload":{}}`)
+		expect(t, conn, "", "connection_ack", "")
+		write(t, conn, `{"id":"1","type":"start","payload":{"query":"subscription { count(to: 2) }"}}`)
+		expect(t, conn, "1", "data", `{"data":{"count":1}}`)
+		expect(t, conn, "1", "data", `{"data":{"count":2}}`)
+		expect(t, conn, "1", "complete", "")
+	})
+
+	t.Run("keep_alive", func(t *testing.T) {
+		conn := dial(t, &ws.Handler{Schema: s, KeepAlive: time.Minute}, ws.ProtocolGraphQLWS)
+		write(t, conn, `{"type":"connection_init"}`)
+		expect(t, conn, "", "connection_ack", "")
+		expect(t, conn, "", "ka", "")
+	})
+
+	t.Run("init_rejected", func(t *testing.T) {
+		h := &ws.Handler{
+			Schema: s,
+			InitFunc: func(ctx context.Context, payload map[string]interface{}) (context.Context, error) {
+				return nil, errors.New("invalid token")
+			},
+		}
+		conn := dial(t, h, ws.ProtocolGraphQLWS)
+		write(t, conn, `{"type":"connection_init"}`)
+		expect(t, conn, "", "connection_error", `{"message":"invalid token"}`)
+	})
+
+	t.Run("start_before_init", func(t *testing.T) {
+		conn := dial(t, &ws.Handler{Schema: s}, ws.ProtocolGraphQLWS)
+		write(t, conn, `{"id":"1","type":"start","payload":{"query":"{ hello }"}}`)
+		expect(t, conn, "1", "error", `{"message":"Unauthorized"}`)
+	})
+}
+
+func TestQueryWithoutSubscriptionRoot(t *testing.T) {
+	s := graphql.MustParseSchema(`type Query { hello: String! }`, &resolver{})
+	conn := dial(t, &ws.Handler{Schema: s}, ws.ProtocolGraphQLTransportWS)
+	write(t, conn, `{"type":"connection_init"}`)
+	expect(t, conn, "", "connection_ack", "")
+	write(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"{ hello }"}}`)
+	expect(t, conn, "1", "next", `{"data":{"hello":"Hello world!"}}`)
+	expect(t, conn, "1", "complete", "")
+}
+
+func TestSubprotocolNotAcceptable(t *testing.T) {
+	srv := httptest.NewServer(&ws.Handler{Schema: graphql.MustParseSchema(schema, &resolver{})})
+	defer srv.Close()
+
+	for _, protocols := range [][]string{nil, {"unknown"}} {
+		d := websocket.Dialer{Subprotocols: protocols}
+		conn, _, err := d.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectClose(t, conn, 4406)
+		conn.Close()
+	}
+}
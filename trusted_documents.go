@@ -0,0 +1,159 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/log"
+)
+
+// TrustedDocuments is an allowlist of documents which were registered ahead of time, e.g. at build time of the
+// clients. Documents are keyed by their ID as well as by the hex encoded SHA-256 hash of their content, so that
+// clients may refer to them by either one, or send a trusted document in full.
+type TrustedDocuments struct {
+	documents map[string]string
+}
+
+// NewTrustedDocuments returns an allowlist of the given documents keyed by their ID.
+func NewTrustedDocuments(documents map[string]string) *TrustedDocuments {
+	td := &TrustedDocuments{documents: make(map[string]string, 2*len(documents))}
+	for id, doc := range documents {
+		td.add(id, doc)
+	}
+	return td
+}
+
+func (td *TrustedDocuments) add(id, doc string) {
+	td.documents[id] = doc
+	td.documents[sha256Hash(doc)] = doc
+}
+
+// ParseTrustedDocuments parses a manifest of trusted documents. Supported are the persisted-queries.json files
+// of the Relay compiler, which map IDs to documents, and Apollo persisted query manifests.
+func ParseTrustedDocuments(manifest []byte) (*TrustedDocuments, error) {
+	var apollo struct {
+		Format     string `json:"format"`
+		Operations []struct {
+			ID   string `json:"id"`
+			Body string `json:"body"`
+		} `json:"operations"`
+	}
+	if err := json.Unmarshal(manifest, &apollo); err == nil && apollo.Format == "apollo-persisted-query-manifest" {
+		td := &TrustedDocuments{documents: make(map[string]string, 2*len(apollo.Operations))}
+		for _, op := range apollo.Operations {
+			td.add(op.ID, op.Body)
+		}
+		return td, nil
+	}
+
+	var relay map[string]string
+	if err := json.Unmarshal(manifest, &relay); err != nil {
+		return nil, fmt.Errorf("invalid trusted documents manifest: %s", err)
+	}
+	return NewTrustedDocuments(relay), nil
+}
+
+// LoadTrustedDocuments reads a manifest file of trusted documents. See [ParseTrustedDocuments] for the supported formats.
+func LoadTrustedDocuments(filename string) (*TrustedDocuments, error) {
+	manifest, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTrustedDocuments(manifest)
+}
+
+// Get returns the document for the ID or SHA-256 hash. IDs in the "sha256:<hash>" form are supported as well.
+func (td *TrustedDocuments) Get(id string) (string, bool) {
+	doc, ok := td.documents[strings.TrimPrefix(id, "sha256:")]
+	return doc, ok
+}
+
+// lookup returns the trusted document the request refers to.
+func (td *TrustedDocuments) lookup(req *Request) (string, bool) {
+	if req.DocumentID != "" {
+		return td.Get(req.DocumentID)
+	}
+	if pq, ok := req.Extensions["persistedQuery"].(map[string]interface{}); ok && req.Query == "" {
+		h, _ := pq["sha256Hash"].(string)
+		return td.Get(strings.ToLower(h))
+	}
+	return td.Get(sha256Hash(req.Query))
+}
+
+// UseTrustedDocuments restricts execution to the given trusted documents. Requests with other documents are
+// rejected before they are parsed. See [LogUntrustedDocuments] for a mode which only reports them.
+func UseTrustedDocuments(documents *TrustedDocuments) SchemaOpt {
+	return func(s *Schema) {
+		s.trustedDocuments = documents
+	}
+}
+
+// LogUntrustedDocuments changes [UseTrustedDocuments] to execute documents which are not trusted and to pass them
+// to the given func instead. This is useful to roll out trusted documents. If fn is nil, the documents are logged
+// by the logger of the schema if it implements [log.DocumentLogger].
+func LogUntrustedDocuments(fn func(ctx context.Context, query string)) SchemaOpt {
+	return func(s *Schema) {
+		if fn == nil {
+			fn = s.logUntrusted
+		}
+		s.logUntrustedDocument = fn
+	}
+}
+
+// logUntrusted logs an untrusted document with the logger of the schema.
+func (s *Schema) logUntrusted(ctx context.Context, query string) {
+	if l, ok := s.logger.(log.DocumentLogger); ok {
+		l.LogUntrustedDocument(ctx, query)
+	}
+}
+
+// TrustedDocument returns the trusted document for the ID or SHA-256 hash. It allows transports to inspect a
+// trusted document before executing it.
+func (s *Schema) TrustedDocument(id string) (string, bool) {
+	if s.trustedDocuments == nil {
+		return "", false
+	}
+	return s.trustedDocuments.Get(id)
+}
+
+// requestQuery returns the query of the request. It is looked up from the trusted documents or the automatic
+// persisted queries, see [Schema.persistedQuery].
+func (s *Schema) requestQuery(ctx context.Context, req *Request) (string, func(), *errors.QueryError) {
+	query, persist, untrusted, qErr := s.lookupQuery(ctx, req)
+	if untrusted {
+		s.logUntrustedDocument(ctx, query)
+	}
+	return query, persist, qErr
+}
+
+// lookupQuery returns the query of the request like requestQuery, but reports whether it is an untrusted document
+// to be logged instead of logging it.
+func (s *Schema) lookupQuery(ctx context.Context, req *Request) (query string, persist func(), untrusted bool, qErr *errors.QueryError) {
+	if s.trustedDocuments == nil || req.internal {
+		if req.DocumentID != "" {
+			return "", nil, false, errors.Errorf("documentId is not supported without trusted documents")
+		}
+		query, persist, qErr = s.persistedQuery(ctx, req)
+		return query, persist, false, qErr
+	}
+
+	if query, ok := s.trustedDocuments.lookup(req); ok {
+		return query, nil, false, nil
+	}
+	if req.DocumentID != "" {
+		return "", nil, false, persistedQueryError(PersistedQueryNotFound, errors.CodePersistedQueryNotFound)
+	}
+	if s.logUntrustedDocument == nil {
+		if req.Query == "" {
+			return "", nil, false, persistedQueryError(PersistedQueryNotFound, errors.CodePersistedQueryNotFound)
+		}
+		return "", nil, false, errors.Errorf("only trusted documents may be executed")
+	}
+
+	query, persist, qErr = s.persistedQuery(ctx, req)
+	return query, persist, qErr == nil, qErr
+}
@@ -0,0 +1,145 @@
+package graphql_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+const (
+	helloDocument = `query Hello { hello }`
+	relayManifest = `{"a1b2c3": "query Hello { hello }"}`
+)
+
+func TestParseTrustedDocuments(t *testing.T) {
+	t.Parallel()
+
+	apollo := `{
+		"format": "apollo-persisted-query-manifest",
+		"version": 1,
+		"operations": [{"id": "a1b2c3", "name": "Hello", "type": "query", "body": "query Hello { hello }"}]
+	}`
+	for name, manifest := range map[string]string{"relay": relayManifest, "apollo": apollo} {
+		td, err := graphql.ParseTrustedDocuments([]byte(manifest))
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		for _, id := range []string{"a1b2c3", sha256Hex(helloDocument), "sha256:" + sha256Hex(helloDocument)} {
+			if doc, ok := td.Get(id); !ok || doc != helloDocument {
+				t.Errorf("%s: got %q, %v for %q, want the document", name, doc, ok, id)
+			}
+		}
+	}
+
+	if _, err := graphql.ParseTrustedDocuments([]byte(`[]`)); err == nil {
+		t.Error("expected an error for an invalid manifest")
+	}
+}
+
+func TestLoadTrustedDocuments(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "persisted-queries.json")
+	if err := os.WriteFile(filename, []byte(relayManifest), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	td, err := graphql.LoadTrustedDocuments(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := td.Get("a1b2c3"); !ok {
+		t.Error("expected the document to be loaded")
+	}
+}
+
+func TestUseTrustedDocuments(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	td := graphql.NewTrustedDocuments(map[string]string{"a1b2c3": helloDocument})
+	s := graphql.MustParseSchema(helloSchema, &helloWorldResolver1{}, graphql.UseTrustedDocuments(td))
+
+	const hello = `{"data":{"hello":"Hello world!"}}`
+	const notFound = `{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`
+	tests := map[string]struct {
+		req  *graphql.Request
+		want string
+	}{
+		"document_id":      {req: &graphql.Request{DocumentID: "a1b2c3"}, want: hello},
+		"document_hash":    {req: &graphql.Request{DocumentID: "sha256:" + sha256Hex(helloDocument)}, want: hello},
+		"persisted_query":  {req: &graphql.Request{Extensions: persistedQuery(helloDocument)}, want: hello},
+		"trusted_query":    {req: &graphql.Request{Query: helloDocument}, want: hello},
+		"unknown_id":       {req: &graphql.Request{DocumentID: "unknown"}, want: notFound},
+		"unknown_hash":     {req: &graphql.Request{Extensions: persistedQuery(`{ hello }`)}, want: notFound},
+		"untrusted_query":  {req: &graphql.Request{Query: `{ hello }`}, want: `{"errors":[{"message":"only trusted documents may be executed"}]}`},
+		"untrusted_syntax": {req: &graphql.Request{Query: `{`}, want: `{"errors":[{"message":"only trusted documents may be executed"}]}`},
+	}
+	for name, tt := range tests {
+		if got := marshalResponse(t, s.ExecRequest(ctx, tt.req)); got != tt.want {
+			t.Errorf("%s: got %s, want %s", name, got, tt.want)
+		}
+	}
+
+	if got := marshalResponse(t, s.Exec(ctx, `{ hello }`, "", nil)); got != `{"errors":[{"message":"only trusted documents may be executed"}]}` {
+		t.Errorf("got %s for Exec with an untrusted query", got)
+	}
+	if _, err := s.ToJSON(); err != nil {
+		t.Errorf("ToJSON must not be restricted by trusted documents: %s", err)
+	}
+}
+
+func TestLogUntrustedDocuments(t *testing.T) {
+	t.Parallel()
+
+	var logged []string
+	td := graphql.NewTrustedDocuments(map[string]string{"a1b2c3": helloDocument})
+	s := graphql.MustParseSchema(helloSchema, &helloWorldResolver1{},
+		graphql.UseTrustedDocuments(td),
+		graphql.LogUntrustedDocuments(func(_ context.Context, query string) {
+			logged = append(logged, query)
+		}),
+	)
+
+	ctx := context.Background()
+	for _, q := range []string{helloDocument, `{ hello }`} {
+		if got, want := marshalResponse(t, s.Exec(ctx, q, "", nil)), `{"data":{"hello":"Hello world!"}}`; got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+	if len(logged) != 1 || logged[0] != `{ hello }` {
+		t.Errorf("got logged documents %q, want only the untrusted one", logged)
+	}
+}
+
+// documentLogger records the untrusted documents.
+type documentLogger struct {
+	documents []string
+}
+
+func (l *documentLogger) LogPanic(ctx context.Context, value interface{}) {}
+
+func (l *documentLogger) LogUntrustedDocument(ctx context.Context, query string) {
+	l.documents = append(l.documents, query)
+}
+
+func TestLogUntrustedDocuments_Logger(t *testing.T) {
+	t.Parallel()
+
+	l := &documentLogger{}
+	td := graphql.NewTrustedDocuments(map[string]string{"a1b2c3": helloDocument})
+	s := graphql.MustParseSchema(helloSchema, &helloWorldResolver1{},
+		graphql.UseTrustedDocuments(td),
+		graphql.LogUntrustedDocuments(nil),
+		graphql.Logger(l),
+	)
+
+	if got, want := marshalResponse(t, s.Exec(context.Background(), `{ hello }`, "", nil)), `{"data":{"hello":"Hello world!"}}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if len(l.documents) != 1 || l.documents[0] != `{ hello }` {
+		t.Errorf("got logged documents %q, want the untrusted one", l.documents)
+	}
+}
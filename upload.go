@@ -0,0 +1,45 @@
+package graphql
+
+import (
+	"fmt"
+	"io"
+)
+
+// Upload represents a file sent with a multipart request following the [GraphQL multipart request specification].
+// It has to be added to a schema via "scalar Upload" since it is not a predeclared GraphQL type like "ID".
+// Uploads can only be used as input values, the HTTP transport puts them into the variables of the operation.
+//
+// [GraphQL multipart request specification]: https://github.com/jaydenseric/graphql-multipart-request-spec
+type Upload struct {
+	// File streams the content of the uploaded file. It is closed by the transport once the operation completes.
+	File io.ReadSeeker
+	// Filename is the name of the file as sent by the client.
+	Filename string
+	// ContentType is the media type of the file as sent by the client.
+	ContentType string
+	// Size is the size of the file in bytes.
+	Size int64
+}
+
+// ImplementsGraphQLType maps this custom Go type
+// to the graphql scalar type in the schema.
+func (Upload) ImplementsGraphQLType(name string) bool {
+	return name == "Upload"
+}
+
+// UnmarshalGraphQL is a custom unmarshaler for Upload
+//
+// This function will be called whenever you use the
+// Upload scalar as an input
+func (u *Upload) UnmarshalGraphQL(input interface{}) error {
+	switch input := input.(type) {
+	case *Upload:
+		*u = *input
+		return nil
+	case Upload:
+		*u = input
+		return nil
+	default:
+		return fmt.Errorf("wrong type for Upload: %T, files must be sent with a multipart request", input)
+	}
+}
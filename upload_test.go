@@ -0,0 +1,60 @@
+package graphql_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/decode"
+)
+
+func TestUpload_ImplementsUnmarshaler(t *testing.T) {
+	// assert *Upload implements decode.Unmarshaler interface
+	var _ decode.Unmarshaler = (*graphql.Upload)(nil)
+}
+
+func TestUpload_ImplementsGraphQLType(t *testing.T) {
+	u := &graphql.Upload{}
+
+	if u.ImplementsGraphQLType("foobar") {
+		t.Error("Type *Upload must not claim to implement GraphQL type 'foobar'")
+	}
+
+	if !u.ImplementsGraphQLType("Upload") {
+		t.Error("Failed asserting *Upload implements GraphQL type Upload")
+	}
+}
+
+func TestUpload_UnmarshalGraphQL(t *testing.T) {
+	ref := &graphql.Upload{File: strings.NewReader("content"), Filename: "a.txt", ContentType: "text/plain", Size: 7}
+
+	tests := []struct {
+		name    string
+		input   interface{}
+		wantErr string
+	}{
+		{name: "pointer", input: ref},
+		{name: "value", input: *ref},
+		{name: "string", input: "a.txt", wantErr: "wrong type for Upload: string, files must be sent with a multipart request"},
+		{name: "nil", input: nil, wantErr: "wrong type for Upload: <nil>, files must be sent with a multipart request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var u graphql.Upload
+			err := u.UnmarshalGraphQL(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("UnmarshalGraphQL() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnmarshalGraphQL() error = %v", err)
+			}
+			if u != *ref {
+				t.Errorf("UnmarshalGraphQL() got = %+v, want = %+v", u, *ref)
+			}
+		})
+	}
+}